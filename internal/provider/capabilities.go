@@ -0,0 +1,76 @@
+// capabilities.go
+package provider
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// endpointVersions are the ZenML versions that added the endpoints of
+// resources that older servers don't serve, by collection. Endpoints that
+// all supported servers (ZenML >= 0.70.0) serve aren't listed.
+var endpointVersions = map[string]string{
+	"projects": "0.80.0",
+}
+
+// SupportsEndpoint reports whether the server serves the endpoints of a
+// collection, e.g. "models", along with the ZenML version that added them.
+// Servers whose version is unknown, or isn't a release version, are assumed
+// to serve every endpoint.
+func (c *Client) SupportsEndpoint(name string) (bool, string) {
+	required, ok := endpointVersions[name]
+	if !ok {
+		return true, ""
+	}
+	server, ok := parseVersion(c.ServerVersion)
+	if !ok {
+		return true, required
+	}
+	minimum, _ := parseVersion(required)
+	return slices.Compare(server[:], minimum[:]) >= 0, required
+}
+
+// requireEndpoint returns a CustomizeDiffFunc that fails the plan of a
+// resource if the server doesn't serve the endpoints of its collection,
+// instead of failing the apply with a bare 404.
+func requireEndpoint(name string) schema.CustomizeDiffFunc {
+	return func(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+		client, ok := m.(*Client)
+		if !ok {
+			return nil
+		}
+		if supported, required := client.SupportsEndpoint(name); !supported {
+			return fmt.Errorf("this resource requires ZenML >= %s, but the server at %s runs ZenML %s, which doesn't serve %s",
+				required, client.ServerURL, client.ServerVersion, name)
+		}
+		return nil
+	}
+}
+
+// parseVersion parses the major, minor and patch numbers of a version,
+// e.g. "0.58.2". Suffixes of pre-releases and builds, e.g. "0.58.2rc1",
+// are ignored.
+func parseVersion(version string) ([3]int, bool) {
+	var parsed [3]int
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+	if len(parts) < 2 {
+		return parsed, false
+	}
+	for i, part := range parts {
+		end := strings.IndexFunc(part, func(r rune) bool { return r < '0' || r > '9' })
+		if end >= 0 {
+			part = part[:end]
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return parsed, false
+		}
+		parsed[i] = n
+	}
+	return parsed, true
+}
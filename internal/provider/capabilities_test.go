@@ -0,0 +1,70 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    [3]int
+		ok      bool
+	}{
+		{"0.80.0", [3]int{0, 80, 0}, true},
+		{"v0.75.1", [3]int{0, 75, 1}, true},
+		{"0.81.0rc1", [3]int{0, 81, 0}, true},
+		{"0.80.2.dev0", [3]int{0, 80, 2}, true},
+		{"1.0", [3]int{1, 0, 0}, true},
+		{"", [3]int{}, false},
+		{"main", [3]int{}, false},
+		{"x.80.0", [3]int{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseVersion(tt.version)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("parseVersion(%q): expected %v %v, got %v %v", tt.version, tt.want, tt.ok, got, ok)
+		}
+	}
+}
+
+func TestRequireEndpoint(t *testing.T) {
+	tests := []struct {
+		serverVersion string
+		wantErr       bool
+	}{
+		{"0.75.0", true},
+		{"0.80.0", false},
+		{"0.81.1", false},
+		// Servers that don't report a release version get the benefit of
+		// the doubt
+		{"", false},
+		{"develop", false},
+	}
+	for _, tt := range tests {
+		client := NewClient("http://zenml.example.com", "", "test-token")
+		client.ServerVersion = tt.serverVersion
+
+		r := resourceProject()
+		config := terraform.NewResourceConfigRaw(map[string]interface{}{"name": "project"})
+		_, err := r.Diff(context.Background(), nil, config, client)
+		if tt.wantErr {
+			if err == nil || !strings.Contains(err.Error(), "requires ZenML >= 0.80.0") || !strings.Contains(err.Error(), "runs ZenML "+tt.serverVersion) {
+				t.Errorf("server %q: expected the plan to fail naming the required version, got %v", tt.serverVersion, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("server %q: unexpected error: %v", tt.serverVersion, err)
+		}
+	}
+
+	client := NewClient("http://zenml.example.com", "", "test-token")
+	client.ServerVersion = "0.70.0"
+	if supported, _ := client.SupportsEndpoint("stacks"); !supported {
+		t.Errorf("expected endpoints served by all supported servers to be supported")
+	}
+}
@@ -0,0 +1,127 @@
+// circuit_breaker.go
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ErrServerUnavailable is returned without sending the request while the
+// circuit breaker is open, see WithCircuitBreaker.
+var ErrServerUnavailable = errors.New("server unavailable")
+
+// Defaults of the circuit breaker.
+const (
+	defaultBreakerThreshold = 5
+	defaultBreakerWindow    = 30 * time.Second
+	defaultBreakerCooldown  = 30 * time.Second
+)
+
+// WithCircuitBreaker configures the circuit breaker, which stops sending
+// requests for cooldown once threshold requests in a row failed to reach
+// the server within window, so that a plan against a server that is down
+// fails fast instead of every resource retrying on its own. After the
+// cooldown, a single request probes the server: if it succeeds requests
+// are sent again, otherwise the breaker stays open for another cooldown.
+// A threshold of 0 disables the breaker.
+func WithCircuitBreaker(threshold int, window, cooldown time.Duration) ClientOption {
+	return func(c *Client) {
+		c.breaker = newCircuitBreaker(threshold, window, cooldown)
+	}
+}
+
+// circuitBreaker counts consecutive failures to reach the server. It is
+// shared by the clients derived with With, which talk to the same server.
+type circuitBreaker struct {
+	threshold int
+	window    time.Duration
+	cooldown  time.Duration
+
+	mu sync.Mutex
+	// failures is the number of consecutive failures, the first of which
+	// happened at firstFailure.
+	failures     int
+	firstFailure time.Time
+	// openedAt is set while the breaker is open.
+	openedAt time.Time
+	// probing is set while the request probing the server after the
+	// cooldown is in flight.
+	probing bool
+}
+
+func newCircuitBreaker(threshold int, window, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, window: window, cooldown: cooldown}
+}
+
+// allow returns an error wrapping ErrServerUnavailable if a request must
+// not be sent. A nil breaker allows every request.
+func (b *circuitBreaker) allow() error {
+	if b == nil || b.threshold <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openedAt.IsZero() {
+		return nil
+	}
+	if remaining := b.cooldown - time.Since(b.openedAt); remaining > 0 {
+		return fmt.Errorf("%w: %s in a row failed, not sending requests for another %s",
+			ErrServerUnavailable, pluralize(b.failures, "request"), remaining.Round(time.Second))
+	}
+	if b.probing {
+		return fmt.Errorf("%w: waiting for a request to reach the server again", ErrServerUnavailable)
+	}
+	b.probing = true
+	return nil
+}
+
+// record records the outcome of a request allowed by allow. Requests
+// canceled by the caller tell nothing about the server.
+func (b *circuitBreaker) record(ctx context.Context, resp *http.Response, err error) {
+	if b == nil || b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err != nil && ctx.Err() != nil {
+		b.probing = false
+		return
+	}
+	now := time.Now()
+	if !isUnreachable(resp, err) {
+		b.failures, b.openedAt, b.probing = 0, time.Time{}, false
+		return
+	}
+	if b.probing {
+		// The server is still down
+		b.failures++
+		b.openedAt, b.probing = now, false
+		return
+	}
+	if b.failures == 0 || now.Sub(b.firstFailure) > b.window {
+		b.failures, b.firstFailure = 0, now
+	}
+	b.failures++
+	if b.failures >= b.threshold && b.openedAt.IsZero() {
+		b.openedAt = now
+	}
+}
+
+// isUnreachable reports whether a request failed to reach the server: the
+// connection failed, or a proxy in front of the server reported it as down.
+func isUnreachable(resp *http.Response, err error) bool {
+	if err != nil {
+		var urlErr *url.Error
+		return errors.As(err, &urlErr)
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientCircuitBreaker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(StackResponse{ID: "stack-id", Name: "stack"})
+	}))
+	defer server.Close()
+
+	var down atomic.Bool
+	var sent int32
+	unreachable := WithRoundTripper(func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			atomic.AddInt32(&sent, 1)
+			if down.Load() {
+				return nil, errors.New("connection refused")
+			}
+			return next.RoundTrip(req)
+		})
+	})
+	const cooldown = 50 * time.Millisecond
+	client := NewClient(server.URL, "", "test-token", unreachable, WithCircuitBreaker(3, time.Minute, cooldown))
+	client.MaxRetries = 0
+	derived := client.With(WithHeader("X-Team", "ml"))
+
+	get := func(c *Client) error {
+		_, err := c.GetStack(context.Background(), "stack-id")
+		return err
+	}
+
+	// Sustained failures open the breaker after the threshold
+	down.Store(true)
+	for i := 0; i < 5; i++ {
+		err := get(client)
+		if fastFail := i >= 3; errors.Is(err, ErrServerUnavailable) != fastFail {
+			t.Fatalf("request %d: expected a fast failure %v, got %v", i, fastFail, err)
+		}
+	}
+	if err := get(derived); !errors.Is(err, ErrServerUnavailable) {
+		t.Errorf("expected derived clients to share the breaker, got %v", err)
+	}
+	if n := atomic.LoadInt32(&sent); n != 3 {
+		t.Errorf("expected 3 requests to be sent, got %d", n)
+	}
+
+	// After the cooldown a single request probes the server, which is
+	// still down
+	time.Sleep(cooldown + 10*time.Millisecond)
+	if err := get(client); err == nil || errors.Is(err, ErrServerUnavailable) {
+		t.Errorf("expected the probe to be sent and fail, got %v", err)
+	}
+	if err := get(client); !errors.Is(err, ErrServerUnavailable) {
+		t.Errorf("expected the breaker to open again after a failed probe, got %v", err)
+	}
+	if n := atomic.LoadInt32(&sent); n != 4 {
+		t.Errorf("expected only the probe to be sent, got %d requests", n)
+	}
+
+	// A successful probe closes the breaker
+	down.Store(false)
+	time.Sleep(cooldown + 10*time.Millisecond)
+	for i := 0; i < 3; i++ {
+		if err := get(client); err != nil {
+			t.Fatalf("expected the server to be used again, got %v", err)
+		}
+	}
+
+	// A disabled breaker sends every request
+	down.Store(true)
+	client = NewClient(server.URL, "", "test-token", unreachable, WithCircuitBreaker(0, 0, 0))
+	client.MaxRetries = 0
+	for i := 0; i < 5; i++ {
+		if err := get(client); errors.Is(err, ErrServerUnavailable) {
+			t.Fatalf("expected a disabled breaker not to fail fast, got %v", err)
+		}
+	}
+}
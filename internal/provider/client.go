@@ -2,12 +2,24 @@ package provider
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"mime"
 	"net/http"
 	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-log/tflog"
@@ -16,37 +28,1086 @@ import (
 type ListParams struct {
 	Page     int
 	PageSize int
-	Filter   map[string]string
+	// Filter maps fields to the values to filter on. A key may carry a
+	// filter operator after a colon, e.g. "name:contains", which is sent as
+	// the value prefix the API expects ("name=contains:value").
+	Filter map[string]string
+	// MultiFilter maps fields to several values to filter on, which are
+	// sent as repeated query parameters, e.g. {"tag": {"a", "b"}} as
+	// "tag=a&tag=b". Keys may carry an operator like in Filter.
+	MultiFilter map[string][]string
+	// Fields are the top-level fields of the listed items the caller
+	// needs, e.g. "id", "name" and "body". No ZenML endpoint supports
+	// projecting single fields, so they only decide whether the items are
+	// hydrated: the "metadata" and "resources" fields are only returned by
+	// hydrated lists, which are much larger. Empty fields leave hydration
+	// to the "hydrate" filter and the server's default.
+	Fields []string
+	// RawFilterKeys sends filter keys verbatim, without extracting
+	// operators from them.
+	RawFilterKeys bool
 }
 
+// filterOperators are the operators the API accepts as a filter value
+// prefix.
+var filterOperators = map[string]bool{
+	"equals":     true,
+	"notequals":  true,
+	"contains":   true,
+	"startswith": true,
+	"endswith":   true,
+	"oneof":      true,
+	"gte":        true,
+	"gt":         true,
+	"lte":        true,
+	"lt":         true,
+}
+
+// splitFilterKey splits a filter key into the field and the operator it
+// carries, if any. Keys with an unknown operator suffix are returned
+// unchanged, so fields that contain colons themselves are left intact.
+func splitFilterKey(key string) (field, operator string) {
+	i := strings.LastIndex(key, ":")
+	if i <= 0 || !filterOperators[key[i+1:]] {
+		return key, ""
+	}
+	return key[:i], key[i+1:]
+}
+
+// listQuery returns the query parameters of a list request with defaults
+// already applied to params. Keys and values are URL-encoded by the caller
+// through url.Values.Encode.
+func listQuery(params *ListParams) url.Values {
+	query := url.Values{}
+	query.Add("page", fmt.Sprintf("%d", params.Page))
+	query.Add("size", fmt.Sprintf("%d", params.PageSize))
+	add := func(k, v string) {
+		if params.RawFilterKeys {
+			query.Add(k, v)
+			return
+		}
+		if field, operator := splitFilterKey(k); operator != "" {
+			query.Add(field, operator+":"+v)
+			return
+		}
+		query.Add(k, v)
+	}
+	for k, v := range params.Filter {
+		add(k, v)
+	}
+	for k, values := range params.MultiFilter {
+		for _, v := range values {
+			add(k, v)
+		}
+	}
+	if len(params.Fields) > 0 {
+		query.Set("hydrate", strconv.FormatBool(slices.ContainsFunc(params.Fields, func(field string) bool {
+			return hydratedFields[field]
+		})))
+	}
+	return query
+}
+
+// hydratedFields are the fields of listed items that are only returned
+// when the items are hydrated.
+var hydratedFields = map[string]bool{
+	"metadata":  true,
+	"resources": true,
+}
+
+// filterHasField reports whether the filter filters on the given field,
+// with or without an operator in the key.
+func filterHasField(filter map[string]string, field string) bool {
+	for k := range filter {
+		if f, _ := splitFilterKey(k); f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Page size limits used when the server doesn't report its own.
+const (
+	defaultPageSize    = 100
+	defaultMaxPageSize = 1000
+)
+
+// listParams returns a copy of the given list parameters with defaults
+// applied and the page size clamped to the server's maximum. The caller's
+// parameters are never modified, so the same parameters can be shared
+// between concurrent requests.
+func (c *Client) listParams(params *ListParams) *ListParams {
+	p := ListParams{}
+	if params != nil {
+		p = *params
+	}
+	if p.Page <= 0 {
+		p.Page = 1
+	}
+	if p.PageSize <= 0 {
+		p.PageSize = c.DefaultPageSize
+	}
+	if c.MaxPageSize > 0 && p.PageSize > c.MaxPageSize {
+		p.PageSize = c.MaxPageSize
+	}
+	return &p
+}
+
+// listPage fetches a page of the list endpoint at path. Servers may be
+// configured with a lower page size limit than they report, in which case
+// they reject larger pages with a 422. The request is then retried once with
+// the largest page size the server accepts.
+func listPage[T any](ctx context.Context, c *Client, path string, params *ListParams) (*Page[T], error) {
+	params = c.listParams(params)
+
+	resp, _, err := c.doRequest(ctx, "GET", path+"?"+listQuery(params).Encode(), nil)
+	if limit := pageSizeLimit(err); limit > 0 && limit < params.PageSize {
+		tflog.Warn(ctx, fmt.Sprintf("[ZENML] Page size %d rejected by the server, retrying with %d", params.PageSize, limit))
+		params.PageSize = limit
+		resp, _, err = c.doRequest(ctx, "GET", path+"?"+listQuery(params).Encode(), nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeResponse[Page[T]](c, resp)
+}
+
+// pageSizeLimitPattern matches the limit in the validation error of a page
+// size that is too large, e.g. "ensure this value is less than or equal to
+// 1000" or "Input should be less than or equal to 1000".
+var pageSizeLimitPattern = regexp.MustCompile(`less than or equal to (\d+)`)
+
+// pageSizeLimit returns the page size limit stated by a 422 error that
+// rejected the page size of a list request, or 0 for any other error.
+func pageSizeLimit(err error) int {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnprocessableEntity {
+		return 0
+	}
+	var payload struct {
+		Detail []struct {
+			Loc []interface{} `json:"loc"`
+			Msg string        `json:"msg"`
+		} `json:"detail"`
+	}
+	if err := json.Unmarshal(apiErr.Body, &payload); err != nil {
+		return 0
+	}
+	for _, d := range payload.Detail {
+		if len(d.Loc) == 0 || d.Loc[len(d.Loc)-1] != "size" {
+			continue
+		}
+		if m := pageSizeLimitPattern.FindStringSubmatch(d.Msg); m != nil {
+			limit, _ := strconv.Atoi(m[1])
+			return limit
+		}
+	}
+	return 0
+}
+
+// NextPage returns the list parameters for the page following current, or
+// nil if current is the last page. The returned parameters are a copy, so
+// params is not modified. A typical loop over all pages looks like:
+//
+//	for p := params; p != nil; p = NextPage(p, page) {
+//		page, err = c.ListStacks(ctx, p)
+//		...
+//	}
+func NextPage[T any](params *ListParams, current *Page[T]) *ListParams {
+	// Stop on empty pages too, to never loop forever on an inconsistent page
+	if !current.HasNext() || len(current.Items) == 0 {
+		return nil
+	}
+	next := ListParams{}
+	if params != nil {
+		next = *params
+	}
+	if next.PageSize <= 0 {
+		// Keep the page size the server applied to the current page
+		next.PageSize = current.MaxSize
+	}
+	next.Page = current.Index + 1
+	return &next
+}
+
+// forEach fetches the pages of a list operation one at a time and calls fn
+// for every item, so that only a single page is held in memory. A page that
+// fails with a transient error is fetched again, with the client's retry
+// settings. It stops at the first error returned by fn and returns it.
+//
+// Pages are offsets into the result, so items created or deleted while the
+// pages are fetched shift the following pages. Unless the caller sorts the
+// result, it is sorted by creation time, so that items created in between
+// are appended to the last page instead of shifting the others. As other
+// sort orders and deletions can still shift items onto the next page, items
+// with an ID that was already passed to fn are skipped.
+func forEach[T any](ctx context.Context, c *Client, params *ListParams, list func(context.Context, *ListParams) (*Page[T], error), fn func(T) error) error {
+	var page *Page[T]
+	var err error
+	params = stableListParams(params)
+	seen := map[string]bool{}
+	for p := params; p != nil; p = NextPage(p, page) {
+		page, err = listWithRetries(ctx, c, p, list)
+		if err != nil {
+			return err
+		}
+		for _, item := range page.Items {
+			if id := itemID(item); id != "" {
+				if seen[id] {
+					tflog.Debug(ctx, fmt.Sprintf("[ZENML] Skipping %s listed again on page %d", id, page.Index))
+					continue
+				}
+				seen[id] = true
+			}
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// stableListParams returns a copy of params that sorts the result by
+// creation time, unless params already sorts it.
+func stableListParams(params *ListParams) *ListParams {
+	p := ListParams{}
+	if params != nil {
+		p = *params
+	}
+	if filterHasField(p.Filter, "sort_by") {
+		return &p
+	}
+	filter := map[string]string{"sort_by": "asc:created"}
+	for k, v := range p.Filter {
+		filter[k] = v
+	}
+	p.Filter = filter
+	return &p
+}
+
+// itemID returns the ID field of a listed item, or "" if it has none.
+func itemID(item interface{}) string {
+	v := reflect.Indirect(reflect.ValueOf(item))
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	id := v.FieldByName("ID")
+	if !id.IsValid() || id.Kind() != reflect.String {
+		return ""
+	}
+	return id.String()
+}
+
+// listWithRetries fetches a single page of a list operation, retrying it
+// with exponential backoff while it fails with a transient error.
+func listWithRetries[T any](ctx context.Context, c *Client, params *ListParams, list func(context.Context, *ListParams) (*Page[T], error)) (*Page[T], error) {
+	for attempt := 0; ; attempt++ {
+		page, err := list(ctx, params)
+		if err == nil || attempt >= c.MaxRetries || !isTransientError(err) {
+			return page, err
+		}
+		wait := c.backoff(attempt)
+		tflog.Warn(ctx, fmt.Sprintf("[ZENML] Fetching page %d failed, retrying in %s: %v", params.Page, wait, err))
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// listAll fetches all pages of a list operation and returns the accumulated
+// items. If a page ultimately fails, the items of the pages fetched before
+// it are returned along with the error, so the returned slice is partial
+// whenever the error is non-nil.
+func listAll[T any](ctx context.Context, c *Client, params *ListParams, list func(context.Context, *ListParams) (*Page[T], error)) ([]T, error) {
+	items := []T{}
+	err := forEach(ctx, c, params, list, func(item T) error {
+		items = append(items, item)
+		return nil
+	})
+	return items, err
+}
+
+// Client is a ZenML API client. A Client is safe for concurrent use by
+// multiple goroutines: Terraform runs CRUD operations for independent
+// resources in parallel against the same provider-configured client.
 type Client struct {
+	// ServerURL is the URL of the ZenML server. It may include a base path
+	// for servers hosted behind a path prefix, e.g.
+	// "https://platform.example.com/zenml".
 	ServerURL       string
 	APIKey          string
 	APIToken        string
 	APITokenExpires *time.Time
 	HTTPClient      *http.Client
+
+	// APIVersion is the version of the API paths, "v1" by default.
+	// APIVersions overrides it for the collections served by another
+	// version, keyed by the first path segment, e.g. "triggers": "v2".
+	APIVersion  string
+	APIVersions map[string]string
+
+	// ServerVersion is the ZenML version of the server, if known. It tells
+	// which endpoints the server serves, see SupportsEndpoint.
+	ServerVersion string
+
+	// MaxRetries is the number of times an idempotent request is retried
+	// after a transient failure, waiting with exponential backoff between
+	// RetryWaitMin and RetryWaitMax.
+	MaxRetries   int
+	RetryWaitMin time.Duration
+	RetryWaitMax time.Duration
+
+	// StackWaitTimeout bounds how long WaitForStack polls for a stack that
+	// was just created, waiting StackWaitInterval between attempts.
+	StackWaitTimeout  time.Duration
+	StackWaitInterval time.Duration
+
+	// Timeout bounds each API request, including its retries.
+	// OperationTimeouts overrides it for the kinds of operations that
+	// are typically faster or slower, keyed by OperationRead,
+	// OperationList, OperationCreate, OperationUpdate, OperationDelete and
+	// OperationValidate. A zero timeout doesn't bound requests.
+	Timeout           time.Duration
+	OperationTimeouts map[string]time.Duration
+
+	// DefaultPageSize is the page size of list requests that don't set
+	// one, and MaxPageSize the largest page size sent to the server. Both
+	// are updated from the server info by ConfigurePageSizes.
+	DefaultPageSize int
+	MaxPageSize     int
+
+	// MaxResponseBytes bounds the size of response bodies read from the
+	// server, protecting against unbounded memory use.
+	MaxResponseBytes int64
+
+	// Metrics observes every HTTP round-trip made by doRequest. It
+	// defaults to a no-op.
+	Metrics MetricsHook
+
+	// Tracer starts a span for every API request made by doRequest. It
+	// defaults to a no-op.
+	Tracer Tracer
+
+	// Headers are sent with every request, e.g. headers required by an API
+	// gateway in front of the server. They can't override the
+	// Authorization, Content-Type and X-Request-ID headers set by the
+	// client.
+	Headers map[string]string
+
+	// DefaultLabels are added to the labels of the stacks, components and
+	// service connectors the client creates, and of those it updates the
+	// labels of. Labels set on the resource itself take precedence.
+	DefaultLabels map[string]string
+
+	// responseCache holds the last body and ETag returned for GET
+	// requests, keyed by path, when enabled with WithResponseCache.
+	responseCache        map[string]cachedResponse
+	responseCacheEnabled bool
+	responseCacheMu      sync.Mutex
+
+	// connectorResources caches the resources listed for service
+	// connectors, keyed by connector ID and resource type.
+	connectorResources   map[string]connectorResourcesCacheEntry
+	connectorResourcesMu sync.Mutex
+
+	// flavors caches the flavors looked up with GetFlavor, keyed by
+	// component type and name.
+	flavors   map[string]*FlavorResponse
+	flavorsMu sync.Mutex
+
+	// dryRun returns write requests as errors instead of sending them, see
+	// WithDryRun.
+	dryRun bool
+
+	// strictDecoding rejects responses with unknown fields or empty
+	// required fields, see WithStrictDecoding.
+	strictDecoding bool
+
+	// compressRequests gzip-encodes large request bodies, see
+	// WithRequestCompression.
+	compressRequests bool
+
+	// breaker stops sending requests while the server is down, see
+	// WithCircuitBreaker.
+	breaker *circuitBreaker
+
+	// roundTrippers wrap the transport of HTTPClient once all options are
+	// applied, see WithRoundTripper.
+	roundTrippers []func(http.RoundTripper) http.RoundTripper
+	// baseTransport is the transport of HTTPClient before the round
+	// trippers wrap it, which the transport options configure.
+	baseTransport *http.Transport
+	// transportShared is set on clients derived with With until they get
+	// their own transport.
+	transportShared bool
+
+	// tokenAuth enables exchanging the API key for a short-lived access
+	// token at the login endpoint instead of sending it as a bearer token.
+	tokenAuth bool
+	// mu guards APIToken and APITokenExpires, which are refreshed while
+	// Terraform issues requests concurrently.
+	mu sync.RWMutex
+}
+
+// ClientOption configures optional behavior of a Client.
+type ClientOption func(*Client)
+
+// WithTokenAuth makes the client exchange its API key for a short-lived
+// access token at the /api/v1/login endpoint, as required by ZenML Pro
+// servers. The token is cached and refreshed when it is about to expire or
+// when the server rejects it with a 401.
+func WithTokenAuth() ClientOption {
+	return func(c *Client) {
+		c.tokenAuth = true
+	}
+}
+
+// WithRetries configures how many times idempotent requests are retried
+// after a transient failure and the bounds of the exponential backoff
+// between attempts.
+func WithRetries(maxRetries int, waitMin, waitMax time.Duration) ClientOption {
+	return func(c *Client) {
+		c.MaxRetries = maxRetries
+		c.RetryWaitMin = waitMin
+		c.RetryWaitMax = waitMax
+	}
+}
+
+// Defaults of the wait for a created stack to become readable.
+const (
+	defaultStackWaitTimeout  = 30 * time.Second
+	defaultStackWaitInterval = time.Second
+)
+
+// WithStackWait configures how long WaitForStack polls for a created stack
+// and how long it waits between attempts.
+func WithStackWait(timeout, interval time.Duration) ClientOption {
+	return func(c *Client) {
+		c.StackWaitTimeout = timeout
+		c.StackWaitInterval = interval
+	}
+}
+
+// defaultMaxResponseBytes is the default limit on the size of response
+// bodies.
+const defaultMaxResponseBytes = 10 << 20
+
+// Operations that requests are classified as, to give each its own
+// timeout with WithOperationTimeout.
+const (
+	OperationRead     = "read"
+	OperationList     = "list"
+	OperationCreate   = "create"
+	OperationUpdate   = "update"
+	OperationDelete   = "delete"
+	OperationValidate = "validate"
+)
+
+// Default request timeouts. Validations and verifications call out to
+// cloud providers, so they get the most headroom.
+const defaultTimeout = 2 * time.Minute
+
+var defaultOperationTimeouts = map[string]time.Duration{
+	OperationRead:     30 * time.Second,
+	OperationList:     time.Minute,
+	OperationCreate:   time.Minute,
+	OperationUpdate:   time.Minute,
+	OperationDelete:   time.Minute,
+	OperationValidate: 5 * time.Minute,
+}
+
+// WithTimeout sets the timeout of requests whose operation has no timeout
+// of its own, see Client.Timeout.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.Timeout = timeout
+	}
+}
+
+// WithOperationTimeout sets the timeout of the requests of one operation,
+// e.g. OperationValidate, see Client.OperationTimeouts.
+func WithOperationTimeout(operation string, timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.OperationTimeouts = maps.Clone(c.OperationTimeouts)
+		if c.OperationTimeouts == nil {
+			c.OperationTimeouts = map[string]time.Duration{}
+		}
+		c.OperationTimeouts[operation] = timeout
+	}
+}
+
+// requestOperation classifies a request by the kind of operation it
+// performs. Below the API version, paths with an odd number of segments
+// name a collection, e.g. /api/v1/stacks, and the others a single
+// resource, e.g. /api/v1/stacks/{id}.
+func requestOperation(method, path string) string {
+	path, _, _ = strings.Cut(path, "?")
+	switch path[strings.LastIndex(path, "/")+1:] {
+	case "validate", "verify":
+		return OperationValidate
+	}
+	switch method {
+	case "POST":
+		return OperationCreate
+	case "PUT", "PATCH":
+		return OperationUpdate
+	case "DELETE":
+		return OperationDelete
+	}
+	if _, rest, ok := strings.Cut(strings.TrimPrefix(path, "/api/"), "/"); ok && strings.Count(rest, "/")%2 == 1 {
+		return OperationRead
+	}
+	return OperationList
+}
+
+// requestTimeout returns the timeout of a request, or 0 if it is unbounded.
+func (c *Client) requestTimeout(operation string) time.Duration {
+	if timeout, ok := c.OperationTimeouts[operation]; ok {
+		return timeout
+	}
+	return c.Timeout
+}
+
+// WithMaxResponseBytes overrides the maximum size of response bodies read
+// from the server, e.g. for servers with very large stacks.
+func WithMaxResponseBytes(n int64) ClientOption {
+	return func(c *Client) {
+		c.MaxResponseBytes = n
+	}
+}
+
+// WithResponseCache enables caching the responses of GetStack and
+// GetComponent in memory. Cached entries are revalidated with a conditional
+// GET using the ETag returned by the server, and reused when it responds 304
+// Not Modified, which saves transferring and decoding large stacks again on
+// every refresh.
+func WithResponseCache(enabled bool) ClientOption {
+	return func(c *Client) {
+		c.responseCacheEnabled = enabled
+	}
+}
+
+// Connection pool defaults. All requests go to the single ZenML server, so
+// up to defaultMaxIdleConnsPerHost connections are kept open to it, instead
+// of the 2 of the default transport, which makes Terraform's parallel
+// requests tear down and re-dial connections and can exhaust ephemeral ports.
+const (
+	defaultMaxIdleConns        = 100
+	defaultMaxIdleConnsPerHost = 100
+	defaultIdleConnTimeout     = 90 * time.Second
+)
+
+// newTransport returns a copy of the default transport, keeping its TLS and
+// HTTP/2 settings, that uses the given proxy function and the default
+// connection pool settings.
+func newTransport(proxy func(*http.Request) (*url.URL, error)) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	transport.MaxIdleConns = defaultMaxIdleConns
+	transport.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	transport.IdleConnTimeout = defaultIdleConnTimeout
+	return transport
+}
+
+// transport returns the transport of the client's HTTP client before the
+// round trippers wrap it, so that options can adjust it in place and be
+// applied in any order. A transport that isn't an *http.Transport is
+// replaced with a new one.
+func (c *Client) transport() *http.Transport {
+	transport := c.baseTransport
+	if transport == nil {
+		var ok bool
+		if transport, ok = c.HTTPClient.Transport.(*http.Transport); !ok {
+			transport = newTransport(http.ProxyFromEnvironment)
+		}
+	} else if c.transportShared {
+		// Don't change the transport of the client this one was derived
+		// from
+		transport = transport.Clone()
+	}
+	c.baseTransport = transport
+	c.HTTPClient.Transport = transport
+	c.transportShared = false
+	return transport
+}
+
+// WithDefaultLabels sets the labels added to the labels of stacks,
+// components and service connectors, see Client.DefaultLabels.
+func WithDefaultLabels(labels map[string]string) ClientOption {
+	return func(c *Client) {
+		c.DefaultLabels = labels
+	}
+}
+
+// withDefaultLabels returns the given labels merged over the client's
+// default labels.
+func (c *Client) withDefaultLabels(labels map[string]string) map[string]string {
+	if len(c.DefaultLabels) == 0 {
+		return labels
+	}
+	merged := make(map[string]string, len(c.DefaultLabels)+len(labels))
+	for k, v := range c.DefaultLabels {
+		merged[k] = v
+	}
+	for k, v := range labels {
+		merged[k] = v
+	}
+	return merged
+}
+
+// withoutDefaultLabels reverses withDefaultLabels for the labels read from
+// the server: the default labels that the configured labels don't set are
+// removed, so that they don't show up as changes to the configuration.
+func (c *Client) withoutDefaultLabels(labels map[string]string, configured map[string]interface{}) map[string]string {
+	if len(c.DefaultLabels) == 0 || labels == nil {
+		return labels
+	}
+	result := make(map[string]string, len(labels))
+	for k, v := range labels {
+		if _, ok := configured[k]; !ok && c.DefaultLabels[k] == v {
+			continue
+		}
+		result[k] = v
+	}
+	return result
+}
+
+// WithDryRun makes the client return a *DryRunError for write requests
+// instead of sending them, so that tooling can inspect the exact body that
+// would be sent. Reads, and verifications, which don't change anything on
+// the server, are still sent.
+func WithDryRun() ClientOption {
+	return func(c *Client) {
+		c.dryRun = true
+	}
+}
+
+// DryRunError is returned for write requests that a dry-run client didn't
+// send. Body is the JSON body the request would have been sent with, if any.
+type DryRunError struct {
+	Method string
+	Path   string
+	Body   []byte
+}
+
+func (e *DryRunError) Error() string {
+	return fmt.Sprintf("dry run: %s %s not sent", e.Method, e.Path)
+}
+
+// isWriteRequest reports whether a request changes the server's state.
+// Verifications are POST requests that don't.
+func isWriteRequest(method, path string) bool {
+	if method == "GET" || method == "HEAD" || method == "OPTIONS" {
+		return false
+	}
+	path, _, _ = strings.Cut(path, "?")
+	return !pathActions[path[strings.LastIndex(path, "/")+1:]]
+}
+
+// marshalRequestBody returns the JSON body a request is sent with.
+func marshalRequestBody(body interface{}) ([]byte, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request body: %v", err)
+	}
+	return data, nil
+}
+
+// MarshalStackRequest returns the exact body sent to update a stack, e.g.
+// to review what an update would change.
+func MarshalStackRequest(stack StackUpdate) ([]byte, error) {
+	return marshalRequestBody(stack)
+}
+
+// WithStrictDecoding makes decoding a response fail if it has fields the
+// provider's models don't know, or if fields the provider relies on are
+// empty, so that changes of the server's schema are caught early instead of
+// storing zero values in the state. Decoding is lenient by default, so that
+// newer servers that add fields remain compatible.
+func WithStrictDecoding(strict bool) ClientOption {
+	return func(c *Client) {
+		c.strictDecoding = strict
+	}
+}
+
+// minCompressedRequestBytes is the size from which request bodies are
+// compressed with WithRequestCompression. Smaller bodies don't shrink
+// enough to be worth it.
+const minCompressedRequestBytes = 8 << 10
+
+// WithRequestCompression gzip-encodes request bodies of at least
+// minCompressedRequestBytes, e.g. of components with large configurations,
+// and sends them with Content-Encoding: gzip. Only enable it for servers,
+// or proxies in front of them, that decompress request bodies: ZenML
+// servers don't by default.
+func WithRequestCompression(compress bool) ClientOption {
+	return func(c *Client) {
+		c.compressRequests = compress
+	}
+}
+
+// compressRequestBody gzip-encodes a request body.
+func compressRequestBody(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(body); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WithHeader adds a header sent with every request. It can be repeated to
+// add several headers.
+func WithHeader(key, value string) ClientOption {
+	return func(c *Client) {
+		if c.Headers == nil {
+			c.Headers = map[string]string{}
+		}
+		c.Headers[key] = value
+	}
+}
+
+// WithProxy sends all requests through the given proxy instead of the one
+// configured by the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment
+// variables. If proxyURL is invalid, requests fail with the parse error.
+func WithProxy(proxyURL string) ClientOption {
+	return func(c *Client) {
+		proxy := func(*http.Request) (*url.URL, error) {
+			return nil, fmt.Errorf("invalid proxy URL %q", proxyURL)
+		}
+		if u, err := url.Parse(proxyURL); err == nil && u.Scheme != "" && u.Host != "" {
+			proxy = http.ProxyURL(u)
+		}
+		c.transport().Proxy = proxy
+	}
+}
+
+// WithConnectionPool overrides how many idle connections are kept open in
+// total and to the ZenML server, and how long an idle connection is kept
+// before it is closed. A zero value keeps the corresponding default.
+func WithConnectionPool(maxIdleConns, maxIdleConnsPerHost int, idleConnTimeout time.Duration) ClientOption {
+	return func(c *Client) {
+		transport := c.transport()
+		if maxIdleConns > 0 {
+			transport.MaxIdleConns = maxIdleConns
+		}
+		if maxIdleConnsPerHost > 0 {
+			transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+		}
+		if idleConnTimeout > 0 {
+			transport.IdleConnTimeout = idleConnTimeout
+		}
+	}
+}
+
+// WithRoundTripper wraps the transport of the client's HTTP client, e.g. to
+// sign requests for an AWS SigV4 or HMAC gateway in front of the server.
+// Wrappers are applied after all other options, so they wrap the transport
+// configured by WithProxy and WithConnectionPool, and each wrapper wraps the
+// ones given before it, so the last one sees requests first.
+//
+// The client sets all its headers, including Authorization, Content-Type
+// and User-Agent, before the request reaches the wrappers, so a signer can
+// sign over them. The body of requests that have one can be read again with
+// GetBody.
+func WithRoundTripper(wrap func(http.RoundTripper) http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		c.roundTrippers = append(c.roundTrippers, wrap)
+	}
+}
+
+// userAgent is sent with every request that doesn't set its own
+// User-Agent header with WithHeader. Setting it explicitly, instead of
+// leaving it to the transport, lets round-trippers sign over it.
+const userAgent = "terraform-provider-zenml"
+
+// MetricsHook receives an observation for every API request the client
+// makes, e.g. to feed a Prometheus collector. The path is templated, e.g.
+// /api/v1/stacks/{id}, to keep the cardinality of labels bounded. A status
+// of 0 means that no response was received. ObserveRequest is called
+// concurrently and must not block.
+type MetricsHook interface {
+	ObserveRequest(method, path string, status int, dur time.Duration)
+}
+
+type noopMetricsHook struct{}
+
+func (noopMetricsHook) ObserveRequest(string, string, int, time.Duration) {}
+
+// WithMetricsHook sets the hook that observes the client's API requests.
+func WithMetricsHook(hook MetricsHook) ClientOption {
+	return func(c *Client) {
+		c.Metrics = hook
+	}
+}
+
+// TracerProvider provides the tracer of the client's API requests. It
+// mirrors the OpenTelemetry TracerProvider without depending on it, so
+// that users who don't trace aren't forced to pull in OpenTelemetry; a thin
+// adapter around an OpenTelemetry tracer provider satisfies it.
+type TracerProvider interface {
+	Tracer(name string) Tracer
+}
+
+// Tracer starts spans for API requests.
+type Tracer interface {
+	// Start starts a span as a child of the span in ctx, if any, and
+	// returns a context carrying the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+	// Inject writes the trace context of ctx into the headers of an
+	// outgoing request, e.g. as a W3C traceparent header.
+	Inject(ctx context.Context, header http.Header)
+}
+
+// Span is a span started by a Tracer. Its methods are called from the
+// goroutine that started it.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+// tracerName is the instrumentation name the client's tracer is requested
+// with.
+const tracerName = "terraform-provider-zenml"
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, _ string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+func (noopTracer) Inject(context.Context, http.Header) {}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(string, interface{}) {}
+func (noopSpan) RecordError(error)                {}
+func (noopSpan) End()                             {}
+
+// WithTracerProvider traces the client's API requests with a tracer from
+// the given provider. Every request gets a span named after its method and
+// templated path, and carries the trace context in its headers.
+func WithTracerProvider(provider TracerProvider) ClientOption {
+	return func(c *Client) {
+		c.Tracer = provider.Tracer(tracerName)
+	}
+}
+
+// startRequestSpan starts the span of an API request and returns the
+// headers to send with it, including the trace context.
+func (c *Client) startRequestSpan(ctx context.Context, method, path string, header http.Header) (context.Context, Span, http.Header) {
+	if c.Tracer == nil {
+		return ctx, noopSpan{}, header
+	}
+	template := templatePath(path)
+	ctx, span := c.Tracer.Start(ctx, method+" "+template)
+	span.SetAttribute("http.request.method", method)
+	span.SetAttribute("url.template", template)
+
+	traced := make(http.Header, len(header)+1)
+	for k, v := range header {
+		traced[k] = v
+	}
+	c.Tracer.Inject(ctx, traced)
+	return ctx, span, traced
+}
+
+// pathActions are path segments that name an action rather than a resource
+// ID, e.g. /api/v1/service_connectors/verify.
+var pathActions = map[string]bool{
+	"verify": true,
+}
+
+// templatePath replaces the resource IDs and names in an API path with
+// {id} and drops the query string. Below the API version, e.g. /api/v1,
+// path segments alternate between collections and IDs.
+func templatePath(path string) string {
+	if i := strings.Index(path, "?"); i >= 0 {
+		path = path[:i]
+	}
+	if !strings.HasPrefix(path, "/api/") {
+		return path
+	}
+	version, rest, ok := strings.Cut(strings.TrimPrefix(path, "/api/"), "/")
+	if !ok {
+		return path
+	}
+	prefix := "/api/" + version + "/"
+	segments := strings.Split(rest, "/")
+	for i := 1; i < len(segments); i += 2 {
+		if !pathActions[segments[i]] {
+			segments[i] = "{id}"
+		}
+	}
+	return prefix + strings.Join(segments, "/")
 }
 
-func NewClient(serverURL, apiKey string, apiToken string) *Client {
-	return &Client{
+func NewClient(serverURL, apiKey string, apiToken string, opts ...ClientOption) *Client {
+	transport := newTransport(http.ProxyFromEnvironment)
+	c := &Client{
 		ServerURL:       serverURL,
 		APIKey:          apiKey,
 		APIToken:        apiToken,
 		APITokenExpires: nil,
-		HTTPClient:      &http.Client{},
+		HTTPClient:      &http.Client{Transport: transport},
+		APIVersion:      defaultAPIVersion,
+		MaxRetries:      3,
+		RetryWaitMin:    500 * time.Millisecond,
+		RetryWaitMax:    10 * time.Second,
+
+		StackWaitTimeout:  defaultStackWaitTimeout,
+		StackWaitInterval: defaultStackWaitInterval,
+
+		Timeout:           defaultTimeout,
+		OperationTimeouts: maps.Clone(defaultOperationTimeouts),
+
+		DefaultPageSize:  defaultPageSize,
+		MaxPageSize:      defaultMaxPageSize,
+		MaxResponseBytes: defaultMaxResponseBytes,
+		Metrics:          noopMetricsHook{},
+		Tracer:           noopTracer{},
+
+		breaker:       newCircuitBreaker(defaultBreakerThreshold, defaultBreakerWindow, defaultBreakerCooldown),
+		baseTransport: transport,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	for _, wrap := range c.roundTrippers {
+		c.HTTPClient.Transport = wrap(c.HTTPClient.Transport)
+	}
+	return c
+}
+
+// With returns a copy of the client with the given options applied, e.g.
+// other default labels for another workspace. The copy shares the
+// client's transport, and so its connection pool; options that configure
+// the transport, like WithProxy, give the copy its own transport instead of
+// changing the shared one. The copy keeps the client's round trippers.
+//
+// The copy starts with the client's access token but caches and refreshes
+// its own, so neither client sees the other's tokens, and its response
+// caches start empty.
+func (c *Client) With(opts ...ClientOption) *Client {
+	c.mu.RLock()
+	apiToken, apiTokenExpires := c.APIToken, c.APITokenExpires
+	c.mu.RUnlock()
+
+	httpClient := *c.HTTPClient
+	d := &Client{
+		ServerURL:       c.ServerURL,
+		APIKey:          c.APIKey,
+		APIToken:        apiToken,
+		APITokenExpires: apiTokenExpires,
+		HTTPClient:      &httpClient,
+		APIVersion:      c.APIVersion,
+		APIVersions:     maps.Clone(c.APIVersions),
+		ServerVersion:   c.ServerVersion,
+		MaxRetries:      c.MaxRetries,
+		RetryWaitMin:    c.RetryWaitMin,
+		RetryWaitMax:    c.RetryWaitMax,
+
+		StackWaitTimeout:  c.StackWaitTimeout,
+		StackWaitInterval: c.StackWaitInterval,
+
+		Timeout:           c.Timeout,
+		OperationTimeouts: maps.Clone(c.OperationTimeouts),
+
+		DefaultPageSize:  c.DefaultPageSize,
+		MaxPageSize:      c.MaxPageSize,
+		MaxResponseBytes: c.MaxResponseBytes,
+		Metrics:          c.Metrics,
+		Tracer:           c.Tracer,
+		Headers:          maps.Clone(c.Headers),
+		DefaultLabels:    maps.Clone(c.DefaultLabels),
+
+		responseCacheEnabled: c.responseCacheEnabled,
+		dryRun:               c.dryRun,
+		strictDecoding:       c.strictDecoding,
+		compressRequests:     c.compressRequests,
+		breaker:              c.breaker,
+		tokenAuth:            c.tokenAuth,
+		roundTrippers:        slices.Clone(c.roundTrippers),
+		baseTransport:        c.baseTransport,
+		transportShared:      true,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	// Wrap the possibly reconfigured transport again with the client's
+	// round trippers and any given to With
+	if d.baseTransport != nil {
+		d.HTTPClient.Transport = d.baseTransport
 	}
+	for _, wrap := range d.roundTrippers {
+		d.HTTPClient.Transport = wrap(d.HTTPClient.Transport)
+	}
+	return d
+}
+
+// defaultAPIVersion is the version of the API paths that the client
+// doesn't override.
+const defaultAPIVersion = "v1"
+
+// apiPath returns the path of an API endpoint from its segments, e.g.
+// apiPath("stacks", id) for /api/v1/stacks/{id}. The segments are escaped,
+// so names and IDs can be passed as is. The API version is looked up by the
+// first segment in APIVersions, falling back to APIVersion.
+func (c *Client) apiPath(parts ...string) string {
+	version := c.APIVersion
+	if len(parts) > 0 && c.APIVersions[parts[0]] != "" {
+		version = c.APIVersions[parts[0]]
+	}
+	if version == "" {
+		version = defaultAPIVersion
+	}
+	escaped := make([]string, len(parts))
+	for i, part := range parts {
+		escaped[i] = url.PathEscape(part)
+	}
+	return "/api/" + version + "/" + strings.Join(escaped, "/")
+}
+
+// endpoint returns the URL of an escaped API path, which may carry a query
+// string, on the server. All request URLs are built by it. The path is
+// joined to the path of ServerURL, so that servers hosted behind a path
+// prefix are reached under that prefix. Repeated slashes, e.g. after a
+// ServerURL with a trailing slash, and trailing slashes are removed, as some
+// gateways reject the empty path segments they make.
+func (c *Client) endpoint(path string) (string, error) {
+	base, err := url.Parse(c.ServerURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid server URL %q: %v", c.ServerURL, err)
+	}
+	// The path isn't parsed as a URL, in which a leading "//" would start
+	// a host name
+	path, query, _ := strings.Cut(path, "?")
+	if _, err := url.PathUnescape(path); err != nil {
+		return "", fmt.Errorf("invalid API path %q: %v", path, err)
+	}
+	u := base.JoinPath(strings.TrimRight(path, "/"))
+	u.RawQuery = query
+	return u.String(), nil
 }
 
 func (c *Client) getAPIToken(ctx context.Context) (string, error) {
-	if c.APIToken != "" {
-		if c.APITokenExpires == nil {
+	c.mu.RLock()
+	token, expires := c.APIToken, c.APITokenExpires
+	c.mu.RUnlock()
+
+	if token != "" {
+		if expires == nil {
 			// No expiry, so just return the token
-			return c.APIToken, nil
+			return token, nil
 		}
 		// Check if the token has expired
-		if time.Now().Before(*c.APITokenExpires) {
+		if time.Now().Before(*expires) {
 			// Token is still valid
-			return c.APIToken, nil
+			return token, nil
 		}
 		if c.APIKey == "" {
 			// Token has expired and we can't refresh it
@@ -72,84 +1133,357 @@ or use the ZENML_API_KEY environment variable to set the API key.
 		return "", fmt.Errorf("an API key or an API token must be configured for the ZenML Terraform provider to be able to authenticate with your ZenML server")
 	}
 
+	if !c.tokenAuth {
+		// The server accepts the API key directly as a bearer token
+		return c.APIKey, nil
+	}
+
+	return c.login(ctx)
+}
+
+// login exchanges the API key for an access token using the password flow
+// and caches it on the client.
+func (c *Client) login(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Another request may have refreshed the token while we were waiting
+	// for the lock
+	if c.APIToken != "" && c.APITokenExpires != nil && time.Now().Before(*c.APITokenExpires) {
+		return c.APIToken, nil
+	}
+
 	// Get a new token from the API key using the password flow
+	loginURL, err := c.endpoint(c.apiPath("login"))
+	if err != nil {
+		return "", err
+	}
 	data := url.Values{}
 	data.Set("password", c.APIKey)
-	loginReq, err := http.NewRequest(
+	loginReq, err := http.NewRequestWithContext(
+		ctx,
 		"POST",
-		fmt.Sprintf("%s/api/v1/login", c.ServerURL),
+		loginURL,
 		bytes.NewBufferString(data.Encode()),
 	)
 	if err != nil {
 		return "", fmt.Errorf("error creating login request: %v", err)
 	}
+	for k, v := range c.Headers {
+		loginReq.Header.Set(k, v)
+	}
+	if loginReq.Header.Get("User-Agent") == "" {
+		loginReq.Header.Set("User-Agent", userAgent)
+	}
 	loginReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	loginResp, err := c.HTTPClient.Do(loginReq)
 	if err != nil {
-		return "", fmt.Errorf("error making login request: %v", err)
+		return "", fmt.Errorf("error making login request: %w", err)
 	}
 	defer loginResp.Body.Close()
 
+	body, err := readResponseBody(loginResp.Body, c.MaxResponseBytes)
+	if err != nil {
+		return "", fmt.Errorf("error reading login response: %v", err)
+	}
+
+	htmlDetail, isHTML := htmlResponseDetail(loginResp, body)
+	if loginResp.StatusCode < 200 || loginResp.StatusCode >= 300 || isHTML {
+		apiErr := &APIError{
+			StatusCode: loginResp.StatusCode,
+			Detail:     errorDetail(body),
+			Body:       body,
+		}
+		if isHTML {
+			apiErr.Detail = htmlDetail
+		}
+		return "", fmt.Errorf("login request failed: %w", apiErr)
+	}
+
 	var tokenResp struct {
 		AccessToken string `json:"access_token"`
 		ExpiresIn   int    `json:"expires_in"`
 	}
-	if err := json.NewDecoder(loginResp.Body).Decode(&tokenResp); err != nil {
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
 		return "", fmt.Errorf("error decoding login response: %v", err)
 	}
 
 	c.APIToken = tokenResp.AccessToken
-	// Set the expiry time to 5 minutes before the actual expiry, to account for
-	// clock skew and to avoid using an expired token when making requests
-	expiresAt := time.Now().Add(
-		time.Duration(tokenResp.ExpiresIn-300) * time.Second,
-	)
-	c.APITokenExpires = &expiresAt
+	c.APITokenExpires = nil
+	if tokenResp.ExpiresIn > 0 {
+		// Set the expiry time to 5 minutes before the actual expiry, to
+		// account for clock skew and to avoid using an expired token when
+		// making requests
+		expiresAt := time.Now().Add(
+			time.Duration(tokenResp.ExpiresIn-300) * time.Second,
+		)
+		c.APITokenExpires = &expiresAt
+	}
 
 	return c.APIToken, nil
 }
 
+// invalidateAPIToken drops the cached access token if it is still the one
+// that was rejected by the server, so that the next request logs in again.
+// It reports whether a new token can be obtained.
+func (c *Client) invalidateAPIToken(rejected string) bool {
+	if !c.tokenAuth || c.APIKey == "" {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.APIToken == rejected {
+		c.APIToken = ""
+		c.APITokenExpires = nil
+	}
+	return true
+}
+
 func (c *Client) doRequest(ctx context.Context, method, path string, body interface{}) (*http.Response, int, error) {
-	var bodyReader io.Reader
+	return c.doRequestWithHeader(ctx, method, path, body, nil)
+}
 
-	if body != nil {
-		jsonBody, err := json.Marshal(body)
-		if err != nil {
-			return nil, 0, fmt.Errorf("error marshaling request body: %v", err)
+// doRequestWithHeader is doRequest with additional request headers, e.g. for
+// conditional requests. The response headers are available on the returned
+// response.
+//
+// Every request carries a random X-Request-ID header, which is kept across
+// retries and included in the returned error, so that a failed apply can be
+// traced in the server logs. Returned errors also state how many attempts
+// were made and how long they took, to tell slow timeouts from immediate
+// rejections.
+func (c *Client) doRequestWithHeader(ctx context.Context, method, path string, body interface{}, header http.Header) (*http.Response, int, error) {
+	if c.dryRun && isWriteRequest(method, path) {
+		dryRun := &DryRunError{Method: method, Path: path}
+		if body != nil {
+			var err error
+			if dryRun.Body, err = marshalRequestBody(body); err != nil {
+				return nil, 0, err
+			}
 		}
-		bodyReader = bytes.NewBuffer(jsonBody)
+		return nil, 0, dryRun
 	}
 
-	req, err := http.NewRequest(method, fmt.Sprintf("%s%s", c.ServerURL, path), bodyReader)
-	if err != nil {
-		return nil, 0, fmt.Errorf("error creating request: %v", err)
+	operation := requestOperation(method, path)
+	timeout := c.requestTimeout(operation)
+	parent := ctx
+	if timeout > 0 {
+		// The response body is read before returning, so the deadline
+		// can't cut off the caller's decoding
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
 
-	accessToken, err := c.getAPIToken(ctx)
+	ctx, span, header := c.startRequestSpan(ctx, method, path, header)
+	defer span.End()
+	header = withIdempotencyKey(method, header)
 
+	requestID := newRequestID()
+	start := time.Now()
+	attempts := 0
+	resp, status, err := c.sendRequest(ctx, method, path, body, header, requestID, &attempts)
+	if status != 0 {
+		span.SetAttribute("http.response.status_code", status)
+	}
 	if err != nil {
-		return nil, 0, fmt.Errorf("error getting API token: %v", err)
+		if errors.Is(err, context.DeadlineExceeded) && parent.Err() == nil {
+			err = fmt.Errorf("%s request timed out after %s: %w", operation, timeout, err)
+		}
+		span.RecordError(err)
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			// API errors carry the request ID themselves
+			err = fmt.Errorf("%w (request ID %s)", err, requestID)
+		}
+		return nil, status, fmt.Errorf("after %s in %s: %w", pluralize(attempts, "attempt"), time.Since(start).Round(time.Millisecond), err)
 	}
+	return resp, status, nil
+}
 
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
-	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
+// idempotencyKeyHeader carries a key that is unique per logical create
+// request and kept across its retries, so that a server or gateway that
+// supports it can tell a replay from a new request. Servers that don't
+// support it ignore the header.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// withIdempotencyKey returns the headers of a request with a new
+// idempotency key added for POST requests, unless the headers already
+// carry one.
+func withIdempotencyKey(method string, header http.Header) http.Header {
+	if method != "POST" || header.Get(idempotencyKeyHeader) != "" {
+		return header
+	}
+	keyed := make(http.Header, len(header)+1)
+	for k, v := range header {
+		keyed[k] = v
 	}
+	keyed.Set(idempotencyKeyHeader, newRequestID())
+	return keyed
+}
+
+// pluralize formats a count followed by a noun, e.g. "1 attempt" or
+// "2 attempts".
+func pluralize(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// newRequestID returns a random version 4 UUID.
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// crypto/rand doesn't fail on supported platforms
+		return ""
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// requestIDPattern matches the request ID appended to error messages, and
+// requestAttemptsPattern the attempts and duration they are prefixed with.
+var (
+	requestIDPattern       = regexp.MustCompile(` \(request ID [^)]*\)`)
+	requestAttemptsPattern = regexp.MustCompile(`after \d+ attempts? in [^:]+: `)
+)
+
+// withoutRequestDetails removes the request IDs, attempts and durations
+// from an error message, so that errors with the same cause can be compared.
+func withoutRequestDetails(msg string) string {
+	msg = requestAttemptsPattern.ReplaceAllString(msg, "")
+	return requestIDPattern.ReplaceAllString(msg, "")
+}
+
+// sendRequest sends a request with the given request ID, retrying and
+// re-authenticating as needed. The number of requests sent is stored in
+// attempts.
+func (c *Client) sendRequest(ctx context.Context, method, path string, body interface{}, header http.Header, requestID string, attempts *int) (*http.Response, int, error) {
+	var jsonBody []byte
 
-	tflog.Info(ctx, fmt.Sprintf("[ZENML] Making request: %s %s", method, req.URL.String()))
 	if body != nil {
-		prettyJSON, _ := json.MarshalIndent(body, "", "  ")
-		tflog.Debug(ctx, fmt.Sprintf("[ZENML] Request body (JSON):\n%s", prettyJSON))
+		var err error
+		jsonBody, err = marshalRequestBody(body)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	// The body is compressed once, so that retries replay the same bytes
+	contentEncoding := ""
+	if c.compressRequests && len(jsonBody) >= minCompressedRequestBytes {
+		compressed, err := compressRequestBody(jsonBody)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error compressing request body: %w", err)
+		}
+		jsonBody, contentEncoding = compressed, "gzip"
 	}
 
-	resp, err := c.HTTPClient.Do(req)
+	endpoint, err := c.endpoint(path)
 	if err != nil {
-		return nil, 0, fmt.Errorf("error making request: %v", err)
+		return nil, 0, err
 	}
 
-	// Read the response body once and store it in a variable
-	defer resp.Body.Close()
-	resp_body, _ := io.ReadAll(resp.Body)
+	var resp *http.Response
+	var resp_body []byte
+	reauthenticated := false
+	for attempt := 0; ; attempt++ {
+		*attempts = attempt + 1
+
+		// A bytes.Reader body also sets GetBody, so that the request can be
+		// replayed when following a 307/308 redirect
+		var bodyReader io.Reader
+		if body != nil {
+			bodyReader = bytes.NewReader(jsonBody)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, endpoint, bodyReader)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error creating request: %v", err)
+		}
+
+		accessToken, err := c.getAPIToken(ctx)
+
+		if err != nil {
+			return nil, 0, fmt.Errorf("error getting API token: %w", err)
+		}
+
+		for k, v := range c.Headers {
+			req.Header.Set(k, v)
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		if req.Header.Get("User-Agent") == "" {
+			req.Header.Set("User-Agent", userAgent)
+		}
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+		// The transport only decompresses responses when it asks for gzip
+		// itself, which it doesn't with a custom transport or when the
+		// header is set explicitly, so responses are decompressed by
+		// readResponse
+		req.Header.Set("Accept-Encoding", "gzip")
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if contentEncoding != "" {
+			req.Header.Set("Content-Encoding", contentEncoding)
+		}
+		if requestID != "" {
+			req.Header.Set("X-Request-ID", requestID)
+		}
+
+		tflog.Info(ctx, fmt.Sprintf("[ZENML] Making request %s: %s %s", requestID, method, req.URL.String()))
+		if body != nil && attempt == 0 {
+			prettyJSON, _ := json.MarshalIndent(body, "", "  ")
+			tflog.Debug(ctx, fmt.Sprintf("[ZENML] Request body (JSON):\n%s", prettyJSON))
+		}
+
+		if err := c.breaker.allow(); err != nil {
+			return nil, 0, err
+		}
+		start := time.Now()
+		resp, err = c.HTTPClient.Do(req)
+		c.breaker.record(ctx, resp, err)
+		if err != nil {
+			c.observeRequest(method, path, 0, time.Since(start))
+			return nil, 0, fmt.Errorf("error making request: %w", err)
+		}
+		c.observeRequest(method, path, resp.StatusCode, time.Since(start))
+
+		// The access token may have been revoked or may have expired
+		// earlier than advertised: log in again and replay the request once
+		if resp.StatusCode == http.StatusUnauthorized && !reauthenticated && c.invalidateAPIToken(accessToken) {
+			tflog.Info(ctx, "[ZENML] Access token rejected, re-authenticating")
+			closeResponse(resp)
+			reauthenticated = true
+			continue
+		}
+
+		// Read the response body once and store it in a variable. What
+		// isn't read, e.g. of a response over the size limit, is drained,
+		// so that the connection is reused.
+		var readErr error
+		resp_body, readErr = c.readResponse(resp)
+		closeResponse(resp)
+
+		// A proxy timing out mid-stream truncates the response. Replaying
+		// the request is only safe if it is idempotent.
+		if isTruncatedResponse(resp_body, readErr) && isIdempotent(method) && attempt < c.MaxRetries {
+			wait := c.backoff(attempt)
+			tflog.Warn(ctx, fmt.Sprintf("[ZENML] Truncated response received, retrying in %s", wait))
+			if err := sleepContext(ctx, wait); err != nil {
+				return nil, 0, err
+			}
+			continue
+		}
+		if readErr != nil {
+			return nil, resp.StatusCode, fmt.Errorf("error reading response body: %w", readErr)
+		}
+		break
+	}
 
 	// Print the response body as JSON if available
 	if len(resp_body) > 0 {
@@ -164,343 +1498,1602 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body interf
 
 	tflog.Info(ctx, fmt.Sprintf("[ZENML] Response status: %d", resp.StatusCode))
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, resp.StatusCode, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(resp_body))
+	// An HTML page is never a valid API response, even with a successful
+	// status: redirects are followed, so a proxy login page ends up as 200
+	htmlDetail, isHTML := htmlResponseDetail(resp, resp_body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 || isHTML {
+		apiErr := &APIError{
+			StatusCode: resp.StatusCode,
+			Detail:     errorDetail(resp_body),
+			Body:       resp_body,
+			RequestID:  resp.Header.Get("X-Request-ID"),
+		}
+		if isHTML {
+			apiErr.Detail = htmlDetail
+		}
+		if apiErr.RequestID == "" {
+			apiErr.RequestID = requestID
+		}
+		return nil, resp.StatusCode, apiErr
 	}
 
 	// Re-wrap the body so that the caller can still read it
 	resp.Body = io.NopCloser(bytes.NewReader(resp_body))
+	resp.Header.Del("Content-Encoding")
+	resp.ContentLength = int64(len(resp_body))
 
 	return resp, resp.StatusCode, nil
 }
 
-// GetServerInfo fetches server info to determine version and capabilities
-func (c *Client) GetServerInfo(ctx context.Context) (*ServerInfo, error) {
-	resp, _, err := c.doRequest(ctx, "GET", "/api/v1/info", nil)
+// errorDetail extracts a readable message from the body of a failed
+// request. The detail of ZenML errors is a string, a [type, message] list,
+// or for validation errors a list of {loc, msg} objects. Bodies in any other
+// shape are returned as is, so the server message is never lost.
+func errorDetail(body []byte) string {
+	var payload struct {
+		Detail json.RawMessage `json:"detail"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || len(payload.Detail) == 0 {
+		return string(body)
+	}
+
+	var detail string
+	if err := json.Unmarshal(payload.Detail, &detail); err == nil && detail != "" {
+		return detail
+	}
+
+	var parts []string
+	if err := json.Unmarshal(payload.Detail, &parts); err == nil && len(parts) > 0 {
+		return strings.Join(parts, ": ")
+	}
+
+	var validationErrors []struct {
+		Loc []interface{} `json:"loc"`
+		Msg string        `json:"msg"`
+	}
+	if err := json.Unmarshal(payload.Detail, &validationErrors); err == nil && len(validationErrors) > 0 {
+		messages := make([]string, 0, len(validationErrors))
+		for _, e := range validationErrors {
+			if e.Msg == "" {
+				return string(body)
+			}
+			loc := make([]string, 0, len(e.Loc))
+			for _, l := range e.Loc {
+				loc = append(loc, fmt.Sprint(l))
+			}
+			if len(loc) > 0 {
+				messages = append(messages, strings.Join(loc, ".")+": "+e.Msg)
+			} else {
+				messages = append(messages, e.Msg)
+			}
+		}
+		return strings.Join(messages, "; ")
+	}
+
+	return string(body)
+}
+
+// htmlSnippetLength is the number of characters of an HTML response quoted
+// in errors.
+const htmlSnippetLength = 200
+
+// htmlResponseDetail returns a readable error message for a response that
+// is an HTML page instead of JSON, e.g. the login page of an authentication
+// proxy in front of the server, or the dashboard when the URL is wrong. It
+// reports false for any other response.
+func htmlResponseDetail(resp *http.Response, body []byte) (string, bool) {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+		return "", false
+	}
+	trimmed := bytes.TrimSpace(body)
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" && !bytes.HasPrefix(trimmed, []byte("<")) {
+		return "", false
+	}
+
+	snippet := strings.Join(strings.Fields(string(trimmed)), " ")
+	if runes := []rune(snippet); len(runes) > htmlSnippetLength {
+		snippet = string(runes[:htmlSnippetLength]) + "..."
+	}
+
+	detail := "received an HTML response instead of JSON, likely from an authentication proxy or because the server URL is wrong"
+	if resp.Request != nil && resp.Request.Response != nil {
+		// The request was redirected; the query is left out as it may
+		// carry tokens
+		target := *resp.Request.URL
+		target.RawQuery = ""
+		target.Fragment = ""
+		detail += fmt.Sprintf(" (redirected to %s)", target.Redacted())
+	}
+	if snippet != "" {
+		detail += ": " + snippet
+	}
+	return detail, true
+}
+
+// observeRequest reports a round-trip to the metrics hook, if any.
+func (c *Client) observeRequest(method, path string, status int, dur time.Duration) {
+	if c.Metrics != nil {
+		c.Metrics.ObserveRequest(method, templatePath(path), status, dur)
+	}
+}
+
+// decodeResponse decodes the JSON body of a successful response and closes
+// it. An empty body, as sent with 204 No Content by some server versions,
+// decodes to the zero value instead of failing with EOF.
+func decodeResponse[T any](c *Client, resp *http.Response) (*T, error) {
+	defer closeResponse(resp)
+
+	var result T
+	if resp.StatusCode == http.StatusNoContent {
+		return &result, nil
+	}
+	if err := c.decodeJSON(resp.Body, &result); err != nil {
+		return nil, fmt.Errorf("error decoding response: %v", err)
+	}
+	return &result, nil
+}
+
+// get fetches and decodes a single resource. A resource that doesn't exist
+// is returned as nil without an error.
+func get[T any](ctx context.Context, c *Client, path string) (*T, error) {
+	resp, status, err := c.doRequest(ctx, "GET", path, nil)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return decodeResponse[T](c, resp)
+}
+
+// create posts body to path and decodes the created resource.
+func create[T any](ctx context.Context, c *Client, path string, body any) (*T, error) {
+	return send[T](ctx, c, "POST", path, body)
+}
+
+// update puts body to path and decodes the updated resource.
+func update[T any](ctx context.Context, c *Client, path string, body any) (*T, error) {
+	return send[T](ctx, c, "PUT", path, body)
+}
+
+// send sends body to path and decodes the response. Unlike get, a 404 is
+// an error, since the resource was expected to exist.
+func send[T any](ctx context.Context, c *Client, method, path string, body any) (*T, error) {
+	resp, _, err := c.doRequest(ctx, method, path, body)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	return decodeResponse[T](c, resp)
+}
 
-	var result ServerInfo
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("error decoding server info: %v", err)
+// remove deletes the resource at path. Deleting a resource that doesn't
+// exist succeeds.
+func remove(ctx context.Context, c *Client, path string) error {
+	resp, status, err := c.doRequest(ctx, "DELETE", path, nil)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return nil
+		}
+		return err
+	}
+	closeResponse(resp)
+	return nil
+}
+
+// decodeJSON decodes a JSON document into v. With strict decoding, fields
+// that v has no counterpart for and empty required fields are errors.
+func (c *Client) decodeJSON(r io.Reader, v interface{}) error {
+	decoder := json.NewDecoder(r)
+	if c.strictDecoding {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(v); err != nil && err != io.EOF {
+		return err
+	}
+	if c.strictDecoding {
+		return checkRequiredFields(reflect.ValueOf(v), "")
+	}
+	return nil
+}
+
+// checkRequiredFields returns an error naming the first field tagged
+// `zenml:"required"` that is empty, by its path in the JSON document, e.g.
+// "items.0.name".
+func checkRequiredFields(v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return checkRequiredFields(v.Elem(), path)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := checkRequiredFields(v.Index(i), joinFieldPath(path, strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, key := range keys {
+			if err := checkRequiredFields(v.MapIndex(key), joinFieldPath(path, fmt.Sprint(key.Interface()))); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			fieldPath := joinFieldPath(path, name)
+			if field.Tag.Get("zenml") == "required" && v.Field(i).IsZero() {
+				return fmt.Errorf("missing required field %q", fieldPath)
+			}
+			if err := checkRequiredFields(v.Field(i), fieldPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// joinFieldPath appends a field name or index to a JSON field path.
+func joinFieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// closeResponse drains and closes a response body. A body that isn't read to
+// the end keeps the connection from being reused for the next request.
+func closeResponse(resp *http.Response) {
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+// cachedResponse is a response body cached along with its ETag.
+type cachedResponse struct {
+	etag string
+	body []byte
+}
+
+// getCached performs a GET request and decodes the response. When the
+// response cache is enabled and holds an ETag for the path, the request is
+// made conditional with If-None-Match, and a 304 Not Modified response is
+// answered from the cache. It also returns the status code of the response.
+func getCached[T any](ctx context.Context, c *Client, path string) (*T, int, error) {
+	var header http.Header
+	cached, ok := c.cachedResponse(path)
+	if ok {
+		header = http.Header{"If-None-Match": {cached.etag}}
+	}
+
+	resp, status, err := c.doRequestWithHeader(ctx, "GET", path, nil, header)
+	if ok && status == http.StatusNotModified {
+		tflog.Debug(ctx, fmt.Sprintf("[ZENML] Not modified, reusing cached response for %s", path))
+		var result T
+		if err := c.decodeJSON(bytes.NewReader(cached.body), &result); err != nil {
+			return nil, status, fmt.Errorf("error decoding response: %v", err)
+		}
+		return &result, status, nil
+	}
+	if err != nil {
+		if status == http.StatusNotFound {
+			c.cacheResponse(path, "", nil)
+		}
+		return nil, status, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	closeResponse(resp)
+	if err != nil {
+		return nil, status, fmt.Errorf("error reading response body: %w", err)
+	}
+	c.cacheResponse(path, resp.Header.Get("ETag"), body)
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	result, err := decodeResponse[T](c, resp)
+	return result, status, err
+}
+
+// cachedResponse returns the cached response for a path, if the response
+// cache is enabled and holds one.
+func (c *Client) cachedResponse(path string) (cachedResponse, bool) {
+	if !c.responseCacheEnabled {
+		return cachedResponse{}, false
+	}
+	c.responseCacheMu.Lock()
+	defer c.responseCacheMu.Unlock()
+	cached, ok := c.responseCache[path]
+	return cached, ok
+}
+
+// cacheResponse stores the response for a path in the response cache, or
+// drops the cached response if there's no ETag to revalidate it with.
+func (c *Client) cacheResponse(path, etag string, body []byte) {
+	if !c.responseCacheEnabled {
+		return
+	}
+	c.responseCacheMu.Lock()
+	defer c.responseCacheMu.Unlock()
+	if etag == "" {
+		delete(c.responseCache, path)
+		return
+	}
+	if c.responseCache == nil {
+		c.responseCache = make(map[string]cachedResponse)
+	}
+	c.responseCache[path] = cachedResponse{etag: etag, body: body}
+}
+
+// ErrResponseTooLarge is returned when a response body exceeds the client's
+// MaxResponseBytes limit.
+var ErrResponseTooLarge = errors.New("response body too large")
+
+// readResponse reads the body of a response, decompressing it if the
+// server gzip-encoded it. The size limit applies to the decompressed body.
+func (c *Client) readResponse(resp *http.Response) ([]byte, error) {
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return readResponseBody(resp.Body, c.MaxResponseBytes)
+	}
+	reader, err := gzip.NewReader(resp.Body)
+	if err == io.EOF {
+		// An empty body, e.g. of a 204 No Content
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing response: %w", err)
+	}
+	defer reader.Close()
+	return readResponseBody(reader, c.MaxResponseBytes)
+}
+
+// readResponseBody reads a response body, failing rather than allocating
+// more than limit bytes. A non-positive limit disables the check.
+func readResponseBody(body io.Reader, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		return io.ReadAll(body)
+	}
+	data, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return data, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: exceeds the maximum of %d bytes", ErrResponseTooLarge, limit)
+	}
+	return data, nil
+}
+
+// isIdempotent reports whether a request with the given method can be safely
+// replayed.
+func isIdempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
+
+// isTransientError reports whether a failed request may succeed when it is
+// sent again: the server was unreachable, overloaded or temporarily failing.
+func isTransientError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// isTruncatedResponse reports whether a response body was cut short in
+// transit, as opposed to being complete but malformed. Truncated JSON fails
+// to decode with an unexpected EOF, while malformed JSON fails with a
+// syntax error.
+func isTruncatedResponse(body []byte, readErr error) bool {
+	if errors.Is(readErr, io.ErrUnexpectedEOF) {
+		return true
+	}
+	if readErr != nil || len(body) == 0 {
+		return false
+	}
+	var v interface{}
+	err := json.NewDecoder(bytes.NewReader(body)).Decode(&v)
+	return errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// backoff returns the exponential backoff delay before the given retry
+// attempt, bounded by the client's retry wait settings.
+func (c *Client) backoff(attempt int) time.Duration {
+	wait := c.RetryWaitMin
+	for i := 0; i < attempt && wait < c.RetryWaitMax; i++ {
+		wait *= 2
+	}
+	if wait > c.RetryWaitMax {
+		wait = c.RetryWaitMax
+	}
+	return wait
+}
+
+// sleepContext waits for the given duration, returning early with the
+// context's error if it is cancelled. Every wait between retries goes
+// through it, so that cancelling an apply doesn't wait for the backoff.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	// Don't race an expired timer against an already cancelled context
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// GetServerInfo fetches server info to determine version and capabilities
+func (c *Client) GetServerInfo(ctx context.Context) (*ServerInfo, error) {
+	return send[ServerInfo](ctx, c, "GET", c.apiPath("info"), nil)
+}
+
+// Ping checks that the server can be reached and accepts the client's
+// credentials, by fetching the current user. The info endpoint is not used
+// because it doesn't require authentication. The returned error tells apart
+// unreachable servers, rejected credentials and other failures.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.GetCurrentUser(ctx)
+	if err == nil {
+		return nil
+	}
+
+	var apiErr *APIError
+	var urlErr *url.Error
+	switch {
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized:
+		return fmt.Errorf("the ZenML server at %s rejected the credentials, check that the API key (or API token) and the server URL are correct: %w", c.ServerURL, err)
+	case errors.As(err, &urlErr):
+		return fmt.Errorf("could not connect to the ZenML server at %s, check the server URL and that the server is reachable: %w", c.ServerURL, err)
+	default:
+		return fmt.Errorf("checking the connection to the ZenML server at %s failed: %w", c.ServerURL, err)
+	}
+}
+
+// ConfigurePageSizes aligns the client's page sizes with the limits
+// reported by the server. Limits the server doesn't report keep their
+// current values. It must be called before the client is used concurrently.
+func (c *Client) ConfigurePageSizes(info *ServerInfo) {
+	if info == nil {
+		return
+	}
+	if info.MaxPageSize > 0 {
+		c.MaxPageSize = info.MaxPageSize
+	}
+	if info.DefaultPageSize > 0 {
+		c.DefaultPageSize = info.DefaultPageSize
+	}
+	if c.MaxPageSize > 0 && c.DefaultPageSize > c.MaxPageSize {
+		c.DefaultPageSize = c.MaxPageSize
 	}
-	return &result, nil
 }
 
 // Stack operations
 func (c *Client) CreateStack(ctx context.Context, workspace string, stack StackRequest) (*StackResponse, error) {
-	endpoint := fmt.Sprintf("/api/v1/workspaces/%s/stacks", workspace)
-	resp, _, err := c.doRequest(ctx, "POST", endpoint, stack)
+	stack.Labels = c.withDefaultLabels(stack.Labels)
+	endpoint := c.apiPath("workspaces", workspace, "stacks")
+	return create[StackResponse](ctx, c, endpoint, stack)
+}
+
+// CreateOrGetStack creates a stack, or returns the existing stack with the
+// same name if creating it conflicts with one. This makes concurrent creation
+// of the same stack idempotent. The returned boolean reports whether an
+// existing stack was returned.
+func (c *Client) CreateOrGetStack(ctx context.Context, workspace string, stack StackRequest) (*StackResponse, bool, error) {
+	created, err := c.CreateStack(ctx, workspace, stack)
+	if err == nil {
+		return created, false, nil
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		return nil, false, err
+	}
+
+	existing, getErr := c.GetStackByName(ctx, workspace, stack.Name)
+	if getErr != nil {
+		return nil, false, fmt.Errorf("error getting conflicting stack %s: %w", stack.Name, getErr)
+	}
+	if existing == nil {
+		// The conflict wasn't caused by a stack with the same name
+		return nil, false, err
+	}
+	return existing, true, nil
+}
+
+func (c *Client) GetStack(ctx context.Context, id string) (*StackResponse, error) {
+	result, status, err := getCached[StackResponse](ctx, c, c.apiPath("stacks", id))
 	if err != nil {
+		if status == 404 {
+			// Return nil if the stack is not found
+			return nil, nil
+		}
 		return nil, err
 	}
-	defer resp.Body.Close()
+	return result, nil
+}
 
-	var result StackResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("error decoding response: %v", err)
+// GetStackHydrated returns a stack with the full details of its
+// components embedded, or nil if there is no such stack. Servers that don't
+// embed the components only return their IDs, see StackComponents.Hydrated.
+func (c *Client) GetStackHydrated(ctx context.Context, id string) (*StackResponse, error) {
+	result, status, err := getCached[StackResponse](ctx, c, c.apiPath("stacks", id)+"?hydrate=true")
+	if err != nil {
+		if status == 404 {
+			// Return nil if the stack is not found
+			return nil, nil
+		}
+		return nil, err
 	}
-	return &result, nil
+	return result, nil
 }
 
-func (c *Client) GetStack(ctx context.Context, id string) (*StackResponse, error) {
-	resp, status, err := c.doRequest(ctx, "GET", fmt.Sprintf("/api/v1/stacks/%s", id), nil)
+// WaitForStack polls GetStack until the stack can be read or the timeout
+// elapses. Servers with replicated databases may not find a stack right
+// after it was created.
+func (c *Client) WaitForStack(ctx context.Context, id string, timeout time.Duration) (*StackResponse, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		stack, err := c.GetStack(ctx, id)
+		if err != nil || stack != nil {
+			return stack, err
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, fmt.Errorf("stack %s was not found within %s after it was created", id, timeout)
+		}
+		wait := c.StackWaitInterval
+		if wait > remaining {
+			wait = remaining
+		}
+		tflog.Debug(ctx, fmt.Sprintf("[ZENML] Stack %s not found yet, retrying in %s", id, wait))
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *Client) UpdateStack(ctx context.Context, id string, stack StackUpdate) (*StackResponse, error) {
+	if stack.Labels != nil {
+		labels := c.withDefaultLabels(*stack.Labels)
+		stack.Labels = &labels
+	}
+	return update[StackResponse](ctx, c, c.apiPath("stacks", id), stack)
+}
+
+func (c *Client) DeleteStack(ctx context.Context, id string) error {
+	return remove(ctx, c, c.apiPath("stacks", id))
+}
+
+// TrashStack moves a stack to the trash instead of deleting it. Trashed
+// stacks can be restored with RestoreStack until the server's retention
+// period expires.
+func (c *Client) TrashStack(ctx context.Context, id string) error {
+	resp, status, err := c.doRequest(ctx, "POST", c.apiPath("stacks", id, "trash"), nil)
 	if err != nil {
 		if status == 404 {
 			// Return nil if the stack is not found
+			return nil
+		}
+		return err
+	}
+	closeResponse(resp)
+	return nil
+}
+
+// RestoreStack restores a stack that was moved to the trash.
+func (c *Client) RestoreStack(ctx context.Context, id string) (*StackResponse, error) {
+	return create[StackResponse](ctx, c, c.apiPath("stacks", id, "restore"), nil)
+}
+
+// ValidateStack checks that the components of a stack are compatible with
+// each other. It returns nil if the server doesn't implement stack
+// validation.
+func (c *Client) ValidateStack(ctx context.Context, id string) (*StackValidationResponse, error) {
+	resp, status, err := c.doRequest(ctx, "GET", c.apiPath("stacks", id, "validate"), nil)
+	if err != nil {
+		switch status {
+		case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+			// Older servers don't know the endpoint
 			return nil, nil
 		}
 		return nil, err
 	}
-	defer resp.Body.Close()
+	return decodeResponse[StackValidationResponse](c, resp)
+}
+
+// GetTrashedStackByName returns the trashed stack with the given name in a
+// workspace, or nil if there is none.
+func (c *Client) GetTrashedStackByName(ctx context.Context, workspace, name string) (*StackResponse, error) {
+	params := &ListParams{
+		Filter: map[string]string{
+			"name":      name,
+			"workspace": workspace,
+			"trashed":   "true",
+		},
+	}
+
+	stacks, err := c.ListStacks(ctx, params)
+	if err != nil {
+		return nil, err
+	}
 
-	var result StackResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("error decoding response: %v", err)
+	if len(stacks.Items) == 0 {
+		return nil, nil
+	}
+
+	return &stacks.Items[0], nil
+}
+
+func (c *Client) ListStacks(ctx context.Context, params *ListParams) (*Page[StackResponse], error) {
+	return listPage[StackResponse](ctx, c, c.apiPath("stacks"), params)
+}
+
+// CreateStackWithComponents creates the given components and then a stack
+// that includes them, in addition to any components already referenced by
+// the stack request. If any step fails, the components that were created are
+// deleted again so that no orphaned components are left behind.
+func (c *Client) CreateStackWithComponents(ctx context.Context, workspace string, stack StackRequest, components []ComponentRequest) (*StackResponse, []ComponentResponse, error) {
+	var created []ComponentResponse
+
+	rollback := func(cause error) error {
+		// Delete in reverse order
+		for i := len(created) - 1; i >= 0; i-- {
+			if err := c.DeleteComponent(ctx, created[i].ID); err != nil {
+				cause = fmt.Errorf("%w (additionally, rolling back component %s failed: %v)", cause, created[i].ID, err)
+			}
+		}
+		return cause
+	}
+
+	stackComponents := make(map[string][]string, len(stack.Components)+len(components))
+	for k, v := range stack.Components {
+		stackComponents[k] = append([]string{}, v...)
+	}
+
+	created, err := c.CreateComponentsBatch(ctx, workspace, components)
+	if err != nil {
+		return nil, nil, rollback(err)
+	}
+	// All components were created, in the order they were given
+	for i, component := range components {
+		stackComponents[component.Type] = append(stackComponents[component.Type], created[i].ID)
+	}
+
+	stack.Components = stackComponents
+	resp, err := c.CreateStack(ctx, workspace, stack)
+	if err != nil {
+		return nil, nil, rollback(fmt.Errorf("error creating stack: %w", err))
+	}
+
+	return resp, created, nil
+}
+
+// GetStackByName returns the stack with the given name in a workspace, or nil
+// if there is no such stack.
+func (c *Client) GetStackByName(ctx context.Context, workspace, name string) (*StackResponse, error) {
+	params := &ListParams{
+		Filter: map[string]string{
+			"name":      name,
+			"workspace": workspace,
+		},
+	}
+
+	stacks, err := c.ListStacks(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stacks.Items) == 0 {
+		return nil, nil
+	}
+
+	return &stacks.Items[0], nil
+}
+
+// ListAllStacks returns the stacks on all pages matching the given list
+// parameters. If a page can't be fetched, the stacks of the previous pages
+// are returned along with the error: the slice may be partial whenever the
+// error is non-nil.
+func (c *Client) ListAllStacks(ctx context.Context, params *ListParams) ([]StackResponse, error) {
+	return listAll(ctx, c, params, c.ListStacks)
+}
+
+// CountStacks returns the number of stacks matching the filter. It only
+// requests a single item and reads the total of the page, instead of
+// listing all matching stacks, unhydrated.
+func (c *Client) CountStacks(ctx context.Context, filter map[string]string) (int, error) {
+	params := &ListParams{Page: 1, PageSize: 1, Filter: filter, Fields: []string{"id"}}
+	page, err := listWithRetries(ctx, c, params, c.ListStacks)
+	if err != nil {
+		return 0, err
+	}
+	return page.Total, nil
+}
+
+// Component operations...
+func (c *Client) CreateComponent(ctx context.Context, workspace string, component ComponentRequest) (*ComponentResponse, error) {
+	component.Labels = c.withDefaultLabels(component.Labels)
+	endpoint := c.apiPath("workspaces", workspace, "components")
+	return create[ComponentResponse](ctx, c, endpoint, component)
+}
+
+func (c *Client) GetComponent(ctx context.Context, id string) (*ComponentResponse, error) {
+	result, status, err := getCached[ComponentResponse](ctx, c, c.apiPath("components", id))
+	if err != nil {
+		if status == 404 {
+			// Return nil if the component is not found
+			return nil, nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) UpdateComponent(ctx context.Context, id string, component ComponentUpdate) (*ComponentResponse, error) {
+	if component.Labels != nil {
+		component.Labels = c.withDefaultLabels(component.Labels)
+	}
+	return update[ComponentResponse](ctx, c, c.apiPath("components", id), component)
+}
+
+func (c *Client) DeleteComponent(ctx context.Context, id string) error {
+	return c.DeleteComponentWithOptions(ctx, id, false)
+}
+
+// DeleteComponentWithOptions deletes a component. With force, the server
+// deletes the component even if stacks still reference it.
+func (c *Client) DeleteComponentWithOptions(ctx context.Context, id string, force bool) error {
+	path := c.apiPath("components", id)
+	if force {
+		path += "?force=true"
+	}
+	return remove(ctx, c, path)
+}
+
+func (c *Client) ListStackComponents(ctx context.Context, workspace string, params *ListParams) (*Page[ComponentResponse], error) {
+	return listPage[ComponentResponse](ctx, c, c.apiPath("workspaces", workspace, "components"), params)
+}
+
+// ListAllStackComponents returns the components on all pages matching the
+// given list parameters. Like ListAllStacks, it returns the components
+// fetched so far along with the error of a failed page.
+func (c *Client) ListAllStackComponents(ctx context.Context, workspace string, params *ListParams) ([]ComponentResponse, error) {
+	return listAll(ctx, c, params, func(ctx context.Context, p *ListParams) (*Page[ComponentResponse], error) {
+		return c.ListStackComponents(ctx, workspace, p)
+	})
+}
+
+// ForEachComponent calls fn for every component matching the given list
+// parameters, fetching one page at a time. Unlike ListAllStackComponents it
+// never holds more than a page of components in memory. Iteration stops at
+// the first error returned by fn, which is returned as is.
+func (c *Client) ForEachComponent(ctx context.Context, workspace string, params *ListParams, fn func(ComponentResponse) error) error {
+	return forEach(ctx, c, params, func(ctx context.Context, p *ListParams) (*Page[ComponentResponse], error) {
+		return c.ListStackComponents(ctx, workspace, p)
+	}, fn)
+}
+
+// ErrNotFound is returned by lookups that must resolve to exactly one entity
+// when none matches.
+var ErrNotFound = errors.New("not found")
+
+// ErrAmbiguous is returned by lookups that must resolve to exactly one entity
+// when several match.
+var ErrAmbiguous = errors.New("ambiguous match")
+
+// GetComponentByNameAndType returns the component with the given name and
+// type in a workspace. Component names are only unique per type, so both are
+// needed to identify a component. It returns an error wrapping ErrNotFound if
+// no component matches and ErrAmbiguous if several do.
+func (c *Client) GetComponentByNameAndType(ctx context.Context, workspace, name, componentType string) (*ComponentResponse, error) {
+	params := &ListParams{
+		Filter: map[string]string{
+			"name":      name,
+			"type":      componentType,
+			"workspace": workspace,
+		},
+	}
+
+	components, err := c.ListStackComponents(ctx, workspace, params)
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(components.Items) {
+	case 0:
+		return nil, fmt.Errorf("%w: no %s component named %s in workspace %s", ErrNotFound, componentType, name, workspace)
+	case 1:
+		return &components.Items[0], nil
+	default:
+		return nil, fmt.Errorf("%w: %d %s components named %s in workspace %s", ErrAmbiguous, len(components.Items), componentType, name, workspace)
+	}
+}
+
+// ListStacksByComponent returns all stacks that reference a component.
+func (c *Client) ListStacksByComponent(ctx context.Context, componentID string) ([]StackResponse, error) {
+	params := &ListParams{
+		Filter: map[string]string{
+			"component_id": componentID,
+		},
+	}
+	return c.ListAllStacks(ctx, params)
+}
+
+// GetStackComponentsForStack returns the components of a stack grouped by
+// type, the way stacks reference them. A stack can have several components
+// of the same type, e.g. two step operators, which are ordered by name.
+func (c *Client) GetStackComponentsForStack(ctx context.Context, stackID string) (map[string][]ComponentResponse, error) {
+	components, err := c.ListComponentsByStack(ctx, stackID)
+	if err != nil {
+		return nil, err
+	}
+	return groupComponentsByType(components), nil
+}
+
+// groupComponentsByType groups components by type, ordered by name.
+func groupComponentsByType(components []ComponentResponse) map[string][]ComponentResponse {
+	byType := map[string][]ComponentResponse{}
+	for _, component := range components {
+		componentType := ""
+		if component.Body != nil {
+			componentType = component.Body.Type
+		}
+		byType[componentType] = append(byType[componentType], component)
+	}
+	for _, components := range byType {
+		sort.Slice(components, func(i, j int) bool {
+			if components[i].Name != components[j].Name {
+				return components[i].Name < components[j].Name
+			}
+			return components[i].ID < components[j].ID
+		})
+	}
+	return byType
+}
+
+// ListComponents lists the components of all workspaces.
+func (c *Client) ListComponents(ctx context.Context, params *ListParams) (*Page[ComponentResponse], error) {
+	return listPage[ComponentResponse](ctx, c, c.apiPath("components"), params)
+}
+
+// ListComponentsByStack returns all components that belong to a stack,
+// including their metadata.
+func (c *Client) ListComponentsByStack(ctx context.Context, stackID string) ([]ComponentResponse, error) {
+	params := &ListParams{
+		Filter: map[string]string{
+			"stack_id": stackID,
+			"hydrate":  "true",
+		},
+	}
+	return listAll(ctx, c, params, c.ListComponents)
+}
+
+// ListComponentsByConnector returns all components that use a service
+// connector, e.g. to tell which components keep it from being deleted.
+func (c *Client) ListComponentsByConnector(ctx context.Context, connectorID string) ([]ComponentResponse, error) {
+	params := &ListParams{
+		Filter: map[string]string{
+			"connector_id": connectorID,
+		},
+	}
+	return listAll(ctx, c, params, c.ListComponents)
+}
+
+// GetFlavor returns the flavor of the given component type and name,
+// including its configuration schema, or nil if there is no such flavor.
+// Flavors don't change while Terraform runs, so lookups are cached for the
+// lifetime of the client, including those that found no flavor.
+func (c *Client) GetFlavor(ctx context.Context, componentType, name string) (*FlavorResponse, error) {
+	key := componentType + "/" + name
+	c.flavorsMu.Lock()
+	flavor, ok := c.flavors[key]
+	c.flavorsMu.Unlock()
+	if ok {
+		return flavor, nil
+	}
+
+	flavor, err := c.getFlavor(ctx, componentType, name)
+	if err != nil {
+		return nil, err
+	}
+	c.flavorsMu.Lock()
+	if c.flavors == nil {
+		c.flavors = make(map[string]*FlavorResponse)
+	}
+	c.flavors[key] = flavor
+	c.flavorsMu.Unlock()
+	return flavor, nil
+}
+
+// ListFlavorsByName lists the flavors with the given name, of all
+// component types.
+func (c *Client) ListFlavorsByName(ctx context.Context, name string) ([]FlavorResponse, error) {
+	params := &ListParams{Filter: map[string]string{"name": name}}
+	return listAll(ctx, c, params, func(ctx context.Context, params *ListParams) (*Page[FlavorResponse], error) {
+		return listPage[FlavorResponse](ctx, c, c.apiPath("flavors"), params)
+	})
+}
+
+func (c *Client) getFlavor(ctx context.Context, componentType, name string) (*FlavorResponse, error) {
+	query := url.Values{}
+	query.Add("type", componentType)
+	query.Add("name", name)
+	query.Add("hydrate", "true")
+
+	path := c.apiPath("flavors") + "?" + query.Encode()
+	resp, _, err := c.doRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	result, err := decodeResponse[Page[FlavorResponse]](c, resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Items) == 0 {
+		return nil, nil
+	}
+	return &result.Items[0], nil
+}
+
+// maxConcurrentDeletes bounds the number of deletions DeleteComponentsBatch
+// and DeleteComponentsByFilter send to the server at the same time.
+const maxConcurrentDeletes = 4
+
+// DeleteComponentsBatch deletes components concurrently, e.g. to tear down
+// the components of a stack. Components that are already gone count as
+// deleted. Deletion errors don't stop the remaining deletions; they are
+// returned together as a *MultiError.
+func (c *Client) DeleteComponentsBatch(ctx context.Context, ids []string) error {
+	return newMultiError(c.deleteComponents(ctx, ids))
+}
+
+// deleteComponents deletes components concurrently and returns the error of
+// each deletion, in the order the IDs were given.
+func (c *Client) deleteComponents(ctx context.Context, ids []string) []*ItemError {
+	errs := make([]*ItemError, len(ids))
+	sem := make(chan struct{}, maxConcurrentDeletes)
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := c.DeleteComponent(ctx, id); err != nil {
+				errs[i] = &ItemError{Op: "deleting", Kind: "component", ID: id, Err: err}
+			}
+		}(i, id)
+	}
+	wg.Wait()
+	return errs
+}
+
+// DeleteComponentsByFilter deletes all components in a workspace that match
+// the given filter and returns them. All matching components are listed
+// before anything is deleted, so that deletions don't shift the pages being
+// read. With dryRun set, the matching components are returned without being
+// deleted. Deletion errors don't stop the remaining deletions; they are
+// returned together as a *MultiError and the failed components are left
+// out of the result.
+func (c *Client) DeleteComponentsByFilter(ctx context.Context, workspace string, filter map[string]string, dryRun bool) ([]ComponentResponse, error) {
+	components, err := c.ListAllStackComponents(ctx, workspace, &ListParams{Filter: filter})
+	if err != nil {
+		return nil, fmt.Errorf("error listing components: %w", err)
+	}
+	if dryRun {
+		return components, nil
+	}
+
+	ids := make([]string, len(components))
+	for i, component := range components {
+		ids[i] = component.ID
+	}
+	errs := c.deleteComponents(ctx, ids)
+
+	deleted := make([]ComponentResponse, 0, len(components))
+	for i, component := range components {
+		if errs[i] == nil {
+			deleted = append(deleted, component)
+		}
+	}
+	return deleted, newMultiError(errs)
+}
+
+// maxConcurrentCreates bounds the number of creations CreateComponentsBatch
+// sends to the server at the same time.
+const maxConcurrentCreates = 4
+
+// CreateComponentsBatch creates components concurrently, as the API has no
+// batch endpoint. Unlike sequential creation it doesn't stop at the first
+// failure: it returns the components that were created, in the order they
+// were given, along with a *MultiError of the components that could not be
+// created.
+func (c *Client) CreateComponentsBatch(ctx context.Context, workspace string, components []ComponentRequest) ([]ComponentResponse, error) {
+	results := make([]*ComponentResponse, len(components))
+	errs := make([]*ItemError, len(components))
+	sem := make(chan struct{}, maxConcurrentCreates)
+	var wg sync.WaitGroup
+	for i, component := range components {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, component ComponentRequest) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			resp, err := c.CreateComponent(ctx, workspace, component)
+			if err != nil {
+				errs[i] = &ItemError{Op: "creating", Kind: "component", ID: component.Name, Err: err}
+				return
+			}
+			results[i] = resp
+		}(i, component)
+	}
+	wg.Wait()
+
+	created := make([]ComponentResponse, 0, len(components))
+	for _, resp := range results {
+		if resp != nil {
+			created = append(created, *resp)
+		}
+	}
+	return created, newMultiError(errs)
+}
+
+// Service Connector operations...
+func (c *Client) VerifyServiceConnector(ctx context.Context, connector ServiceConnectorRequest) (*ServiceConnectorResources, error) {
+	return create[ServiceConnectorResources](ctx, c, c.apiPath("service_connectors", "verify"), connector)
+}
+
+func (c *Client) CreateServiceConnector(ctx context.Context, workspace string, connector ServiceConnectorRequest) (*ServiceConnectorResponse, error) {
+	connector.Labels = c.withDefaultLabels(connector.Labels)
+	endpoint := c.apiPath("workspaces", workspace, "service_connectors")
+	return create[ServiceConnectorResponse](ctx, c, endpoint, connector)
+}
+
+func (c *Client) GetServiceConnector(ctx context.Context, id string) (*ServiceConnectorResponse, error) {
+	return c.getServiceConnector(ctx, id, false)
+}
+
+// getServiceConnector reads a service connector. With expandSecrets set,
+// the secret values are included in the returned configuration.
+func (c *Client) getServiceConnector(ctx context.Context, id string, expandSecrets bool) (*ServiceConnectorResponse, error) {
+	path := c.apiPath("service_connectors", id)
+	if expandSecrets {
+		path += "?expand_secrets=true"
+	}
+	return get[ServiceConnectorResponse](ctx, c, path)
+}
+
+// GetServiceConnectorSecrets returns the secret values of a service
+// connector by key, unmasked. Values that aren't strings are JSON-encoded.
+func (c *Client) GetServiceConnectorSecrets(ctx context.Context, id string) (map[string]string, error) {
+	connector, err := c.getServiceConnector(ctx, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if connector == nil {
+		return nil, fmt.Errorf("service connector not found: %s", id)
+	}
+	secrets := map[string]string{}
+	if connector.Metadata != nil {
+		for key, value := range flattenConfiguration(connector.Metadata.Secrets) {
+			secrets[key] = value.(string)
+		}
+	}
+	return secrets, nil
+}
+
+func (c *Client) UpdateServiceConnector(ctx context.Context, id string, connector ServiceConnectorUpdate) (*ServiceConnectorResponse, error) {
+	if connector.Labels != nil {
+		connector.Labels = c.withDefaultLabels(connector.Labels)
 	}
-	return &result, nil
+	return update[ServiceConnectorResponse](ctx, c, c.apiPath("service_connectors", id), connector)
 }
 
-func (c *Client) UpdateStack(ctx context.Context, id string, stack StackUpdate) (*StackResponse, error) {
-	resp, _, err := c.doRequest(ctx, "PUT", fmt.Sprintf("/api/v1/stacks/%s", id), stack)
+// UpdateAndVerifyServiceConnector updates a service connector and verifies
+// it with the updated configuration. If the verification fails, the previous
+// configuration, which is read with its secrets before the update, is
+// restored and an error is returned.
+func (c *Client) UpdateAndVerifyServiceConnector(ctx context.Context, id string, update ServiceConnectorUpdate) (*ServiceConnectorResponse, error) {
+	previous, err := c.getServiceConnector(ctx, id, true)
+	if err != nil {
+		return nil, fmt.Errorf("error reading service connector before update: %w", err)
+	}
+	if previous == nil {
+		return nil, fmt.Errorf("service connector not found: %s", id)
+	}
+
+	updated, err := c.UpdateServiceConnector(ctx, id, update)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	var result StackResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("error decoding response: %v", err)
+	resources, verifyErr := c.ListServiceConnectorResources(ctx, id, "", 0)
+	if verifyErr == nil && resources.Error != nil {
+		verifyErr = errors.New(*resources.Error)
+	}
+	if verifyErr == nil {
+		return updated, nil
 	}
-	return &result, nil
-}
 
-func (c *Client) DeleteStack(ctx context.Context, id string) error {
-	resp, status, err := c.doRequest(ctx, "DELETE", fmt.Sprintf("/api/v1/stacks/%s", id), nil)
-	if err != nil {
-		if status == 404 {
-			// Return nil if the stack is not found
-			return nil
-		}
-		return err
+	err = fmt.Errorf("error verifying updated service connector, previous configuration restored: %w", verifyErr)
+	if _, rollbackErr := c.UpdateServiceConnector(ctx, id, previousServiceConnectorUpdate(previous)); rollbackErr != nil {
+		err = fmt.Errorf("error verifying updated service connector: %w (additionally, restoring the previous configuration failed: %v)", verifyErr, rollbackErr)
 	}
-	resp.Body.Close()
-	return nil
+	return nil, err
 }
 
-func (c *Client) ListStacks(ctx context.Context, params *ListParams) (*Page[StackResponse], error) {
-	if params == nil {
-		params = &ListParams{
-			Page:     1,
-			PageSize: 100,
+// previousServiceConnectorUpdate returns the update that restores a service
+// connector to the given state.
+func previousServiceConnectorUpdate(previous *ServiceConnectorResponse) ServiceConnectorUpdate {
+	name := previous.Name
+	restore := ServiceConnectorUpdate{
+		Name:          &name,
+		Configuration: map[string]interface{}{},
+		Labels:        map[string]string{},
+		ResourceTypes: []string{},
+	}
+	if previous.Body != nil {
+		if previous.Body.ResourceTypes != nil {
+			restore.ResourceTypes = previous.Body.ResourceTypes
+		}
+		restore.ResourceID = previous.Body.ResourceID
+		restore.ExpiresAt = previous.Body.ExpiresAt
+	}
+	if previous.Metadata != nil {
+		if previous.Metadata.Configuration != nil {
+			restore.Configuration = previous.Metadata.Configuration
+		}
+		if previous.Metadata.Labels != nil {
+			restore.Labels = previous.Metadata.Labels
 		}
-	} else {
-		if params.Page <= 0 {
-			params.Page = 1
+		if len(previous.Metadata.Secrets) > 0 {
+			restore.Secrets = map[string]string{}
+			for key, value := range flattenConfiguration(previous.Metadata.Secrets) {
+				restore.Secrets[key] = value.(string)
+			}
 		}
-		if params.PageSize <= 0 {
-			params.PageSize = 100
+	}
+	return restore
+}
+
+// ListServiceConnectorResources lists the resources that a service connector
+// can access, optionally restricted to a single resource type. Listing
+// resources calls out to the cloud provider and can be slow, so when cacheTTL
+// is positive the result is cached and reused for that long.
+func (c *Client) ListServiceConnectorResources(ctx context.Context, id, resourceType string, cacheTTL time.Duration) (*ServiceConnectorResources, error) {
+	key := id + "/" + resourceType
+	if cacheTTL > 0 {
+		c.connectorResourcesMu.Lock()
+		entry, ok := c.connectorResources[key]
+		c.connectorResourcesMu.Unlock()
+		if ok && time.Now().Before(entry.expires) {
+			return entry.resources, nil
 		}
 	}
 
 	query := url.Values{}
-	query.Add("page", fmt.Sprintf("%d", params.Page))
-	query.Add("size", fmt.Sprintf("%d", params.PageSize))
-
-	for k, v := range params.Filter {
-		query.Add(k, v)
+	query.Add("list_resources", "true")
+	if resourceType != "" {
+		query.Add("resource_type", resourceType)
 	}
 
-	path := fmt.Sprintf("/api/v1/stacks?%s", query.Encode())
-	resp, _, err := c.doRequest(ctx, "GET", path, nil)
+	path := c.apiPath("service_connectors", id, "verify") + "?" + query.Encode()
+	resp, _, err := c.doRequest(ctx, "PUT", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	result, err := decodeResponse[ServiceConnectorResources](c, resp)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	var result Page[StackResponse]
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("error decoding response: %v", err)
+	// Not all servers honor the resource type filter
+	if resourceType != "" {
+		filtered := make([]ServiceConnectorTypedResources, 0, len(result.Resources))
+		for _, r := range result.Resources {
+			if r.ResourceType == resourceType {
+				filtered = append(filtered, r)
+			}
+		}
+		result.Resources = filtered
 	}
 
-	return &result, nil
+	if cacheTTL > 0 {
+		c.connectorResourcesMu.Lock()
+		if c.connectorResources == nil {
+			c.connectorResources = make(map[string]connectorResourcesCacheEntry)
+		}
+		c.connectorResources[key] = connectorResourcesCacheEntry{
+			resources: result,
+			expires:   time.Now().Add(cacheTTL),
+		}
+		c.connectorResourcesMu.Unlock()
+	}
+
+	return result, nil
 }
 
-// Component operations...
-func (c *Client) CreateComponent(ctx context.Context, workspace string, component ComponentRequest) (*ComponentResponse, error) {
-	endpoint := fmt.Sprintf("/api/v1/workspaces/%s/components", workspace)
-	resp, _, err := c.doRequest(ctx, "POST", endpoint, component)
+type connectorResourcesCacheEntry struct {
+	resources *ServiceConnectorResources
+	expires   time.Time
+}
+
+func (c *Client) DeleteServiceConnector(ctx context.Context, id string) error {
+	return remove(ctx, c, c.apiPath("service_connectors", id))
+}
+
+func (c *Client) ListServiceConnectors(ctx context.Context, params *ListParams) (*Page[ServiceConnectorResponse], error) {
+	return listPage[ServiceConnectorResponse](ctx, c, c.apiPath("service_connectors"), params)
+}
+
+// ListAllServiceConnectors returns the service connectors on all pages
+// matching the given list parameters. Like ListAllStacks, it returns the
+// connectors fetched so far along with the error of a failed page.
+func (c *Client) ListAllServiceConnectors(ctx context.Context, params *ListParams) ([]ServiceConnectorResponse, error) {
+	return listAll(ctx, c, params, c.ListServiceConnectors)
+}
+
+// ListExpiringConnectors returns the service connectors whose credentials
+// expire within the given duration from now, including connectors that
+// have already expired, soonest first. Connectors without an expiration
+// time are excluded.
+func (c *Client) ListExpiringConnectors(ctx context.Context, within time.Duration) ([]ServiceConnectorResponse, error) {
+	connectors, err := c.ListAllServiceConnectors(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	var result ComponentResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("error decoding response: %v", err)
+	deadline := time.Now().Add(within)
+	expiring := []ServiceConnectorResponse{}
+	expiresAt := map[string]time.Time{}
+	for _, connector := range connectors {
+		t, ok, err := connectorExpiresAt(&connector)
+		if err != nil {
+			return nil, err
+		}
+		if !ok || t.After(deadline) {
+			continue
+		}
+		expiresAt[connector.ID] = t
+		expiring = append(expiring, connector)
 	}
-	return &result, nil
+
+	sort.SliceStable(expiring, func(i, j int) bool {
+		return expiresAt[expiring[i].ID].Before(expiresAt[expiring[j].ID])
+	})
+	return expiring, nil
 }
 
-func (c *Client) GetComponent(ctx context.Context, id string) (*ComponentResponse, error) {
-	resp, status, err := c.doRequest(ctx, "GET", fmt.Sprintf("/api/v1/components/%s", id), nil)
+// GetServiceConnectorByName returns the service connector with the given
+// name in a workspace, without its secrets: the secret keys are removed from
+// its configuration. It returns an error wrapping ErrNotFound if there is no
+// such connector and ErrAmbiguous if several match.
+func (c *Client) GetServiceConnectorByName(ctx context.Context, workspace, name string) (*ServiceConnectorResponse, error) {
+	connector, err := c.FindServiceConnector(ctx, workspace, map[string]string{"name": name})
 	if err != nil {
-		if status == 404 {
-			// Return nil if the component is not found
-			return nil, nil
-		}
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	var result ComponentResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("error decoding response: %v", err)
+	if connector.Metadata != nil {
+		connector.Metadata.Configuration = nonSecretConfiguration(connector.Metadata)
+		connector.Metadata.Secrets = nil
 	}
-	return &result, nil
+	return connector, nil
 }
 
-func (c *Client) UpdateComponent(ctx context.Context, id string, component ComponentUpdate) (*ComponentResponse, error) {
-	resp, _, err := c.doRequest(ctx, "PUT", fmt.Sprintf("/api/v1/components/%s", id), component)
+// FindServiceConnector returns the service connector in a workspace that
+// matches the given list filter, e.g. on "connector_type" and
+// "resource_type". It returns an error wrapping ErrNotFound if no connector
+// matches and ErrAmbiguous if several do.
+func (c *Client) FindServiceConnector(ctx context.Context, workspace string, filter map[string]string) (*ServiceConnectorResponse, error) {
+	params := &ListParams{Filter: map[string]string{"workspace": workspace}}
+	for k, v := range filter {
+		params.Filter[k] = v
+	}
+
+	connectors, err := c.ListServiceConnectors(ctx, params)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	var result ComponentResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("error decoding response: %v", err)
+	switch len(connectors.Items) {
+	case 0:
+		return nil, fmt.Errorf("%w: no service connector in workspace %s matches the filter", ErrNotFound, workspace)
+	case 1:
+		return &connectors.Items[0], nil
+	default:
+		names := make([]string, len(connectors.Items))
+		for i, connector := range connectors.Items {
+			names[i] = connector.Name
+		}
+		return nil, fmt.Errorf("%w: %d service connectors in workspace %s match the filter: %s", ErrAmbiguous, max(connectors.Total, len(names)), workspace, listItems(names))
 	}
-	return &result, nil
 }
 
-func (c *Client) DeleteComponent(ctx context.Context, id string) error {
-	resp, status, err := c.doRequest(ctx, "DELETE", fmt.Sprintf("/api/v1/components/%s", id), nil)
-	if err != nil {
-		if status == 404 {
-			// Return nil if the component is not found
-			return nil
-		}
-		return err
-	}
-	resp.Body.Close()
-	return nil
+// Add this new method to the Client
+func (c *Client) GetWorkspaceByName(ctx context.Context, name string) (*WorkspaceResponse, error) {
+	return get[WorkspaceResponse](ctx, c, c.apiPath("workspaces", name))
 }
 
-func (c *Client) ListStackComponents(ctx context.Context, workspace string, params *ListParams) (*Page[ComponentResponse], error) {
-	if params == nil {
-		params = &ListParams{
-			Page:     1,
-			PageSize: 100,
-		}
-	} else {
-		if params.Page <= 0 {
-			params.Page = 1
-		}
-		if params.PageSize <= 0 {
-			params.PageSize = 100
-		}
-	}
+// Add this method to get the current user
+func (c *Client) GetCurrentUser(ctx context.Context) (*UserResponse, error) {
+	return send[UserResponse](ctx, c, "GET", c.apiPath("current-user"), nil)
+}
 
-	query := url.Values{}
-	query.Add("page", fmt.Sprintf("%d", params.Page))
-	query.Add("size", fmt.Sprintf("%d", params.PageSize))
-	for k, v := range params.Filter {
-		query.Add(k, v)
-	}
+// User operations
+func (c *Client) ListUsers(ctx context.Context, params *ListParams) (*Page[UserResponse], error) {
+	return listPage[UserResponse](ctx, c, c.apiPath("users"), params)
+}
 
-	path := fmt.Sprintf("/api/v1/workspaces/%s/components?%s", workspace, query.Encode())
-	resp, _, err := c.doRequest(ctx, "GET", path, nil)
+// GetUserByName returns the user or service account with the given name.
+// It returns an error wrapping ErrNotFound if there is no such user, and one
+// wrapping ErrAmbiguous if several users have the name.
+func (c *Client) GetUserByName(ctx context.Context, name string) (*UserResponse, error) {
+	users, err := c.ListUsers(ctx, &ListParams{Filter: map[string]string{"name": name}})
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	var result Page[ComponentResponse]
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("error decoding response: %v", err)
+	switch len(users.Items) {
+	case 0:
+		return nil, fmt.Errorf("%w: no user named %s", ErrNotFound, name)
+	case 1:
+		return &users.Items[0], nil
+	default:
+		return nil, fmt.Errorf("%w: %d users named %s", ErrAmbiguous, max(users.Total, len(users.Items)), name)
 	}
+}
 
-	return &result, nil
+// Team operations. Teams are only available on ZenML Pro servers.
+func (c *Client) ListTeams(ctx context.Context, params *ListParams) (*Page[TeamResponse], error) {
+	return listPage[TeamResponse](ctx, c, c.apiPath("teams"), params)
 }
 
-// Service Connector operations...
-func (c *Client) VerifyServiceConnector(ctx context.Context, connector ServiceConnectorRequest) (*ServiceConnectorResources, error) {
-	resp, _, err := c.doRequest(ctx, "POST", "/api/v1/service_connectors/verify", connector)
+// GetTeamByName returns the team with the given name. It returns an error
+// wrapping ErrNotFound if there is no such team, and one wrapping
+// ErrAmbiguous if several teams have the name.
+func (c *Client) GetTeamByName(ctx context.Context, name string) (*TeamResponse, error) {
+	teams, err := c.ListTeams(ctx, &ListParams{Filter: map[string]string{"name": name, "hydrate": "true"}})
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	var result ServiceConnectorResources
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("error decoding response: %v", err)
+	switch len(teams.Items) {
+	case 0:
+		return nil, fmt.Errorf("%w: no team named %s", ErrNotFound, name)
+	case 1:
+		return &teams.Items[0], nil
+	default:
+		return nil, fmt.Errorf("%w: %d teams named %s", ErrAmbiguous, max(teams.Total, len(teams.Items)), name)
 	}
-	return &result, nil
 }
 
-func (c *Client) CreateServiceConnector(ctx context.Context, workspace string, connector ServiceConnectorRequest) (*ServiceConnectorResponse, error) {
-	endpoint := fmt.Sprintf("/api/v1/workspaces/%s/service_connectors", workspace)
-	resp, _, err := c.doRequest(ctx, "POST", endpoint, connector)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+// Model operations
+func (c *Client) CreateModel(ctx context.Context, model ModelRequest) (*ModelResponse, error) {
+	return create[ModelResponse](ctx, c, c.apiPath("models"), model)
+}
 
-	var result ServiceConnectorResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("error decoding response: %v", err)
-	}
-	return &result, nil
+func (c *Client) GetModel(ctx context.Context, id string) (*ModelResponse, error) {
+	return get[ModelResponse](ctx, c, c.apiPath("models", id))
 }
 
-func (c *Client) GetServiceConnector(ctx context.Context, id string) (*ServiceConnectorResponse, error) {
-	resp, status, err := c.doRequest(ctx, "GET", fmt.Sprintf("/api/v1/service_connectors/%s", id), nil)
-	if err != nil {
-		if status == 404 {
-			// Return nil if the service connector is not found
-			return nil, nil
-		}
-		return nil, err
-	}
-	defer resp.Body.Close()
+func (c *Client) UpdateModel(ctx context.Context, id string, model ModelUpdate) (*ModelResponse, error) {
+	return update[ModelResponse](ctx, c, c.apiPath("models", id), model)
+}
 
-	var result ServiceConnectorResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("error decoding response: %v", err)
-	}
+func (c *Client) DeleteModel(ctx context.Context, id string) error {
+	return remove(ctx, c, c.apiPath("models", id))
+}
 
-	return &result, nil
+// Model version operations
+func (c *Client) CreateModelVersion(ctx context.Context, version ModelVersionRequest) (*ModelVersionResponse, error) {
+	return create[ModelVersionResponse](ctx, c, c.apiPath("model_versions"), version)
 }
 
-func (c *Client) UpdateServiceConnector(ctx context.Context, id string, connector ServiceConnectorUpdate) (*ServiceConnectorResponse, error) {
-	resp, _, err := c.doRequest(ctx, "PUT", fmt.Sprintf("/api/v1/service_connectors/%s", id), connector)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+func (c *Client) GetModelVersion(ctx context.Context, id string) (*ModelVersionResponse, error) {
+	return get[ModelVersionResponse](ctx, c, c.apiPath("model_versions", id))
+}
 
-	var result ServiceConnectorResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("error decoding response: %v", err)
+func (c *Client) UpdateModelVersion(ctx context.Context, id string, version ModelVersionUpdate) (*ModelVersionResponse, error) {
+	return update[ModelVersionResponse](ctx, c, c.apiPath("model_versions", id), version)
+}
+
+func (c *Client) DeleteModelVersion(ctx context.Context, id string) error {
+	return remove(ctx, c, c.apiPath("model_versions", id))
+}
+
+// Code repository operations
+
+func (c *Client) CreateCodeRepository(ctx context.Context, repository CodeRepositoryRequest) (*CodeRepositoryResponse, error) {
+	return create[CodeRepositoryResponse](ctx, c, c.apiPath("code_repositories"), repository)
+}
+
+func (c *Client) GetCodeRepository(ctx context.Context, id string) (*CodeRepositoryResponse, error) {
+	return get[CodeRepositoryResponse](ctx, c, c.apiPath("code_repositories", id))
+}
+
+func (c *Client) UpdateCodeRepository(ctx context.Context, id string, repository CodeRepositoryUpdate) (*CodeRepositoryResponse, error) {
+	return update[CodeRepositoryResponse](ctx, c, c.apiPath("code_repositories", id), repository)
+}
+
+func (c *Client) DeleteCodeRepository(ctx context.Context, id string) error {
+	return remove(ctx, c, c.apiPath("code_repositories", id))
+}
+
+// Project operations
+func (c *Client) CreateProject(ctx context.Context, project ProjectRequest) (*ProjectResponse, error) {
+	return create[ProjectResponse](ctx, c, c.apiPath("projects"), project)
+}
+
+// GetProject returns the project with the given name or ID, or nil if there
+// is no such project.
+func (c *Client) GetProject(ctx context.Context, nameOrID string) (*ProjectResponse, error) {
+	return get[ProjectResponse](ctx, c, c.apiPath("projects", nameOrID))
+}
+
+func (c *Client) UpdateProject(ctx context.Context, id string, project ProjectUpdate) (*ProjectResponse, error) {
+	return update[ProjectResponse](ctx, c, c.apiPath("projects", id), project)
+}
+
+func (c *Client) DeleteProject(ctx context.Context, id string) error {
+	return remove(ctx, c, c.apiPath("projects", id))
+}
+
+// Artifact operations. Artifacts are produced by pipeline runs, so they are
+// read-only here.
+func (c *Client) GetArtifact(ctx context.Context, id string) (*ArtifactResponse, error) {
+	return get[ArtifactResponse](ctx, c, c.apiPath("artifacts", id))
+}
+
+func (c *Client) ListArtifacts(ctx context.Context, params *ListParams) (*Page[ArtifactResponse], error) {
+	return listPage[ArtifactResponse](ctx, c, c.apiPath("artifacts"), params)
+}
+
+func (c *Client) GetArtifactVersion(ctx context.Context, id string) (*ArtifactVersionResponse, error) {
+	return get[ArtifactVersionResponse](ctx, c, c.apiPath("artifact_versions", id))
+}
+
+func (c *Client) ListArtifactVersions(ctx context.Context, params *ListParams) (*Page[ArtifactVersionResponse], error) {
+	return listPage[ArtifactVersionResponse](ctx, c, c.apiPath("artifact_versions"), params)
+}
+
+// Tag operations
+func (c *Client) CreateTag(ctx context.Context, tag TagRequest) (*TagResponse, error) {
+	return create[TagResponse](ctx, c, c.apiPath("tags"), tag)
+}
+
+func (c *Client) GetTag(ctx context.Context, id string) (*TagResponse, error) {
+	return get[TagResponse](ctx, c, c.apiPath("tags", id))
+}
+
+func (c *Client) UpdateTag(ctx context.Context, id string, tag TagUpdate) (*TagResponse, error) {
+	return update[TagResponse](ctx, c, c.apiPath("tags", id), tag)
+}
+
+func (c *Client) DeleteTag(ctx context.Context, id string) error {
+	return remove(ctx, c, c.apiPath("tags", id))
+}
+
+// AttachTag attaches a tag to a resource, e.g. a model, pipeline run or
+// artifact, identified by its ID and type.
+func (c *Client) AttachTag(ctx context.Context, tagID, resourceID, resourceType string) error {
+	attachment := TagResourceRequest{
+		TagID:        tagID,
+		ResourceID:   resourceID,
+		ResourceType: resourceType,
 	}
-	return &result, nil
+	resp, _, err := c.doRequest(ctx, "POST", c.apiPath("tag_resources"), attachment)
+	if err != nil {
+		return err
+	}
+	closeResponse(resp)
+	return nil
 }
 
-func (c *Client) DeleteServiceConnector(ctx context.Context, id string) error {
-	resp, status, err := c.doRequest(ctx, "DELETE", fmt.Sprintf("/api/v1/service_connectors/%s", id), nil)
+// DetachTag detaches a tag from a resource. Detaching a tag that isn't
+// attached is not an error.
+func (c *Client) DetachTag(ctx context.Context, tagID, resourceID, resourceType string) error {
+	attachment := TagResourceRequest{
+		TagID:        tagID,
+		ResourceID:   resourceID,
+		ResourceType: resourceType,
+	}
+	resp, status, err := c.doRequest(ctx, "DELETE", c.apiPath("tag_resources"), attachment)
 	if err != nil {
 		if status == 404 {
-			// Return nil if the service connector is not found
 			return nil
 		}
 		return err
 	}
-	resp.Body.Close()
+	closeResponse(resp)
 	return nil
 }
 
-func (c *Client) ListServiceConnectors(ctx context.Context, params *ListParams) (*Page[ServiceConnectorResponse], error) {
-	if params == nil {
-		params = &ListParams{
-			Page:     1,
-			PageSize: 100,
-		}
-	} else {
-		if params.Page <= 0 {
-			params.Page = 1
-		}
-		if params.PageSize <= 0 {
-			params.PageSize = 100
-		}
-	}
+// Event source and trigger operations. Both are only available on ZenML Pro
+// servers.
+func (c *Client) CreateEventSource(ctx context.Context, eventSource EventSourceRequest) (*EventSourceResponse, error) {
+	return create[EventSourceResponse](ctx, c, c.apiPath("event_sources"), eventSource)
+}
 
-	query := url.Values{}
-	query.Add("page", fmt.Sprintf("%d", params.Page))
-	query.Add("size", fmt.Sprintf("%d", params.PageSize))
-	for k, v := range params.Filter {
-		query.Add(k, v)
-	}
+func (c *Client) GetEventSource(ctx context.Context, id string) (*EventSourceResponse, error) {
+	return get[EventSourceResponse](ctx, c, c.apiPath("event_sources", id))
+}
 
-	path := fmt.Sprintf("/api/v1/service_connectors?%s", query.Encode())
-	resp, _, err := c.doRequest(ctx, "GET", path, nil)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+func (c *Client) UpdateEventSource(ctx context.Context, id string, eventSource EventSourceUpdate) (*EventSourceResponse, error) {
+	return update[EventSourceResponse](ctx, c, c.apiPath("event_sources", id), eventSource)
+}
 
-	var result Page[ServiceConnectorResponse]
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("error decoding response: %v", err)
+func (c *Client) DeleteEventSource(ctx context.Context, id string) error {
+	return remove(ctx, c, c.apiPath("event_sources", id))
+}
+
+func (c *Client) ListTriggers(ctx context.Context, params *ListParams) (*Page[TriggerResponse], error) {
+	return listPage[TriggerResponse](ctx, c, c.apiPath("triggers"), params)
+}
+
+// ListTriggersByEventSource returns all triggers that listen to an event
+// source.
+func (c *Client) ListTriggersByEventSource(ctx context.Context, eventSourceID string) ([]TriggerResponse, error) {
+	params := &ListParams{
+		Filter: map[string]string{
+			"event_source_id": eventSourceID,
+		},
 	}
+	return listAll(ctx, c, params, c.ListTriggers)
+}
 
-	return &result, nil
+func (c *Client) CreateTrigger(ctx context.Context, trigger TriggerRequest) (*TriggerResponse, error) {
+	return create[TriggerResponse](ctx, c, c.apiPath("triggers"), trigger)
 }
 
-// Add this new method to the Client
-func (c *Client) GetServiceConnectorByName(ctx context.Context, workspace, name string) (*ServiceConnectorResponse, error) {
+func (c *Client) GetTrigger(ctx context.Context, id string) (*TriggerResponse, error) {
+	return get[TriggerResponse](ctx, c, c.apiPath("triggers", id))
+}
+
+func (c *Client) UpdateTrigger(ctx context.Context, id string, trigger TriggerUpdate) (*TriggerResponse, error) {
+	return update[TriggerResponse](ctx, c, c.apiPath("triggers", id), trigger)
+}
+
+func (c *Client) DeleteTrigger(ctx context.Context, id string) error {
+	return remove(ctx, c, c.apiPath("triggers", id))
+}
+
+// Secret operations
+func (c *Client) ListSecrets(ctx context.Context, params *ListParams) (*Page[SecretResponse], error) {
+	return listPage[SecretResponse](ctx, c, c.apiPath("secrets"), params)
+}
+
+// GetSecretByName returns the secret with the given name in a workspace,
+// without its values. It returns an error wrapping ErrNotFound if there is
+// no such secret, and one wrapping ErrAmbiguous if both a user-scoped and a
+// workspace-scoped secret have the name.
+func (c *Client) GetSecretByName(ctx context.Context, workspace, name string) (*SecretResponse, error) {
 	params := &ListParams{
 		Filter: map[string]string{
 			"name":      name,
@@ -508,49 +3101,98 @@ func (c *Client) GetServiceConnectorByName(ctx context.Context, workspace, name
 		},
 	}
 
-	connectors, err := c.ListServiceConnectors(ctx, params)
+	secrets, err := c.ListSecrets(ctx, params)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(connectors.Items) == 0 {
-		return nil, nil
+	switch len(secrets.Items) {
+	case 0:
+		return nil, fmt.Errorf("%w: no secret named %s in workspace %s", ErrNotFound, name, workspace)
+	case 1:
+		return &secrets.Items[0], nil
+	default:
+		return nil, fmt.Errorf("%w: %d secrets named %s in workspace %s", ErrAmbiguous, max(secrets.Total, len(secrets.Items)), name, workspace)
 	}
+}
 
-	return &connectors.Items[0], nil
+// Pipeline run operations
+func (c *Client) ListRuns(ctx context.Context, params *ListParams) (*Page[RunResponse], error) {
+	return listPage[RunResponse](ctx, c, c.apiPath("runs"), params)
 }
 
-// Add this new method to the Client
-func (c *Client) GetWorkspaceByName(ctx context.Context, name string) (*WorkspaceResponse, error) {
-	resp, status, err := c.doRequest(ctx, "GET", fmt.Sprintf("/api/v1/workspaces/%s", name), nil)
+func (c *Client) GetRun(ctx context.Context, id string) (*RunResponse, error) {
+	return get[RunResponse](ctx, c, c.apiPath("runs", id))
+}
+
+// GetRunByName returns the pipeline run with the given name, or nil if
+// there is no such run.
+func (c *Client) GetRunByName(ctx context.Context, name string) (*RunResponse, error) {
+	params := &ListParams{
+		Filter: map[string]string{
+			"name":    name,
+			"hydrate": "true",
+		},
+	}
+
+	runs, err := c.ListRuns(ctx, params)
 	if err != nil {
-		if status == 404 {
-			// Return nil if the workspace is not found
-			return nil, nil
-		}
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	var result WorkspaceResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("error decoding response: %v", err)
+	if len(runs.Items) == 0 {
+		return nil, nil
 	}
-
-	return &result, nil
+	return &runs.Items[0], nil
 }
 
-// Add this method to get the current user
-func (c *Client) GetCurrentUser(ctx context.Context) (*UserResponse, error) {
-	resp, _, err := c.doRequest(ctx, "GET", "/api/v1/current-user", nil)
+// defaultRunHistory bounds how far back ListPipelinesByStack looks for runs
+// when no creation time filter is given.
+const defaultRunHistory = 30 * 24 * time.Hour
+
+// ListPipelinesByStack returns the pipelines that ran on a stack, most
+// recently run first. The runs are scanned page by page; unless params
+// filters on the run creation time, only runs from the last 30 days are
+// considered to avoid scanning the whole run history.
+func (c *Client) ListPipelinesByStack(ctx context.Context, stackID string, params *ListParams) ([]PipelineResponse, error) {
+	params = c.listParams(params)
+
+	filter := map[string]string{}
+	for k, v := range params.Filter {
+		filter[k] = v
+	}
+	filter["stack_id"] = stackID
+	if !filterHasField(filter, "created") {
+		filter["created"] = "gte:" + formatFilterTime(time.Now().Add(-defaultRunHistory))
+	}
+	if !filterHasField(filter, "sort_by") {
+		filter["sort_by"] = "desc:created"
+	}
+	params.Filter = filter
+
+	runs, err := listAll(ctx, c, params, c.ListRuns)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	var result UserResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("error decoding user response: %v", err)
+	pipelines := []PipelineResponse{}
+	seen := map[string]bool{}
+	for _, run := range runs {
+		// Runs that aren't associated with a pipeline are skipped
+		if run.Body == nil || run.Body.Pipeline == nil || seen[run.Body.Pipeline.ID] {
+			continue
+		}
+		seen[run.Body.Pipeline.ID] = true
+		pipelines = append(pipelines, *run.Body.Pipeline)
 	}
-	return &result, nil
+
+	return pipelines, nil
+}
+
+// parseServerTime parses a timestamp returned by the server. The server
+// reports times in UTC, usually without a timezone designator.
+func parseServerTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t.UTC(), nil
+	}
+	return time.ParseInLocation("2006-01-02T15:04:05.999999999", value, time.UTC)
 }
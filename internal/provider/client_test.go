@@ -0,0 +1,2180 @@
+package provider
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientTokenAuth(t *testing.T) {
+	var logins int32
+	var revoked atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/login":
+			if err := r.ParseForm(); err != nil || r.PostForm.Get("password") != "test-key" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			n := atomic.AddInt32(&logins, 1)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"access_token": fmt.Sprintf("token-%d", n),
+				"expires_in":   3600,
+			})
+		case "/api/v1/stacks/test":
+			// The first token is revoked once the test flips the switch
+			if r.Header.Get("Authorization") == "Bearer token-1" && revoked.Load() {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.Header.Get("Authorization") == "Bearer test-key" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode(StackResponse{ID: "test", Name: "test"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key", "", WithTokenAuth())
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.GetStack(ctx, "test"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&logins); n != 1 {
+		t.Fatalf("expected the access token to be cached after 1 login, got %d logins", n)
+	}
+
+	revoked.Store(true)
+	if _, err := client.GetStack(ctx, "test"); err != nil {
+		t.Fatalf("expected re-authentication after a 401, got error: %v", err)
+	}
+	if n := atomic.LoadInt32(&logins); n != 2 {
+		t.Fatalf("expected a second login after a 401, got %d logins", n)
+	}
+	if client.APIToken != "token-2" {
+		t.Errorf("expected the refreshed token to be cached, got %q", client.APIToken)
+	}
+}
+
+func TestClientAPIKeyAuth(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/login" {
+			t.Errorf("unexpected login request without token auth")
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("expected the API key as bearer token, got %q", got)
+		}
+		json.NewEncoder(w).Encode(StackResponse{ID: "test"})
+	}))
+	client.APIKey = "test-key"
+	client.APIToken = ""
+
+	if _, err := client.GetStack(context.Background(), "test"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestClientGzipResponse(t *testing.T) {
+	var requests int32
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			t.Errorf("expected gzip to be accepted, got %q", r.Header.Get("Accept-Encoding"))
+		}
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		json.NewEncoder(zw).Encode(Page[ComponentResponse]{
+			Index:      1,
+			TotalPages: 1,
+			Total:      2,
+			Items:      []ComponentResponse{{ID: "a", Name: "store"}, {ID: "b", Name: "registry"}},
+		})
+		zw.Close()
+		body := buf.Bytes()
+		if atomic.AddInt32(&requests, 1) == 1 {
+			// A proxy cutting the compressed response short
+			body = body[:len(body)/2]
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(body)
+	}))
+	client.RetryWaitMin = time.Millisecond
+	client.RetryWaitMax = time.Millisecond
+
+	page, err := client.ListComponents(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(page.Items) != 2 || page.Items[1].Name != "registry" {
+		t.Errorf("expected the decompressed components, got %+v", page.Items)
+	}
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("expected the truncated response to be retried, got %d requests", n)
+	}
+
+	// The size limit applies to the decompressed body
+	client.MaxResponseBytes = 16
+	if _, err := client.ListComponents(context.Background(), nil); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("expected ErrResponseTooLarge, got %v", err)
+	}
+}
+
+func TestClientRetriesTruncatedResponse(t *testing.T) {
+	var requests int32
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			// Simulate a proxy cutting the response short
+			w.Write([]byte(`{"id": "test", "na`))
+			return
+		}
+		json.NewEncoder(w).Encode(StackResponse{ID: "test", Name: "test"})
+	}))
+	client.RetryWaitMin = time.Millisecond
+	client.RetryWaitMax = 10 * time.Millisecond
+
+	stack, err := client.GetStack(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stack.Name != "test" {
+		t.Errorf("expected stack name %q, got %q", "test", stack.Name)
+	}
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("expected 2 requests, got %d", n)
+	}
+}
+
+func TestClientRetryCancellation(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/stacks/test" {
+			w.Write([]byte(`{"id": "test", "na`))
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	client.RetryWaitMin = time.Minute
+	client.RetryWaitMax = time.Minute
+
+	tests := []struct {
+		name string
+		call func(context.Context) error
+	}{
+		{"truncated response", func(ctx context.Context) error {
+			_, err := client.GetStack(ctx, "test")
+			return err
+		}},
+		{"failed page", func(ctx context.Context) error {
+			_, err := client.ListAllStacks(ctx, nil)
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(50*time.Millisecond, cancel)
+
+			start := time.Now()
+			err := tt.call(ctx)
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("expected the cancellation error, got %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("expected the backoff to be cut short by the cancellation, returned after %s", elapsed)
+			}
+		})
+	}
+}
+
+func TestClientDoesNotRetryMalformedResponse(t *testing.T) {
+	var requests int32
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(`{"id": test}`))
+	}))
+	client.RetryWaitMin = time.Millisecond
+	client.RetryWaitMax = 10 * time.Millisecond
+
+	if _, err := client.GetStack(context.Background(), "test"); err == nil {
+		t.Fatal("expected a decode error for malformed JSON")
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("expected malformed JSON not to be retried, got %d requests", n)
+	}
+}
+
+func TestClientDoesNotRetryTruncatedPost(t *testing.T) {
+	var requests int32
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(`{"id": "test", "na`))
+	}))
+	client.RetryWaitMin = time.Millisecond
+	client.RetryWaitMax = 10 * time.Millisecond
+
+	_, err := client.CreateStack(context.Background(), "default", StackRequest{Name: "test"})
+	if err == nil || !strings.Contains(err.Error(), "unexpected EOF") {
+		t.Fatalf("expected an unexpected EOF decode error, got %v", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("expected a non-idempotent request not to be retried, got %d requests", n)
+	}
+}
+
+func TestClientConcurrentRequests(t *testing.T) {
+	var logins int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/login" {
+			atomic.AddInt32(&logins, 1)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"access_token": "token",
+				"expires_in":   3600,
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(StackResponse{ID: "test", Name: "test"})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-key", "", WithTokenAuth())
+	params := &ListParams{Filter: map[string]string{"name": "test"}}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := client.GetStack(context.Background(), "test"); err != nil {
+				errs <- err
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			// Shared list parameters must not be mutated by the client
+			if _, err := client.ListStacks(context.Background(), params); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if n := atomic.LoadInt32(&logins); n != 1 {
+		t.Errorf("expected concurrent requests to share a single login, got %d logins", n)
+	}
+}
+
+func TestCreateOrGetStack(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/api/v1/workspaces/default/stacks":
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{"detail": "stack already exists"})
+		case r.Method == "GET" && r.URL.Path == "/api/v1/stacks":
+			if r.URL.Query().Get("name") != "existing" {
+				json.NewEncoder(w).Encode(Page[StackResponse]{})
+				return
+			}
+			json.NewEncoder(w).Encode(Page[StackResponse]{
+				Items: []StackResponse{{ID: "existing-id", Name: "existing"}},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	ctx := context.Background()
+
+	stack, adopted, err := client.CreateOrGetStack(ctx, "default", StackRequest{Name: "existing"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !adopted || stack.ID != "existing-id" {
+		t.Errorf("expected the existing stack to be returned, got %+v (adopted=%v)", stack, adopted)
+	}
+
+	// A conflict that isn't caused by a stack with the same name is returned
+	_, _, err = client.CreateOrGetStack(ctx, "default", StackRequest{Name: "other"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Fatalf("expected a 409 API error, got %v", err)
+	}
+	if apiErr.Detail != "stack already exists" {
+		t.Errorf("expected the API error detail to be decoded, got %q", apiErr.Detail)
+	}
+}
+
+func TestClientErrorDetail(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"string detail", `{"detail": "stack already exists"}`, "stack already exists"},
+		{"zenml error", `{"detail": ["EntityExistsError", "stack already exists"]}`, "EntityExistsError: stack already exists"},
+		{"validation error", `{"detail": [{"loc": ["body", "components", 0], "msg": "value is not a valid uuid", "type": "type_error.uuid"}]}`, "body.components.0: value is not a valid uuid"},
+		{"other shape", `{"message": "internal error"}`, `{"message": "internal error"}`},
+		{"not json", `Bad Gateway`, `Bad Gateway`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				w.Write([]byte(tt.body))
+			}))
+			client.MaxRetries = 0
+
+			_, err := client.UpdateStack(context.Background(), "test", StackUpdate{})
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected an API error, got %v", err)
+			}
+			if apiErr.Detail != tt.want {
+				t.Errorf("expected detail %q, got %q", tt.want, apiErr.Detail)
+			}
+			if string(apiErr.Body) != tt.body {
+				t.Errorf("expected the raw body to be kept, got %q", apiErr.Body)
+			}
+		})
+	}
+}
+
+func TestClientBasePath(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.RequestURI())
+		json.NewEncoder(w).Encode(Page[StackResponse]{})
+	}))
+	t.Cleanup(server.Close)
+
+	for _, serverURL := range []string{server.URL + "/zenml", server.URL + "/zenml/"} {
+		client := NewClient(serverURL, "", "test-token")
+		if _, err := client.ListStacks(context.Background(), &ListParams{PageSize: 10}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	for _, path := range paths {
+		if path != "/zenml/api/v1/stacks?page=1&size=10" {
+			t.Errorf("expected the base path to be kept, got %s", path)
+		}
+	}
+}
+
+func TestClientEndpoint(t *testing.T) {
+	tests := []struct {
+		serverURL string
+		path      string
+		want      string
+	}{
+		{"https://zenml.example.com", "/api/v1/stacks", "https://zenml.example.com/api/v1/stacks"},
+		{"https://zenml.example.com/", "/api/v1/stacks", "https://zenml.example.com/api/v1/stacks"},
+		{"https://zenml.example.com//", "/api/v1/stacks", "https://zenml.example.com/api/v1/stacks"},
+		{"https://zenml.example.com", "api/v1/stacks", "https://zenml.example.com/api/v1/stacks"},
+		{"https://zenml.example.com/zenml", "/api/v1/stacks", "https://zenml.example.com/zenml/api/v1/stacks"},
+		{"https://zenml.example.com/zenml/", "/api/v1/stacks", "https://zenml.example.com/zenml/api/v1/stacks"},
+		{"https://zenml.example.com/zenml/", "//api/v1//stacks/", "https://zenml.example.com/zenml/api/v1/stacks"},
+		{"https://zenml.example.com:8080/", "/api/v1/stacks?page=1&size=10", "https://zenml.example.com:8080/api/v1/stacks?page=1&size=10"},
+		{"https://zenml.example.com/", "/api/v1/workspaces/team%20a%2Fb/stacks", "https://zenml.example.com/api/v1/workspaces/team%20a%2Fb/stacks"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.serverURL+" "+tt.path, func(t *testing.T) {
+			client := NewClient(tt.serverURL, "", "")
+			got, err := client.endpoint(tt.path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestClientPing(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		want   string
+	}{
+		{"ok", http.StatusOK, ""},
+		{"bad credentials", http.StatusUnauthorized, "check that the API key"},
+		{"server error", http.StatusInternalServerError, "checking the connection"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v1/current-user" {
+					t.Errorf("unexpected request to %s", r.URL.Path)
+				}
+				w.WriteHeader(tt.status)
+				json.NewEncoder(w).Encode(UserResponse{ID: "user"})
+			}))
+			client.MaxRetries = 0
+
+			err := client.Ping(context.Background())
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	t.Run("unreachable", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+
+		err := NewClient(server.URL, "", "test-token").Ping(context.Background())
+		if err == nil || !strings.Contains(err.Error(), "could not connect") {
+			t.Errorf("expected a connection error, got %v", err)
+		}
+	})
+
+	t.Run("bad API key", func(t *testing.T) {
+		client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"detail": "invalid API key"}`))
+		}))
+		client.APIKey = "bad-key"
+		client.APIToken = ""
+		client.tokenAuth = true
+
+		err := client.Ping(context.Background())
+		if err == nil || !strings.Contains(err.Error(), "check that the API key") {
+			t.Errorf("expected a credentials error, got %v", err)
+		}
+	})
+}
+
+func TestClientHeaders(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Tenant-ID"); got != "tenant" {
+			t.Errorf("expected the tenant header, got %q", got)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-token" {
+			t.Errorf("expected the client's Authorization header, got %q", got)
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("expected the client's Content-Type header, got %q", got)
+		}
+		json.NewEncoder(w).Encode(StackResponse{ID: "test"})
+	}))
+	for _, opt := range []ClientOption{
+		WithHeader("X-Tenant-ID", "tenant"),
+		WithHeader("Authorization", "Bearer gateway-token"),
+		WithHeader("Content-Type", "text/plain"),
+	} {
+		opt(client)
+	}
+
+	if _, err := client.UpdateStack(context.Background(), "test", StackUpdate{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// hmacSignature signs the method, path, headers and body of a request the
+// way a signing gateway would.
+func hmacSignature(key []byte, method, path string, header http.Header, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s\n%s\n", method, path, header.Get("Authorization"), header.Get("Content-Type"), header.Get("User-Agent"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestClientRoundTripper(t *testing.T) {
+	key := []byte("gateway-secret")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if got, want := r.Header.Get("X-Signature"), hmacSignature(key, r.Method, r.URL.Path, r.Header, body); got != want {
+			t.Errorf("expected signature %q, got %q", want, got)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		json.NewEncoder(w).Encode(StackResponse{ID: "test"})
+	}))
+	t.Cleanup(server.Close)
+
+	var seen []string
+	var order []string
+	sign := func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			order = append(order, "sign")
+			for _, name := range []string{"Authorization", "Content-Type", "User-Agent"} {
+				seen = append(seen, name+": "+req.Header.Get(name))
+			}
+			var body []byte
+			if req.GetBody != nil {
+				reader, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				body, _ = io.ReadAll(reader)
+			}
+			req = req.Clone(req.Context())
+			req.Header.Set("X-Signature", hmacSignature(key, req.Method, req.URL.Path, req.Header, body))
+			return next.RoundTrip(req)
+		})
+	}
+	log := func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			order = append(order, "log")
+			return next.RoundTrip(req)
+		})
+	}
+	// Transport options given after the wrappers don't replace them
+	client := NewClient(server.URL, "", "test-token", WithRoundTripper(sign), WithRoundTripper(log), WithConnectionPool(10, 5, 0))
+
+	if _, err := client.UpdateStack(context.Background(), "test", StackUpdate{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"Authorization: Bearer test-token", "Content-Type: application/json", "User-Agent: terraform-provider-zenml"}
+	if !reflect.DeepEqual(seen, want) {
+		t.Errorf("expected the signer to see headers %v, got %v", want, seen)
+	}
+	if want := []string{"log", "sign"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("expected the last wrapper to run first, got %v", order)
+	}
+}
+
+func TestClientWithKeepsRoundTrippers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(StackResponse{ID: "test"})
+	}))
+	t.Cleanup(server.Close)
+
+	var wrapped []string
+	wrapper := func(name string) func(http.RoundTripper) http.RoundTripper {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				wrapped = append(wrapped, name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+	client := NewClient(server.URL, "", "test-token", WithRoundTripper(wrapper("sign")), WithConnectionPool(10, 5, 0))
+	pooled := client.With(WithConnectionPool(1, 1, time.Second), WithRoundTripper(wrapper("log")))
+	ctx := context.Background()
+
+	if _, err := client.GetStack(ctx, "test"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := pooled.GetStack(ctx, "test"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"sign", "log", "sign"}; !reflect.DeepEqual(wrapped, want) {
+		t.Errorf("expected the derived client to keep the round trippers, got %v", wrapped)
+	}
+	if client.baseTransport.MaxIdleConns != 10 || pooled.baseTransport.MaxIdleConns != 1 || pooled.baseTransport.MaxIdleConnsPerHost != 1 {
+		t.Errorf("expected the derived client to configure a copy of the transport, got %d and %d", client.baseTransport.MaxIdleConns, pooled.baseTransport.MaxIdleConns)
+	}
+}
+
+func TestClientMaxResponseBytes(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(StackResponse{ID: "test", Name: strings.Repeat("x", 1024)})
+	}))
+
+	client.MaxResponseBytes = 512
+	_, err := client.GetStack(context.Background(), "test")
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("expected ErrResponseTooLarge, got %v", err)
+	}
+
+	client.MaxResponseBytes = 4096
+	if _, err := client.GetStack(context.Background(), "test"); err != nil {
+		t.Fatalf("unexpected error below the limit: %v", err)
+	}
+}
+
+func TestCreateStackWithComponents(t *testing.T) {
+	var mu sync.Mutex
+	var deleted []string
+	var stackBody StackRequest
+	failStack := false
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/api/v1/workspaces/ws/components":
+			var component ComponentRequest
+			json.NewDecoder(r.Body).Decode(&component)
+			json.NewEncoder(w).Encode(ComponentResponse{ID: component.Name + "-id", Name: component.Name})
+		case r.Method == "POST" && r.URL.Path == "/api/v1/workspaces/ws/stacks":
+			if failStack {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				return
+			}
+			json.NewDecoder(r.Body).Decode(&stackBody)
+			json.NewEncoder(w).Encode(StackResponse{ID: "stack-id", Name: stackBody.Name})
+		case r.Method == "DELETE":
+			deleted = append(deleted, strings.TrimPrefix(r.URL.Path, "/api/v1/components/"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	ctx := context.Background()
+
+	stack := StackRequest{
+		Name:       "test",
+		Components: map[string][]string{"container_registry": {"external-id"}},
+	}
+	components := []ComponentRequest{
+		{Name: "orchestrator", Type: "orchestrator", Flavor: "local"},
+		{Name: "store", Type: "artifact_store", Flavor: "local"},
+	}
+
+	_, created, err := client.CreateStackWithComponents(ctx, "ws", stack, components)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(created) != 2 {
+		t.Fatalf("expected 2 created components, got %d", len(created))
+	}
+	want := map[string][]string{
+		"container_registry": {"external-id"},
+		"orchestrator":       {"orchestrator-id"},
+		"artifact_store":     {"store-id"},
+	}
+	if !reflect.DeepEqual(stackBody.Components, want) {
+		t.Errorf("expected stack components %v, got %v", want, stackBody.Components)
+	}
+
+	// A failed stack creation rolls back the created components only
+	failStack = true
+	if _, _, err := client.CreateStackWithComponents(ctx, "ws", stack, components); err == nil {
+		t.Fatal("expected an error when the stack cannot be created")
+	}
+	if got := strings.Join(deleted, ","); got != "store-id,orchestrator-id" {
+		t.Errorf("expected created components to be rolled back, got deletions %q", got)
+	}
+}
+
+func TestCreateComponentsBatch(t *testing.T) {
+	var inFlight, maxInFlight int32
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		var component ComponentRequest
+		json.NewDecoder(r.Body).Decode(&component)
+		if strings.HasPrefix(component.Name, "bad") {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		json.NewEncoder(w).Encode(ComponentResponse{ID: component.Name + "-id", Name: component.Name})
+	}))
+	client.MaxRetries = 0
+
+	var components []ComponentRequest
+	for _, name := range []string{"a", "bad1", "b", "c", "bad2", "d", "e", "f"} {
+		components = append(components, ComponentRequest{Name: name, Type: "orchestrator", Flavor: "local"})
+	}
+
+	created, err := client.CreateComponentsBatch(context.Background(), "ws", components)
+	var ids []string
+	for _, c := range created {
+		ids = append(ids, c.ID)
+	}
+	if want := []string{"a-id", "b-id", "c-id", "d-id", "e-id", "f-id"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("expected the created components %v in order, got %v", want, ids)
+	}
+	errs := flattenErrors(err)
+	if len(errs) != 2 || !strings.Contains(errs[0].Error(), "bad1") || !strings.Contains(errs[1].Error(), "bad2") {
+		t.Errorf("expected an error for each failed component, got %v", err)
+	}
+	if maxInFlight > maxConcurrentCreates {
+		t.Errorf("expected at most %d concurrent creations, got %d", maxConcurrentCreates, maxInFlight)
+	}
+}
+
+func TestNextPage(t *testing.T) {
+	tests := []struct {
+		name string
+		page Page[StackResponse]
+		want int
+	}{
+		{"empty result set", Page[StackResponse]{Index: 1, TotalPages: 0}, 0},
+		{"single page", Page[StackResponse]{Index: 1, TotalPages: 1, Items: []StackResponse{{ID: "a"}}}, 0},
+		{"first of two pages", Page[StackResponse]{Index: 1, TotalPages: 2, Items: []StackResponse{{ID: "a"}}}, 2},
+		{"empty page before the last", Page[StackResponse]{Index: 1, TotalPages: 2}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := &ListParams{PageSize: 10}
+			next := NextPage(params, &tt.page)
+			if tt.want == 0 {
+				if next != nil {
+					t.Fatalf("expected no next page, got %+v", next)
+				}
+				return
+			}
+			if next == nil || next.Page != tt.want || next.PageSize != 10 {
+				t.Fatalf("expected page %d of size 10, got %+v", tt.want, next)
+			}
+			if params.Page != 0 {
+				t.Errorf("expected the given parameters not to be mutated, got page %d", params.Page)
+			}
+		})
+	}
+}
+
+func TestListAllStacks(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		json.NewEncoder(w).Encode(Page[StackResponse]{
+			Index:      map[string]int{"1": 1, "2": 2}[page],
+			TotalPages: 2,
+			Items:      []StackResponse{{ID: "stack-" + page}},
+		})
+	}))
+
+	stacks, err := client.ListAllStacks(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stacks) != 2 || stacks[0].ID != "stack-1" || stacks[1].ID != "stack-2" {
+		t.Errorf("expected the stacks of both pages, got %+v", stacks)
+	}
+}
+
+func TestListAllStacksRetriesPages(t *testing.T) {
+	var failures int32
+	fail := int32(1)
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 3 && atomic.AddInt32(&failures, 1) <= atomic.LoadInt32(&fail) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(Page[StackResponse]{
+			Index:      page,
+			TotalPages: 4,
+			Items:      []StackResponse{{ID: fmt.Sprintf("stack-%d", page)}},
+		})
+	}))
+	client.RetryWaitMin = time.Millisecond
+	client.RetryWaitMax = time.Millisecond
+
+	stacks, err := client.ListAllStacks(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stacks) != 4 {
+		t.Errorf("expected the stacks of all pages, got %+v", stacks)
+	}
+
+	// A page that keeps failing returns the stacks fetched before it
+	atomic.StoreInt32(&failures, 0)
+	atomic.StoreInt32(&fail, 100)
+	stacks, err = client.ListAllStacks(context.Background(), nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected the error of the failed page, got %v", err)
+	}
+	if len(stacks) != 2 || stacks[0].ID != "stack-1" || stacks[1].ID != "stack-2" {
+		t.Errorf("expected the stacks of the first two pages, got %+v", stacks)
+	}
+	if got := atomic.LoadInt32(&failures); got != int32(client.MaxRetries)+1 {
+		t.Errorf("expected %d attempts of the failed page, got %d", client.MaxRetries+1, got)
+	}
+}
+
+func TestListAllStacksStablePagination(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		sortBy string
+	}{
+		{"default order", ""},
+		{"newest first", "desc:created"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// Stacks in creation order; a stack is created after the
+			// first page is served
+			stacks := []string{"stack-1", "stack-2", "stack-3", "stack-4"}
+			var sorts []string
+			client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				query := r.URL.Query()
+				page, _ := strconv.Atoi(query.Get("page"))
+				size, _ := strconv.Atoi(query.Get("size"))
+				sorts = append(sorts, query.Get("sort_by"))
+
+				ordered := append([]string{}, stacks...)
+				if query.Get("sort_by") == "desc:created" {
+					slices.Reverse(ordered)
+				}
+				items := []StackResponse{}
+				for _, id := range ordered[min((page-1)*size, len(ordered)):min(page*size, len(ordered))] {
+					items = append(items, StackResponse{ID: id})
+				}
+				json.NewEncoder(w).Encode(Page[StackResponse]{
+					Index:      page,
+					TotalPages: (len(ordered) + size - 1) / size,
+					Items:      items,
+				})
+				if page == 1 {
+					stacks = append(stacks, "stack-5")
+				}
+			}))
+
+			params := &ListParams{PageSize: 2}
+			if tt.sortBy != "" {
+				params.Filter = map[string]string{"sort_by": tt.sortBy}
+			}
+			result, err := client.ListAllStacks(context.Background(), params)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			expectedSort := tt.sortBy
+			if expectedSort == "" {
+				expectedSort = "asc:created"
+			}
+			for _, sort := range sorts {
+				if sort != expectedSort {
+					t.Errorf("expected the stacks to be sorted by %s, got %q", expectedSort, sort)
+				}
+			}
+			seen := map[string]bool{}
+			for _, stack := range result {
+				if seen[stack.ID] {
+					t.Errorf("expected no duplicates, got %s twice", stack.ID)
+				}
+				seen[stack.ID] = true
+			}
+			for _, id := range []string{"stack-1", "stack-2", "stack-3", "stack-4"} {
+				if !seen[id] {
+					t.Errorf("expected %s to be listed, got %+v", id, result)
+				}
+			}
+		})
+	}
+}
+
+func TestForEachComponent(t *testing.T) {
+	var requested []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		requested = append(requested, page)
+		json.NewEncoder(w).Encode(Page[ComponentResponse]{
+			Index:      map[string]int{"1": 1, "2": 2, "3": 3}[page],
+			TotalPages: 3,
+			Items:      []ComponentResponse{{ID: "a" + page}, {ID: "b" + page}},
+		})
+	}))
+	ctx := context.Background()
+
+	var seen []string
+	err := client.ForEachComponent(ctx, "ws", nil, func(c ComponentResponse) error {
+		seen = append(seen, c.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"a1", "b1", "a2", "b2", "a3", "b3"}; !reflect.DeepEqual(seen, want) {
+		t.Errorf("expected the callback to be called once per item %v, got %v", want, seen)
+	}
+
+	requested, seen = nil, nil
+	stop := errors.New("stop")
+	err = client.ForEachComponent(ctx, "ws", nil, func(c ComponentResponse) error {
+		seen = append(seen, c.ID)
+		if c.ID == "a2" {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("expected the callback error to be returned, got %v", err)
+	}
+	if want := []string{"a1", "b1", "a2"}; !reflect.DeepEqual(seen, want) {
+		t.Errorf("expected iteration to stop at a2, got %v", seen)
+	}
+	if len(requested) != 2 {
+		t.Errorf("expected no page to be fetched after the error, got pages %v", requested)
+	}
+}
+
+func TestGetComponentByNameAndType(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("name") != "aws" {
+			t.Errorf("expected the name filter to be sent, got query %q", r.URL.RawQuery)
+		}
+		var items []ComponentResponse
+		switch q.Get("type") {
+		case "artifact_store":
+			items = []ComponentResponse{{ID: "store"}}
+		case "orchestrator":
+			items = []ComponentResponse{{ID: "a"}, {ID: "b"}}
+		}
+		json.NewEncoder(w).Encode(Page[ComponentResponse]{Index: 1, TotalPages: 1, Items: items})
+	}))
+	ctx := context.Background()
+
+	component, err := client.GetComponentByNameAndType(ctx, "ws", "aws", "artifact_store")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if component.ID != "store" {
+		t.Errorf("expected component store, got %s", component.ID)
+	}
+
+	if _, err := client.GetComponentByNameAndType(ctx, "ws", "aws", "step_operator"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := client.GetComponentByNameAndType(ctx, "ws", "aws", "orchestrator"); !errors.Is(err, ErrAmbiguous) {
+		t.Errorf("expected ErrAmbiguous, got %v", err)
+	}
+}
+
+func TestDeleteComponentsByFilter(t *testing.T) {
+	var mu sync.Mutex
+	deleted := map[string]bool{}
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v1/workspaces/ws/components":
+			if r.URL.Query().Get("flavor") != "local" {
+				t.Errorf("expected the filter to be sent, got query %q", r.URL.RawQuery)
+			}
+			page := r.URL.Query().Get("page")
+			json.NewEncoder(w).Encode(Page[ComponentResponse]{
+				Index:      map[string]int{"1": 1, "2": 2}[page],
+				TotalPages: 2,
+				Items:      []ComponentResponse{{ID: "a" + page}, {ID: "b" + page}},
+			})
+		case r.Method == "DELETE":
+			id := strings.TrimPrefix(r.URL.Path, "/api/v1/components/")
+			if id == "b2" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			deleted[id] = true
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	ctx := context.Background()
+	filter := map[string]string{"flavor": "local"}
+
+	components, err := client.DeleteComponentsByFilter(ctx, "ws", filter, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(components) != 4 {
+		t.Errorf("expected 4 matching components, got %d", len(components))
+	}
+	if len(deleted) != 0 {
+		t.Fatalf("expected a dry run not to delete anything, got %v", deleted)
+	}
+
+	components, err = client.DeleteComponentsByFilter(ctx, "ws", filter, false)
+	if err == nil || !strings.Contains(err.Error(), "b2") {
+		t.Fatalf("expected an error for the component that could not be deleted, got %v", err)
+	}
+	if len(components) != 3 || len(deleted) != 3 {
+		t.Errorf("expected the other 3 components to be deleted, got %v", deleted)
+	}
+}
+
+func TestDeleteComponentsBatch(t *testing.T) {
+	var mu sync.Mutex
+	var inFlight, maxInFlight int
+	deleted := map[string]bool{}
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		inFlight--
+
+		id := strings.TrimPrefix(r.URL.Path, "/api/v1/components/")
+		switch id {
+		case "gone":
+			w.WriteHeader(http.StatusNotFound)
+		case "in-use":
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{"detail": "component is used by a stack"})
+		default:
+			deleted[id] = true
+		}
+	}))
+	client.MaxRetries = 0
+
+	ids := []string{"a", "b", "gone", "c", "in-use", "d", "e", "f"}
+	err := client.DeleteComponentsBatch(context.Background(), ids)
+	var itemErr *ItemError
+	if !errors.As(err, &itemErr) || itemErr.ID != "in-use" {
+		t.Fatalf("expected an error for the component in use only, got %v", err)
+	}
+	if strings.Contains(err.Error(), "gone") {
+		t.Errorf("expected a component that is already gone to count as deleted, got %v", err)
+	}
+	if len(deleted) != 6 {
+		t.Errorf("expected the other components to be deleted, got %v", deleted)
+	}
+	if maxInFlight < 2 || maxInFlight > maxConcurrentDeletes {
+		t.Errorf("expected between 2 and %d concurrent deletions, got %d", maxConcurrentDeletes, maxInFlight)
+	}
+}
+
+func TestClientServerPageSizes(t *testing.T) {
+	var sizes []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/info":
+			json.NewEncoder(w).Encode(ServerInfo{DefaultPageSize: 20, MaxPageSize: 50})
+		case "/api/v1/stacks":
+			sizes = append(sizes, r.URL.Query().Get("size"))
+			json.NewEncoder(w).Encode(Page[StackResponse]{})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	ctx := context.Background()
+
+	info, err := client.GetServerInfo(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client.ConfigurePageSizes(info)
+
+	for _, params := range []*ListParams{nil, {PageSize: 30}, {PageSize: 500}} {
+		if _, err := client.ListStacks(ctx, params); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if got := strings.Join(sizes, ","); got != "20,30,50" {
+		t.Errorf("expected page sizes 20,30,50, got %s", got)
+	}
+
+	// Servers that don't report page sizes keep the client defaults
+	client = NewClient("", "", "")
+	client.ConfigurePageSizes(&ServerInfo{})
+	if client.DefaultPageSize != defaultPageSize || client.MaxPageSize != defaultMaxPageSize {
+		t.Errorf("expected default page sizes, got %d/%d", client.DefaultPageSize, client.MaxPageSize)
+	}
+}
+
+func TestClientPageSizeLimit(t *testing.T) {
+	var sizes []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size := r.URL.Query().Get("size")
+		sizes = append(sizes, size)
+		if n, _ := strconv.Atoi(size); n > 50 {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"detail": [{"loc": ["query", "size"], "msg": "ensure this value is less than or equal to 50"}]}`))
+			return
+		}
+		json.NewEncoder(w).Encode(Page[StackResponse]{})
+	}))
+	client.MaxRetries = 0
+
+	if _, err := client.ListStacks(context.Background(), &ListParams{PageSize: 200}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Join(sizes, ","); got != "200,50" {
+		t.Errorf("expected page sizes 200,50, got %s", got)
+	}
+
+	// Other validation errors aren't retried
+	if limit := pageSizeLimit(&APIError{
+		StatusCode: http.StatusUnprocessableEntity,
+		Body:       []byte(`{"detail": [{"loc": ["query", "page"], "msg": "ensure this value is less than or equal to 10"}]}`),
+	}); limit != 0 {
+		t.Errorf("expected no page size limit, got %d", limit)
+	}
+}
+
+func TestListQueryFilterKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		params ListParams
+		want   string
+	}{
+		{
+			name:   "operator in key",
+			params: ListParams{Filter: map[string]string{"name:contains": "prod"}},
+			want:   "name=contains%3Aprod&page=1&size=10",
+		},
+		{
+			name:   "nested key with operator",
+			params: ListParams{Filter: map[string]string{"labels.team:startswith": "ml & ops"}},
+			want:   "labels.team=startswith%3Aml+%26+ops&page=1&size=10",
+		},
+		{
+			name:   "unknown operator",
+			params: ListParams{Filter: map[string]string{"scope:project": "a=b"}},
+			want:   "page=1&scope%3Aproject=a%3Db&size=10",
+		},
+		{
+			name:   "raw filter keys",
+			params: ListParams{Filter: map[string]string{"name:contains": "prod"}, RawFilterKeys: true},
+			want:   "name%3Acontains=prod&page=1&size=10",
+		},
+		{
+			name:   "unhydrated fields",
+			params: ListParams{Filter: map[string]string{"hydrate": "true"}, Fields: []string{"id", "name", "body"}},
+			want:   "hydrate=false&page=1&size=10",
+		},
+		{
+			name:   "hydrated fields",
+			params: ListParams{Fields: []string{"id", "metadata"}},
+			want:   "hydrate=true&page=1&size=10",
+		},
+		{
+			name:   "repeated values",
+			params: ListParams{MultiFilter: map[string][]string{"tag": {"prod", "gpu"}, "name:contains": {"a", "b"}}},
+			want:   "name=contains%3Aa&name=contains%3Ab&page=1&size=10&tag=prod&tag=gpu",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.params.Page = 1
+			tt.params.PageSize = 10
+			if got := listQuery(&tt.params).Encode(); got != tt.want {
+				t.Errorf("expected query %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestListStacksFilterOperators(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("name"); got != "contains:prod" {
+			t.Errorf("expected the operator to reach the server in the value, got %q", got)
+		}
+		json.NewEncoder(w).Encode(Page[StackResponse]{})
+	}))
+
+	params := &ListParams{Filter: map[string]string{"name:contains": "prod"}}
+	if _, err := client.ListStacks(context.Background(), params); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestClientEmptySuccessResponse(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		// An empty 200 response
+	}))
+	ctx := context.Background()
+
+	name := "updated"
+	component, err := client.UpdateComponent(ctx, "test", ComponentUpdate{Name: &name})
+	if err != nil {
+		t.Fatalf("expected a 204 response to be tolerated, got %v", err)
+	}
+	if component == nil || component.ID != "" {
+		t.Errorf("expected an empty component, got %+v", component)
+	}
+
+	if _, err := client.GetStack(ctx, "test"); err != nil {
+		t.Fatalf("expected an empty response body to be tolerated, got %v", err)
+	}
+}
+
+type testMetricsHook struct {
+	mu           sync.Mutex
+	observations []string
+}
+
+func (h *testMetricsHook) ObserveRequest(method, path string, status int, dur time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.observations = append(h.observations, fmt.Sprintf("%s %s %d", method, path, status))
+}
+
+func TestTemplatePath(t *testing.T) {
+	tests := map[string]string{
+		"/api/v1/stacks/1234":                          "/api/v1/stacks/{id}",
+		"/api/v1/stacks?page=1&size=100":               "/api/v1/stacks",
+		"/api/v1/workspaces/default/components?page=1": "/api/v1/workspaces/{id}/components",
+		"/api/v1/service_connectors/verify":            "/api/v1/service_connectors/verify",
+		"/api/v1/service_connectors/1234/verify?x=1":   "/api/v1/service_connectors/{id}/verify",
+		"/api/v1/current-user":                         "/api/v1/current-user",
+		"/api/v2/triggers/1234":                        "/api/v2/triggers/{id}",
+	}
+	for path, want := range tests {
+		if got := templatePath(path); got != want {
+			t.Errorf("templatePath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestClientAPIPath(t *testing.T) {
+	var paths []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		w.Write([]byte(`{"id": "test"}`))
+	}))
+	ctx := context.Background()
+
+	if got := client.apiPath("workspaces", "team a/b", "stacks"); got != "/api/v1/workspaces/team%20a%2Fb/stacks" {
+		t.Errorf("expected the segments to be escaped, got %s", got)
+	}
+
+	client.APIVersion = "v2"
+	client.APIVersions = map[string]string{"stacks": "v1"}
+	client.GetTrigger(ctx, "1234")
+	client.GetStack(ctx, "1234")
+	client.GetCurrentUser(ctx)
+
+	expected := []string{"/api/v2/triggers/1234", "/api/v1/stacks/1234", "/api/v2/current-user"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("expected requests to %v, got %v", expected, paths)
+	}
+}
+
+func TestClientMetricsHook(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/stacks/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(StackResponse{ID: "test"})
+	}))
+	hook := &testMetricsHook{}
+	client.Metrics = hook
+	ctx := context.Background()
+
+	client.GetStack(ctx, "test")
+	client.GetStack(ctx, "missing")
+
+	want := []string{"GET /api/v1/stacks/{id} 200", "GET /api/v1/stacks/{id} 404"}
+	if !reflect.DeepEqual(hook.observations, want) {
+		t.Errorf("expected observations %v, got %v", want, hook.observations)
+	}
+}
+
+type testTracerProvider struct {
+	name  string
+	spans []*testSpan
+}
+
+func (p *testTracerProvider) Tracer(name string) Tracer {
+	p.name = name
+	return p
+}
+
+type testSpanKey struct{}
+
+func (p *testTracerProvider) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &testSpan{name: name, attributes: map[string]interface{}{}}
+	if parent, ok := ctx.Value(testSpanKey{}).(*testSpan); ok {
+		span.parent = parent.name
+	}
+	p.spans = append(p.spans, span)
+	return context.WithValue(ctx, testSpanKey{}, span), span
+}
+
+func (p *testTracerProvider) Inject(ctx context.Context, header http.Header) {
+	if span, ok := ctx.Value(testSpanKey{}).(*testSpan); ok {
+		header.Set("traceparent", span.name)
+	}
+}
+
+type testSpan struct {
+	name       string
+	parent     string
+	attributes map[string]interface{}
+	err        error
+	ended      bool
+}
+
+func (s *testSpan) SetAttribute(key string, value interface{}) { s.attributes[key] = value }
+func (s *testSpan) RecordError(err error)                      { s.err = err }
+func (s *testSpan) End()                                       { s.ended = true }
+
+func TestClientTracing(t *testing.T) {
+	var traceparents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents = append(traceparents, r.Header.Get("traceparent"))
+		if r.URL.Path == "/api/v1/stacks/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(StackResponse{ID: "test"})
+	}))
+	t.Cleanup(server.Close)
+	provider := &testTracerProvider{}
+	client := NewClient(server.URL, "", "test-token", WithTracerProvider(provider))
+
+	parent := &testSpan{name: "terraform apply"}
+	ctx := context.WithValue(context.Background(), testSpanKey{}, parent)
+	client.GetStack(ctx, "test")
+	client.GetStack(ctx, "missing")
+
+	if provider.name != "terraform-provider-zenml" {
+		t.Errorf("expected the tracer to be named after the provider, got %q", provider.name)
+	}
+	if len(provider.spans) != 2 {
+		t.Fatalf("expected a span per request, got %d", len(provider.spans))
+	}
+	for i, status := range []int{http.StatusOK, http.StatusNotFound} {
+		span := provider.spans[i]
+		if span.name != "GET /api/v1/stacks/{id}" || span.parent != "terraform apply" || !span.ended {
+			t.Errorf("expected an ended child span named after the request, got %+v", span)
+		}
+		if span.attributes["http.request.method"] != "GET" || span.attributes["url.template"] != "/api/v1/stacks/{id}" || span.attributes["http.response.status_code"] != status {
+			t.Errorf("expected the request attributes with status %d, got %v", status, span.attributes)
+		}
+		if traceparents[i] != span.name {
+			t.Errorf("expected the trace context to be sent, got %q", traceparents[i])
+		}
+	}
+	if provider.spans[0].err != nil || provider.spans[1].err == nil {
+		t.Errorf("expected only the failed request to record an error, got %v and %v", provider.spans[0].err, provider.spans[1].err)
+	}
+}
+
+func TestUpdateAndVerifyServiceConnector(t *testing.T) {
+	var mu sync.Mutex
+	configuration := map[string]interface{}{"region": "eu-west-1", "secret_key": "good"}
+	var updates int
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v1/service_connectors/conn":
+			if r.URL.Query().Get("expand_secrets") != "true" {
+				t.Errorf("expected the previous configuration to be read with its secrets")
+			}
+			json.NewEncoder(w).Encode(ServiceConnectorResponse{
+				ID:       "conn",
+				Name:     "conn",
+				Body:     &ServiceConnectorResponseBody{ResourceTypes: []string{"s3-bucket"}},
+				Metadata: &ServiceConnectorResponseMetadata{Configuration: configuration},
+			})
+		case r.Method == "PUT" && r.URL.Path == "/api/v1/service_connectors/conn":
+			updates++
+			var update ServiceConnectorUpdate
+			json.NewDecoder(r.Body).Decode(&update)
+			configuration = update.Configuration
+			json.NewEncoder(w).Encode(ServiceConnectorResponse{ID: "conn"})
+		case r.Method == "PUT" && r.URL.Path == "/api/v1/service_connectors/conn/verify":
+			resources := ServiceConnectorResources{ID: "conn"}
+			if configuration["secret_key"] != "good" {
+				message := "invalid credentials"
+				resources.Error = &message
+			}
+			json.NewEncoder(w).Encode(resources)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	ctx := context.Background()
+
+	update := ServiceConnectorUpdate{
+		Configuration: map[string]interface{}{"region": "eu-west-1", "secret_key": "bad"},
+		ResourceTypes: []string{"s3-bucket"},
+	}
+	_, err := client.UpdateAndVerifyServiceConnector(ctx, "conn", update)
+	if err == nil || !strings.Contains(err.Error(), "invalid credentials") {
+		t.Fatalf("expected a verification error, got %v", err)
+	}
+	if updates != 2 {
+		t.Errorf("expected the update to be rolled back, got %d updates", updates)
+	}
+	if configuration["secret_key"] != "good" {
+		t.Errorf("expected the previous configuration to be restored, got %v", configuration)
+	}
+
+	updates = 0
+	update.Configuration = map[string]interface{}{"region": "us-east-1", "secret_key": "good"}
+	if _, err := client.UpdateAndVerifyServiceConnector(ctx, "conn", update); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updates != 1 || configuration["region"] != "us-east-1" {
+		t.Errorf("expected a single update to be kept, got %d updates and %v", updates, configuration)
+	}
+}
+
+func TestClientWithProxy(t *testing.T) {
+	var proxied int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Requests sent through a proxy carry the absolute target URL
+		if r.URL.Host != "zenml.example.com" {
+			t.Errorf("expected a request for the ZenML server, got %s", r.URL)
+		}
+		atomic.AddInt32(&proxied, 1)
+		json.NewEncoder(w).Encode(StackResponse{ID: "test"})
+	}))
+	defer proxy.Close()
+
+	client := NewClient("http://zenml.example.com", "", "test-token", WithProxy(proxy.URL))
+	if _, err := client.GetStack(context.Background(), "test"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := atomic.LoadInt32(&proxied); n != 1 {
+		t.Errorf("expected the request to go through the proxy, got %d proxied requests", n)
+	}
+
+	client = NewClient("http://zenml.example.com", "", "test-token", WithProxy("not a url"))
+	client.MaxRetries = 0
+	if _, err := client.GetStack(context.Background(), "test"); err == nil || !strings.Contains(err.Error(), "invalid proxy URL") {
+		t.Errorf("expected an invalid proxy URL error, got %v", err)
+	}
+}
+
+func TestClientProxyFromEnvironment(t *testing.T) {
+	client := NewClient("http://zenml.example.com", "", "test-token")
+	transport, ok := client.HTTPClient.Transport.(*http.Transport)
+	if !ok || transport.Proxy == nil {
+		t.Fatal("expected the default client to use the proxy from the environment")
+	}
+}
+
+func TestClientConnectionPool(t *testing.T) {
+	client := NewClient("http://zenml.example.com", "", "test-token")
+	transport := client.HTTPClient.Transport.(*http.Transport)
+	if transport.MaxIdleConnsPerHost != defaultMaxIdleConnsPerHost {
+		t.Errorf("expected %d idle connections per host by default, got %d", defaultMaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+	}
+
+	client = NewClient("http://zenml.example.com", "", "test-token",
+		WithConnectionPool(50, 20, time.Minute),
+		WithProxy("http://proxy.example.com:3128"),
+	)
+	transport = client.HTTPClient.Transport.(*http.Transport)
+	if transport.MaxIdleConns != 50 || transport.MaxIdleConnsPerHost != 20 || transport.IdleConnTimeout != time.Minute {
+		t.Errorf("expected the pool settings to survive the proxy option, got %d, %d, %s",
+			transport.MaxIdleConns, transport.MaxIdleConnsPerHost, transport.IdleConnTimeout)
+	}
+	req, _ := http.NewRequest("GET", "http://zenml.example.com", nil)
+	if proxy, err := transport.Proxy(req); err != nil || proxy.Host != "proxy.example.com:3128" {
+		t.Errorf("expected the proxy to be used, got %v, %v", proxy, err)
+	}
+	if transport.TLSHandshakeTimeout == 0 || !transport.ForceAttemptHTTP2 {
+		t.Errorf("expected the TLS settings of the default transport to be kept")
+	}
+}
+
+func TestClientRedirectKeepsBody(t *testing.T) {
+	for _, status := range []int{http.StatusTemporaryRedirect, http.StatusPermanentRedirect} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/api/v1/workspaces/ws/stacks" {
+					// Like a server adding a trailing slash to the route
+					http.Redirect(w, r, r.URL.Path+"/", status)
+					return
+				}
+				var stack StackRequest
+				if err := json.NewDecoder(r.Body).Decode(&stack); err != nil {
+					t.Errorf("expected the body to survive the redirect, got %v", err)
+				}
+				json.NewEncoder(w).Encode(StackResponse{ID: "test", Name: stack.Name})
+			}))
+
+			stack, err := client.CreateStack(context.Background(), "ws", StackRequest{Name: "redirected"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if stack.Name != "redirected" {
+				t.Errorf("expected the stack name to be sent after the redirect, got %q", stack.Name)
+			}
+		})
+	}
+}
+
+func TestClientResponseCache(t *testing.T) {
+	var requests, notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		json.NewEncoder(w).Encode(StackResponse{ID: "stack", Name: "production"})
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	client := NewClient(server.URL, "", "test-token", WithResponseCache(true))
+	for i := 0; i < 2; i++ {
+		stack, err := client.GetStack(ctx, "stack")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if stack.Name != "production" {
+			t.Errorf("expected the stack to be decoded, got %+v", stack)
+		}
+	}
+	if requests != 2 || notModified != 1 {
+		t.Errorf("expected the second request to be answered with 304, got %d requests and %d 304s", requests, notModified)
+	}
+
+	requests, notModified = 0, 0
+	client = NewClient(server.URL, "", "test-token")
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetStack(ctx, "stack"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if notModified != 0 {
+		t.Errorf("expected no conditional requests without the cache, got %d 304s", notModified)
+	}
+}
+
+func TestAttachDetachTag(t *testing.T) {
+	var requests []string
+	var attachments []TagResourceRequest
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		var attachment TagResourceRequest
+		json.NewDecoder(r.Body).Decode(&attachment)
+		attachments = append(attachments, attachment)
+		if r.Method == "DELETE" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	ctx := context.Background()
+
+	if err := client.AttachTag(ctx, "tag", "run", "pipeline_run"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := client.DetachTag(ctx, "tag", "run", "pipeline_run"); err != nil {
+		t.Fatalf("expected detaching a tag that isn't attached to succeed, got %v", err)
+	}
+
+	want := []string{"POST /api/v1/tag_resources", "DELETE /api/v1/tag_resources"}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("expected requests %v, got %v", want, requests)
+	}
+	for _, a := range attachments {
+		if a != (TagResourceRequest{TagID: "tag", ResourceID: "run", ResourceType: "pipeline_run"}) {
+			t.Errorf("unexpected attachment %+v", a)
+		}
+	}
+}
+
+func TestClientReusesConnections(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := "production"
+		if r.URL.Path == "/api/v1/stacks/large" {
+			// Larger than what the transport drains itself on close
+			name = strings.Repeat("x", 1<<20)
+		}
+		json.NewEncoder(w).Encode(StackResponse{ID: "test", Name: name})
+	}))
+	client.MaxResponseBytes = 4096
+
+	var reused []bool
+	ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			reused = append(reused, info.Reused)
+		},
+	})
+	// The body of the response over the size limit is only read in part
+	if _, err := client.UpdateStack(ctx, "large", StackUpdate{}); !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("expected ErrResponseTooLarge, got %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := client.UpdateStack(ctx, "test", StackUpdate{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(reused) != 3 || !reused[1] || !reused[2] {
+		t.Errorf("expected the later requests to reuse the connection, got %v", reused)
+	}
+}
+
+func TestDecodeResponseDrainsBody(t *testing.T) {
+	body := strings.NewReader(`{"id": "test"} trailing`)
+	resp := &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(body)}
+
+	stack, err := decodeResponse[StackResponse](NewClient("", "", ""), resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stack.ID != "test" {
+		t.Errorf("expected the stack to be decoded, got %+v", stack)
+	}
+	if body.Len() != 0 {
+		t.Errorf("expected the body to be drained, %d bytes left", body.Len())
+	}
+}
+
+func TestClientStrictDecoding(t *testing.T) {
+	var body string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	})
+	ctx := context.Background()
+
+	lenient := newTestClient(t, handler)
+	strict := newTestClient(t, handler)
+	WithStrictDecoding(true)(strict)
+
+	tests := []struct {
+		name string
+		body string
+		err  string
+	}{
+		{"valid", `{"id": "test", "name": "stack"}`, ""},
+		{"unknown field", `{"id": "test", "name": "stack", "stack_name": "stack"}`, `unknown field "stack_name"`},
+		{"renamed field", `{"id": "test", "stack_name": "stack"}`, `unknown field "stack_name"`},
+		{"empty field", `{"id": "test", "name": ""}`, `missing required field "name"`},
+		{"nested field", `{"id": "test", "name": "stack", "metadata": {"components": {"orchestrator": [{"id": "component"}]}}}`, `missing required field "metadata.components.orchestrator.0.name"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body = tt.body
+
+			if _, err := lenient.GetStack(ctx, "test"); err != nil {
+				t.Errorf("expected lenient decoding to succeed, got %v", err)
+			}
+
+			_, err := strict.GetStack(ctx, "test")
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("expected strict decoding to succeed, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected error containing %s, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestClientDefaultLabels(t *testing.T) {
+	var sent map[string]string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Labels map[string]string `json:"labels"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		sent = body.Labels
+		w.Write([]byte(`{"id": "test"}`))
+	}))
+	WithDefaultLabels(map[string]string{"team": "ml", "cost-center": "1234"})(client)
+	ctx := context.Background()
+
+	// Labels set on the stack take precedence
+	client.CreateStack(ctx, "default", StackRequest{Name: "test", Labels: map[string]string{"team": "platform"}})
+	expected := map[string]string{"team": "platform", "cost-center": "1234"}
+	if !reflect.DeepEqual(sent, expected) {
+		t.Errorf("expected labels %v, got %v", expected, sent)
+	}
+
+	client.CreateComponent(ctx, "default", ComponentRequest{Name: "test"})
+	expected = map[string]string{"team": "ml", "cost-center": "1234"}
+	if !reflect.DeepEqual(sent, expected) {
+		t.Errorf("expected labels %v, got %v", expected, sent)
+	}
+
+	// Updates that don't change the labels leave them alone
+	client.UpdateComponent(ctx, "test", ComponentUpdate{})
+	if sent != nil {
+		t.Errorf("expected no labels, got %v", sent)
+	}
+
+	// The default labels read back are removed, unless they are configured
+	// or were changed on the server
+	read := map[string]string{"team": "ml", "cost-center": "5678", "env": "prod"}
+	got := client.withoutDefaultLabels(read, map[string]interface{}{"env": "prod"})
+	if expected := map[string]string{"cost-center": "5678", "env": "prod"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected labels %v, got %v", expected, got)
+	}
+	got = client.withoutDefaultLabels(read, map[string]interface{}{"team": "ml"})
+	if expected := map[string]string{"team": "ml", "cost-center": "5678", "env": "prod"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected labels %v, got %v", expected, got)
+	}
+}
+
+func TestClientDryRun(t *testing.T) {
+	var requests []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.Write([]byte(`{"id": "test"}`))
+	}))
+	WithDryRun()(client)
+	ctx := context.Background()
+
+	name := "renamed"
+	update := StackUpdate{Name: &name, Components: map[string][]string{"orchestrator": {"orchestrator-id"}}}
+	_, err := client.UpdateStack(ctx, "test", update)
+	var dryRun *DryRunError
+	if !errors.As(err, &dryRun) {
+		t.Fatalf("expected a dry run error, got %v", err)
+	}
+	expected, err := MarshalStackRequest(update)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dryRun.Method != "PUT" || dryRun.Path != "/api/v1/stacks/test" || !bytes.Equal(dryRun.Body, expected) {
+		t.Errorf("expected the update to be returned, got %s %s %s", dryRun.Method, dryRun.Path, dryRun.Body)
+	}
+
+	if err := client.DeleteStack(ctx, "test"); !errors.As(err, &dryRun) || dryRun.Body != nil {
+		t.Errorf("expected a dry run error without body, got %v", err)
+	}
+	if _, err := client.GetStack(ctx, "test"); err != nil {
+		t.Errorf("expected reads to be sent, got %v", err)
+	}
+	if _, err := client.VerifyServiceConnector(ctx, ServiceConnectorRequest{}); err != nil {
+		t.Errorf("expected verifications to be sent, got %v", err)
+	}
+
+	expectedRequests := []string{"GET /api/v1/stacks/test", "POST /api/v1/service_connectors/verify"}
+	if !reflect.DeepEqual(requests, expectedRequests) {
+		t.Errorf("expected only %v to be sent, got %v", expectedRequests, requests)
+	}
+}
+
+func TestClientIdempotencyKey(t *testing.T) {
+	var keys []string
+	var logins int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/login":
+			n := atomic.AddInt32(&logins, 1)
+			json.NewEncoder(w).Encode(map[string]interface{}{"access_token": fmt.Sprintf("token-%d", n)})
+		case "/api/v1/models":
+			keys = append(keys, r.Header.Get("Idempotency-Key"))
+			// The first token expires while the first model is created
+			if r.Header.Get("Authorization") == "Bearer token-1" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode(ModelResponse{ID: "model"})
+		default:
+			keys = append(keys, r.Header.Get("Idempotency-Key"))
+			json.NewEncoder(w).Encode(ModelResponse{ID: "model"})
+		}
+	}))
+	t.Cleanup(server.Close)
+	client := NewClient(server.URL, "test-key", "", WithTokenAuth())
+	ctx := context.Background()
+
+	if _, err := client.CreateModel(ctx, ModelRequest{Name: "model"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(keys) != 2 || len(keys[0]) != 36 || keys[0] != keys[1] {
+		t.Fatalf("expected the retried create to reuse its idempotency key, got %v", keys)
+	}
+
+	if _, err := client.CreateModel(ctx, ModelRequest{Name: "model"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(keys) != 3 || keys[2] == keys[0] || keys[2] == "" {
+		t.Errorf("expected every create to get its own idempotency key, got %v", keys)
+	}
+
+	client.GetModel(ctx, "model")
+	client.UpdateModel(ctx, "model", ModelUpdate{})
+	if got := keys[3:]; !reflect.DeepEqual(got, []string{"", ""}) {
+		t.Errorf("expected no idempotency key on other requests, got %v", got)
+	}
+}
+
+func TestClientRequestID(t *testing.T) {
+	var ids []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ids = append(ids, r.Header.Get("X-Request-ID"))
+		if r.URL.Path == "/api/v1/stacks/echo" {
+			w.Header().Set("X-Request-ID", "server-id")
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"detail": "invalid stack"}`))
+	}))
+	client.MaxRetries = 0
+	ctx := context.Background()
+
+	_, err := client.UpdateStack(ctx, "test", StackUpdate{})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected an API error, got %v", err)
+	}
+	if len(ids) != 1 || len(ids[0]) != 36 || ids[0][14] != '4' {
+		t.Fatalf("expected a UUID request ID to be sent, got %v", ids)
+	}
+	if apiErr.RequestID != ids[0] || !strings.Contains(err.Error(), ids[0]) {
+		t.Errorf("expected the error to carry the request ID %s, got %v", ids[0], err)
+	}
+
+	if !strings.HasPrefix(err.Error(), "after 1 attempt in ") {
+		t.Errorf("expected the error to state the attempts and duration, got %v", err)
+	}
+
+	_, err = client.UpdateStack(ctx, "echo", StackUpdate{})
+	if !errors.As(err, &apiErr) || apiErr.RequestID != "server-id" {
+		t.Errorf("expected the request ID echoed by the server, got %v", err)
+	}
+	if ids[0] == ids[1] {
+		t.Errorf("expected every request to get its own ID, got %v", ids)
+	}
+
+	client = NewClient("http://zenml.example.com", "", "test-token", WithProxy("not a url"))
+	client.MaxRetries = 0
+	_, err = client.UpdateStack(ctx, "test", StackUpdate{})
+	if err == nil || !strings.Contains(err.Error(), "(request ID ") {
+		t.Errorf("expected transport errors to carry the request ID, got %v", err)
+	}
+}
+
+func TestClientWaitForStack(t *testing.T) {
+	var attempts int32
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The stack only becomes readable on the third attempt
+		if atomic.AddInt32(&attempts, 1) < 3 || r.URL.Path != "/api/v1/stacks/lagging" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(StackResponse{ID: "lagging", Name: "test"})
+	}))
+	client.StackWaitInterval = time.Millisecond
+	ctx := context.Background()
+
+	stack, err := client.WaitForStack(ctx, "lagging", time.Second)
+	if err != nil || stack == nil || stack.ID != "lagging" {
+		t.Fatalf("expected the stack once it can be read, got %v, %v", stack, err)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+
+	_, err = client.WaitForStack(ctx, "missing", 20*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "not found within 20ms") {
+		t.Errorf("expected a timeout error, got %v", err)
+	}
+
+	client.StackWaitInterval = time.Minute
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = client.WaitForStack(ctx, "missing", time.Hour)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the wait to be cancelled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("expected the wait to stop when the context is done, took %s", elapsed)
+	}
+}
+
+func TestClientResourceOperations(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/models/broken":
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"detail": "broken"})
+		case r.URL.Path == "/api/v1/models/existing" && r.Method == "DELETE":
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Path == "/api/v1/models/existing":
+			json.NewEncoder(w).Encode(ModelResponse{ID: "existing", Name: "model"})
+		case r.URL.Path == "/api/v1/models" && r.Method == "POST":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	ctx := context.Background()
+
+	model, err := client.GetModel(ctx, "existing")
+	if err != nil || model == nil || model.ID != "existing" {
+		t.Errorf("expected the model to be decoded, got %v, %v", model, err)
+	}
+	model, err = client.GetModel(ctx, "missing")
+	if err != nil || model != nil {
+		t.Errorf("expected a missing model to be nil without an error, got %v, %v", model, err)
+	}
+	var apiErr *APIError
+	if _, err := client.GetModel(ctx, "broken"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected a 400 API error, got %v", err)
+	}
+
+	// Updating a missing resource is an error, unlike reading it
+	if _, err := client.UpdateModel(ctx, "missing", ModelUpdate{}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("expected a 404 API error, got %v", err)
+	}
+	if model, err := client.CreateModel(ctx, ModelRequest{Name: "model"}); err != nil || model == nil {
+		t.Errorf("expected an empty response to decode to the zero value, got %v, %v", model, err)
+	}
+
+	if err := client.DeleteModel(ctx, "existing"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := client.DeleteModel(ctx, "missing"); err != nil {
+		t.Errorf("expected deleting a missing model to succeed, got %v", err)
+	}
+	if err := client.DeleteModel(ctx, "broken"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected a 400 API error, got %v", err)
+	}
+}
+
+func TestClientHTMLResponse(t *testing.T) {
+	loginPage := "<!DOCTYPE html>\n<html>\n  <head><title>Sign in</title></head>\n  <body>" + strings.Repeat("Please sign in. ", 50) + "</body>\n</html>"
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sso/login":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(loginPage))
+		case "/api/v1/stacks/bad-gateway":
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte("<html><body><h1>502 Bad Gateway</h1></body></html>"))
+		default:
+			http.Redirect(w, r, "/sso/login?state=secret", http.StatusFound)
+		}
+	}))
+	client.MaxRetries = 0
+	ctx := context.Background()
+
+	// Redirects to a login page are followed and answered with 200
+	_, err := client.GetStack(ctx, "test")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusOK {
+		t.Fatalf("expected an API error for the login page, got %v", err)
+	}
+	if !strings.Contains(apiErr.Detail, "received an HTML response instead of JSON") {
+		t.Errorf("expected the detail to explain the HTML response, got %q", apiErr.Detail)
+	}
+	if !strings.Contains(apiErr.Detail, "redirected to http://") || !strings.Contains(apiErr.Detail, "/sso/login)") {
+		t.Errorf("expected the redirect target without its query, got %q", apiErr.Detail)
+	}
+	if !strings.Contains(apiErr.Detail, "<!DOCTYPE html> <html> <head><title>Sign in</title>") || !strings.HasSuffix(apiErr.Detail, "...") {
+		t.Errorf("expected a truncated snippet of the page, got %q", apiErr.Detail)
+	}
+
+	_, err = client.GetStack(ctx, "bad-gateway")
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected a 502 API error, got %v", err)
+	}
+	if want := "received an HTML response instead of JSON, likely from an authentication proxy or because the server URL is wrong: <html><body><h1>502 Bad Gateway</h1></body></html>"; apiErr.Detail != want {
+		t.Errorf("expected detail %q, got %q", want, apiErr.Detail)
+	}
+}
+
+func TestClientWith(t *testing.T) {
+	var mu sync.Mutex
+	var remotes []string
+	var labels []map[string]string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		remotes = append(remotes, r.RemoteAddr)
+		var stack StackRequest
+		json.NewDecoder(r.Body).Decode(&stack)
+		labels = append(labels, stack.Labels)
+		json.NewEncoder(w).Encode(StackResponse{ID: "test"})
+	}))
+	WithDefaultLabels(map[string]string{"team": "ml"})(client)
+	WithHeader("X-Tenant-ID", "tenant")(client)
+	ctx := context.Background()
+
+	derived := client.With(WithDefaultLabels(map[string]string{"team": "data"}), WithHeader("X-Workspace", "data"))
+	if derived.HTTPClient.Transport != client.HTTPClient.Transport {
+		t.Errorf("expected the derived client to share the transport")
+	}
+	if _, ok := client.Headers["X-Workspace"]; ok || derived.Headers["X-Tenant-ID"] != "tenant" {
+		t.Errorf("expected the derived client to get its own headers, got %v and %v", client.Headers, derived.Headers)
+	}
+
+	client.CreateStack(ctx, "default", StackRequest{Name: "a"})
+	derived.CreateStack(ctx, "default", StackRequest{Name: "b"})
+	if want := []map[string]string{{"team": "ml"}, {"team": "data"}}; !reflect.DeepEqual(labels, want) {
+		t.Errorf("expected each client to add its own default labels, got %v", labels)
+	}
+	if len(remotes) != 2 || remotes[0] != remotes[1] {
+		t.Errorf("expected both clients to reuse the same connection, got %v", remotes)
+	}
+
+	// Configuring the transport of a derived client leaves the shared one
+	// alone
+	transport := client.HTTPClient.Transport.(*http.Transport)
+	pooled := client.With(WithConnectionPool(1, 1, time.Second))
+	if pooled.HTTPClient.Transport == client.HTTPClient.Transport || transport.MaxIdleConns == 1 {
+		t.Errorf("expected the derived client to get its own transport")
+	}
+
+	// Tokens refreshed by one client aren't seen by the other
+	derived.APIToken = "derived-token"
+	if client.APIToken != "test-token" {
+		t.Errorf("expected the access token not to be shared, got %q", client.APIToken)
+	}
+}
+
+func TestRequestOperation(t *testing.T) {
+	tests := []struct {
+		method, path, want string
+	}{
+		{"GET", "/api/v1/stacks", OperationList},
+		{"GET", "/api/v1/stacks?name=test", OperationList},
+		{"GET", "/api/v1/stacks/123", OperationRead},
+		{"GET", "/api/v1/service_connectors/123/resources", OperationList},
+		{"GET", "/api/v1/service_connectors/123/verify?list_resources=true", OperationValidate},
+		{"POST", "/api/v1/service_connectors/verify", OperationValidate},
+		{"POST", "/api/v1/stacks", OperationCreate},
+		{"PUT", "/api/v1/stacks/123", OperationUpdate},
+		{"DELETE", "/api/v1/stacks/123", OperationDelete},
+	}
+	for _, tt := range tests {
+		if got := requestOperation(tt.method, tt.path); got != tt.want {
+			t.Errorf("%s %s: expected operation %q, got %q", tt.method, tt.path, tt.want, got)
+		}
+	}
+}
+
+func TestClientOperationTimeouts(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/verify") {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		json.NewEncoder(w).Encode(ServiceConnectorResponse{ID: "connector"})
+	}))
+	client.MaxRetries = 0
+	client.Timeout = 20 * time.Millisecond
+	client = client.With(WithOperationTimeout(OperationValidate, 50*time.Millisecond), WithOperationTimeout(OperationRead, 0))
+
+	if got := client.requestTimeout(OperationList); got != defaultOperationTimeouts[OperationList] {
+		t.Errorf("expected the default list timeout, got %s", got)
+	}
+	delete(client.OperationTimeouts, OperationList)
+	if got := client.requestTimeout(OperationList); got != client.Timeout {
+		t.Errorf("expected operations without a timeout to fall back to the client timeout, got %s", got)
+	}
+
+	_, err := client.VerifyServiceConnector(context.Background(), ServiceConnectorRequest{})
+	if err == nil || !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "validate request timed out after 50ms") {
+		t.Errorf("expected the verification to time out, got %v", err)
+	}
+	if _, err := client.GetServiceConnector(context.Background(), "connector"); err != nil {
+		t.Errorf("expected reads not to time out, got %v", err)
+	}
+}
+
+func TestClientRequestCompression(t *testing.T) {
+	var configs []map[string]interface{}
+	var encodings []string
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+		var reader io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Errorf("expected a gzip-encoded body: %v", err)
+				return
+			}
+			reader = zr
+		}
+		var component ComponentRequest
+		if err := json.NewDecoder(reader).Decode(&component); err != nil {
+			t.Errorf("unexpected error decoding the body: %v", err)
+		}
+		configs = append(configs, component.Configuration)
+		// The first request is redirected, so that the body is replayed
+		if atomic.AddInt32(&requests, 1) == 1 {
+			http.Redirect(w, r, r.URL.Path+"?redirected=true", http.StatusTemporaryRedirect)
+			return
+		}
+		json.NewEncoder(w).Encode(ComponentResponse{ID: "component"})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "", "test-token", WithRequestCompression(true))
+
+	large := map[string]interface{}{"blob": strings.Repeat("x", minCompressedRequestBytes)}
+	if _, err := client.CreateComponent(context.Background(), "ws", ComponentRequest{Name: "large", Configuration: large}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	small := map[string]interface{}{"path": "s3://bucket"}
+	if _, err := client.CreateComponent(context.Background(), "ws", ComponentRequest{Name: "small", Configuration: small}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []string{"gzip", "gzip", ""}; !reflect.DeepEqual(encodings, want) {
+		t.Errorf("expected only the large body to be compressed, also when replayed, got encodings %q", encodings)
+	}
+	if want := []map[string]interface{}{large, large, small}; !reflect.DeepEqual(configs, want) {
+		t.Errorf("expected the server to decode the bodies sent, got %v", configs)
+	}
+}
+
+func TestClientListFields(t *testing.T) {
+	var queries []url.Values
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		// Unhydrated items have neither metadata nor resources
+		w.Write([]byte(`{"index": 1, "max_size": 1, "total_pages": 3, "total": 3, "items": [{"id": "stack-id", "name": "prod"}]}`))
+	}))
+	client.strictDecoding = true
+
+	page, err := client.ListStacks(context.Background(), &ListParams{Fields: []string{"id", "name"}})
+	if err != nil {
+		t.Fatalf("unexpected error decoding sparse items: %v", err)
+	}
+	if len(page.Items) != 1 || page.Items[0].ID != "stack-id" || page.Items[0].Metadata != nil {
+		t.Errorf("expected an unhydrated stack, got %+v", page.Items)
+	}
+
+	// Counting never needs hydrated items, whatever the filter asks for
+	if n, err := client.CountStacks(context.Background(), map[string]string{"hydrate": "true"}); err != nil || n != 3 {
+		t.Fatalf("expected 3 stacks, got %d, %v", n, err)
+	}
+	for _, query := range queries {
+		if query.Get("hydrate") != "false" {
+			t.Errorf("expected unhydrated items to be requested, got %s", query.Encode())
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceArtifact() *schema.Resource {
+	return &schema.Resource{
+		Description: "Data source for ZenML artifacts. Artifacts are produced by pipeline runs and can't be managed by Terraform.",
+		ReadContext: dataSourceArtifactRead,
+		Schema: map[string]*schema.Schema{
+			"id": {
+				Description: "ID of the artifact",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+			},
+			"name": {
+				Description: "Name of the artifact",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+			},
+			"tags": {
+				Description: "Tags attached to the artifact",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"created": {
+				Description: "Timestamp when the artifact was created",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"updated": {
+				Description: "Timestamp when the artifact was last updated",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func dataSourceArtifactRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*Client)
+
+	var artifact *ArtifactResponse
+	var err error
+
+	if id := d.Get("id").(string); id != "" {
+		artifact, err = c.GetArtifact(ctx, id)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("error getting artifact: %v", err))
+		}
+		if artifact == nil {
+			return diag.FromErr(fmt.Errorf("no artifact found with ID %s", id))
+		}
+	} else if name := d.Get("name").(string); name != "" {
+		artifacts, err := c.ListArtifacts(ctx, &ListParams{PageSize: 1, Filter: map[string]string{"name": name}})
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("error listing artifacts: %v", err))
+		}
+		if len(artifacts.Items) == 0 {
+			return diag.FromErr(fmt.Errorf("no artifact found with name %s", name))
+		}
+		artifact = &artifacts.Items[0]
+	} else {
+		return diag.FromErr(fmt.Errorf("either 'id' or 'name' must be set"))
+	}
+
+	d.SetId(artifact.ID)
+	d.Set("id", artifact.ID)
+	d.Set("name", artifact.Name)
+
+	if artifact.Body != nil {
+		tags := make([]string, 0, len(artifact.Body.Tags))
+		for _, tag := range artifact.Body.Tags {
+			tags = append(tags, tag.Name)
+		}
+		d.Set("tags", tags)
+		d.Set("created", artifact.Body.Created)
+		d.Set("updated", artifact.Body.Updated)
+	}
+
+	return nil
+}
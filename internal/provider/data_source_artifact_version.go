@@ -0,0 +1,116 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceArtifactVersion() *schema.Resource {
+	return &schema.Resource{
+		Description: "Data source for ZenML artifact versions. Without an ID or version, the most recent version of the named artifact is returned.",
+		ReadContext: dataSourceArtifactVersionRead,
+		Schema: map[string]*schema.Schema{
+			"id": {
+				Description: "ID of the artifact version",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+			},
+			"name": {
+				Description: "Name of the artifact",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+			},
+			"version": {
+				Description: "Version of the artifact",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+			},
+			"artifact_id": {
+				Description: "ID of the artifact",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"uri": {
+				Description: "URI the artifact version is stored at",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"type": {
+				Description: "Type of the artifact, e.g. DataArtifact or ModelArtifact",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"data_type": {
+				Description: "Fully-qualified name of the Python type of the artifact data",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"created": {
+				Description: "Timestamp when the artifact version was created",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func dataSourceArtifactVersionRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*Client)
+
+	var version *ArtifactVersionResponse
+	var err error
+
+	if id := d.Get("id").(string); id != "" {
+		version, err = c.GetArtifactVersion(ctx, id)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("error getting artifact version: %v", err))
+		}
+		if version == nil {
+			return diag.FromErr(fmt.Errorf("no artifact version found with ID %s", id))
+		}
+	} else if name := d.Get("name").(string); name != "" {
+		filter := map[string]string{
+			"name":    name,
+			"sort_by": "desc:created",
+		}
+		if v, ok := d.GetOk("version"); ok {
+			filter["version"] = v.(string)
+		}
+
+		versions, err := c.ListArtifactVersions(ctx, &ListParams{PageSize: 1, Filter: filter})
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("error listing artifact versions: %v", err))
+		}
+		if len(versions.Items) == 0 {
+			return diag.FromErr(fmt.Errorf("no version found for artifact %s", name))
+		}
+		version = &versions.Items[0]
+	} else {
+		return diag.FromErr(fmt.Errorf("either 'id' or 'name' must be set"))
+	}
+
+	d.SetId(version.ID)
+	d.Set("id", version.ID)
+
+	if version.Body != nil {
+		d.Set("version", version.Body.Version)
+		d.Set("uri", version.Body.URI)
+		d.Set("type", version.Body.Type)
+		d.Set("created", version.Body.Created)
+		if version.Body.Artifact != nil {
+			d.Set("name", version.Body.Artifact.Name)
+			d.Set("artifact_id", version.Body.Artifact.ID)
+		}
+		if version.Body.DataType != nil {
+			d.Set("data_type", version.Body.DataType.Module+"."+version.Body.DataType.Attribute)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDataSourceArtifactVersion(t *testing.T) {
+	version := ArtifactVersionResponse{
+		ID: "version-1",
+		Body: &ArtifactVersionResponseBody{
+			Artifact: &ArtifactResponse{ID: "artifact-1", Name: "training_data"},
+			Version:  "3",
+			URI:      "s3://bucket/training_data/3",
+			Type:     "DataArtifact",
+			DataType: &SourceSpec{Module: "pandas.core.frame", Attribute: "DataFrame"},
+		},
+	}
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/artifact_versions/version-1":
+			json.NewEncoder(w).Encode(version)
+		case "/api/v1/artifact_versions":
+			query := r.URL.Query()
+			if query.Get("sort_by") != "desc:created" || query.Get("size") != "1" {
+				t.Errorf("expected only the most recent version to be requested, got %q", r.URL.RawQuery)
+			}
+			page := Page[ArtifactVersionResponse]{}
+			if query.Get("name") == "training_data" && (query.Get("version") == "" || query.Get("version") == "3") {
+				page.Items = []ArtifactVersionResponse{version}
+			}
+			json.NewEncoder(w).Encode(page)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	cases := []struct {
+		name    string
+		raw     map[string]interface{}
+		wantErr bool
+	}{
+		{"by id", map[string]interface{}{"id": "version-1"}, false},
+		{"latest by name", map[string]interface{}{"name": "training_data"}, false},
+		{"by name and version", map[string]interface{}{"name": "training_data", "version": "3"}, false},
+		{"unknown version", map[string]interface{}{"name": "training_data", "version": "4"}, true},
+		{"unknown id", map[string]interface{}{"id": "missing"}, true},
+		{"no filters", map[string]interface{}{}, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, dataSourceArtifactVersion().Schema, tc.raw)
+			diags := dataSourceArtifactVersionRead(context.Background(), d, client)
+			if tc.wantErr {
+				if !diags.HasError() {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if diags.HasError() {
+				t.Fatalf("unexpected error: %v", diags)
+			}
+			if d.Id() != "version-1" || d.Get("artifact_id") != "artifact-1" || d.Get("uri") != "s3://bucket/training_data/3" {
+				t.Errorf("unexpected artifact version %v", d.State())
+			}
+			if got := d.Get("data_type").(string); got != "pandas.core.frame.DataFrame" {
+				t.Errorf("unexpected data type %q", got)
+			}
+		})
+	}
+}
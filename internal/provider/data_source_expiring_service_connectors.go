@@ -0,0 +1,156 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceExpiringServiceConnectors() *schema.Resource {
+	return &schema.Resource{
+		Description: "Data source for the ZenML service connectors whose credentials expire soon",
+		ReadContext: dataSourceExpiringServiceConnectorsRead,
+		Schema: map[string]*schema.Schema{
+			"within": {
+				Description:  "Time window from now as a duration, e.g. \"72h\"",
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateDuration,
+			},
+			"connectors": {
+				Description: "Service connectors expiring within the window, including already expired ones, soonest first",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"expires_at": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"expired": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceExpiringServiceConnectorsRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*Client)
+
+	within, err := time.ParseDuration(d.Get("within").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	connectors, err := c.ListExpiringConnectors(ctx, within)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error listing service connectors: %v", err))
+	}
+
+	now := time.Now()
+	result := make([]map[string]interface{}, 0, len(connectors))
+	for _, connector := range connectors {
+		// ListExpiringConnectors only returns connectors with a valid
+		// expiration time
+		expiresAt, _, _ := connectorExpiresAt(&connector)
+		result = append(result, map[string]interface{}{
+			"id":         connector.ID,
+			"name":       connector.Name,
+			"expires_at": expiresAt.Format(time.RFC3339),
+			"expired":    !expiresAt.After(now),
+		})
+	}
+
+	d.SetId(d.Get("within").(string))
+	if err := d.Set("connectors", result); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+// validateDuration validates a non-negative duration, e.g. "72h".
+func validateDuration(v interface{}, k string) ([]string, []error) {
+	d, err := time.ParseDuration(v.(string))
+	if err != nil {
+		return nil, []error{fmt.Errorf("%s: invalid duration: %v", k, err)}
+	}
+	if d < 0 {
+		return nil, []error{fmt.Errorf("%s: must not be negative", k)}
+	}
+	return nil, nil
+}
+
+// connectorExpiresAt returns when the credentials of a service connector
+// expire. It reports false for connectors without an expiration.
+func connectorExpiresAt(connector *ServiceConnectorResponse) (time.Time, bool, error) {
+	if connector.Body == nil || connector.Body.ExpiresAt == nil || *connector.Body.ExpiresAt == "" {
+		return time.Time{}, false, nil
+	}
+	t, err := parseServerTime(*connector.Body.ExpiresAt)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("error parsing expiration time of service connector %s: %v", connector.ID, err)
+	}
+	return t, true, nil
+}
+
+// setConnectorExpiry sets the expires_at and expiration_seconds attributes
+// of a service connector, and returns a warning if its credentials expire
+// within the expiry_warning_window.
+func setConnectorExpiry(d *schema.ResourceData, connector *ServiceConnectorResponse) diag.Diagnostics {
+	expiresAt, ok, err := connectorExpiresAt(connector)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	formatted := ""
+	if ok {
+		formatted = expiresAt.Format(time.RFC3339)
+	}
+	if err := d.Set("expires_at", formatted); err != nil {
+		return diag.FromErr(err)
+	}
+	expirationSeconds := 0
+	if connector.Metadata != nil && connector.Metadata.ExpirationSeconds != nil {
+		expirationSeconds = *connector.Metadata.ExpirationSeconds
+	}
+	if err := d.Set("expiration_seconds", expirationSeconds); err != nil {
+		return diag.FromErr(err)
+	}
+
+	window := d.Get("expiry_warning_window").(string)
+	if !ok || window == "" {
+		return nil
+	}
+	within, err := time.ParseDuration(window)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	remaining := time.Until(expiresAt)
+	if remaining > within {
+		return nil
+	}
+	summary := fmt.Sprintf("Service connector %s expires in %s", connector.Name, remaining.Round(time.Minute))
+	if remaining <= 0 {
+		summary = fmt.Sprintf("Service connector %s has expired", connector.Name)
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Warning,
+		Summary:  summary,
+		Detail:   fmt.Sprintf("The credentials of the service connector expire at %s. Renew them before they expire.", formatted),
+	}}
+}
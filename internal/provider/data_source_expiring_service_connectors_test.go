@@ -0,0 +1,57 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestListExpiringConnectors(t *testing.T) {
+	now := time.Now().UTC()
+	expiresIn := func(d time.Duration) *string {
+		// The server reports expiration times in UTC without a timezone
+		s := now.Add(d).Format("2006-01-02T15:04:05.999999")
+		return &s
+	}
+	empty := ""
+	connectors := []ServiceConnectorResponse{
+		{ID: "in-2-days", Body: &ServiceConnectorResponseBody{ExpiresAt: expiresIn(48 * time.Hour)}},
+		{ID: "never", Body: &ServiceConnectorResponseBody{}},
+		{ID: "in-10-days", Body: &ServiceConnectorResponseBody{ExpiresAt: expiresIn(240 * time.Hour)}},
+		{ID: "expired", Body: &ServiceConnectorResponseBody{ExpiresAt: expiresIn(-time.Hour)}},
+		{ID: "empty", Body: &ServiceConnectorResponseBody{ExpiresAt: &empty}},
+		{ID: "in-1-hour", Body: &ServiceConnectorResponseBody{ExpiresAt: expiresIn(time.Hour)}},
+	}
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Page[ServiceConnectorResponse]{Index: 1, TotalPages: 1, Items: connectors})
+	}))
+
+	d := schema.TestResourceDataRaw(t, dataSourceExpiringServiceConnectors().Schema, map[string]interface{}{
+		"within": "72h",
+	})
+	if diags := dataSourceExpiringServiceConnectorsRead(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	want := []struct {
+		id      string
+		expired bool
+	}{
+		{"expired", true},
+		{"in-1-hour", false},
+		{"in-2-days", false},
+	}
+	if got := d.Get("connectors.#").(int); got != len(want) {
+		t.Fatalf("expected %d expiring connectors, got %d", len(want), got)
+	}
+	for i, w := range want {
+		connector := d.Get("connectors").([]interface{})[i].(map[string]interface{})
+		if connector["id"] != w.id || connector["expired"] != w.expired {
+			t.Errorf("expected connector %d to be %s (expired=%v), got %v", i, w.id, w.expired, connector)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceRun() *schema.Resource {
+	return &schema.Resource{
+		Description: "Data source for ZenML pipeline runs. Without an ID, the most recent run matching the filters is returned.",
+		ReadContext: dataSourceRunRead,
+		Schema: map[string]*schema.Schema{
+			"id": {
+				Description: "ID of the pipeline run",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+			},
+			"name": {
+				Description: "Name of the pipeline run",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+			},
+			"pipeline_id": {
+				Description: "ID of the pipeline the run belongs to",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+			},
+			"status": {
+				Description: "Status of the pipeline run, e.g. completed or failed",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+			},
+			"stack_id": {
+				Description: "ID of the stack the run was executed on",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"start_time": {
+				Description: "Time the run started",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"end_time": {
+				Description: "Time the run ended",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"created": {
+				Description: "Timestamp when the run was created",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func dataSourceRunRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*Client)
+
+	var run *RunResponse
+	var err error
+
+	if id := d.Get("id").(string); id != "" {
+		run, err = c.GetRun(ctx, id)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("error getting pipeline run: %v", err))
+		}
+		if run == nil {
+			return diag.FromErr(fmt.Errorf("no pipeline run found with ID %s", id))
+		}
+	} else if name, ok := d.GetOk("name"); ok && !hasRunFilters(d) {
+		// Run names are unique
+		run, err = c.GetRunByName(ctx, name.(string))
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("error getting pipeline run: %v", err))
+		}
+		if run == nil {
+			return diag.FromErr(fmt.Errorf("no pipeline run found with name %s", name))
+		}
+	} else {
+		filter := map[string]string{
+			"sort_by": "desc:created",
+			"hydrate": "true",
+		}
+		for _, key := range []string{"name", "pipeline_id", "status"} {
+			if v, ok := d.GetOk(key); ok {
+				filter[key] = v.(string)
+			}
+		}
+		if len(filter) == 2 {
+			return diag.FromErr(fmt.Errorf("one of 'id', 'name', 'pipeline_id' or 'status' must be set"))
+		}
+
+		runs, err := c.ListRuns(ctx, &ListParams{PageSize: 1, Filter: filter})
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("error listing pipeline runs: %v", err))
+		}
+		if len(runs.Items) == 0 {
+			return diag.FromErr(fmt.Errorf("no pipeline run found matching the filters"))
+		}
+		run = &runs.Items[0]
+	}
+
+	d.SetId(run.ID)
+	d.Set("id", run.ID)
+	d.Set("name", run.Name)
+
+	if run.Body != nil {
+		d.Set("status", run.Body.Status)
+		d.Set("created", run.Body.Created)
+		if run.Body.Pipeline != nil {
+			d.Set("pipeline_id", run.Body.Pipeline.ID)
+		}
+		if run.Body.Stack != nil {
+			d.Set("stack_id", run.Body.Stack.ID)
+		}
+	}
+
+	if run.Metadata != nil {
+		if run.Metadata.StartTime != nil {
+			d.Set("start_time", *run.Metadata.StartTime)
+		}
+		if run.Metadata.EndTime != nil {
+			d.Set("end_time", *run.Metadata.EndTime)
+		}
+	}
+
+	return nil
+}
+
+// hasRunFilters reports whether runs are filtered on more than their name.
+func hasRunFilters(d *schema.ResourceData) bool {
+	_, byPipeline := d.GetOk("pipeline_id")
+	_, byStatus := d.GetOk("status")
+	return byPipeline || byStatus
+}
@@ -0,0 +1,82 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDataSourceRun(t *testing.T) {
+	startTime := "2024-01-01T00:00:00"
+	run := RunResponse{
+		ID:   "run-1",
+		Name: "training-2024_01_01",
+		Body: &RunResponseBody{
+			Status:   "completed",
+			Pipeline: &PipelineResponse{ID: "pipeline-1"},
+			Stack:    &StackResponse{ID: "stack-1"},
+		},
+		Metadata: &RunResponseMetadata{StartTime: &startTime},
+	}
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/runs/run-1":
+			json.NewEncoder(w).Encode(run)
+		case "/api/v1/runs":
+			query := r.URL.Query()
+			if name := query.Get("name"); name != "" {
+				page := Page[RunResponse]{}
+				if name == run.Name {
+					page.Items = []RunResponse{run}
+				}
+				json.NewEncoder(w).Encode(page)
+				return
+			}
+			if query.Get("sort_by") != "desc:created" || query.Get("size") != "1" {
+				t.Errorf("expected only the most recent run to be requested, got %q", r.URL.RawQuery)
+			}
+			page := Page[RunResponse]{}
+			if query.Get("pipeline_id") == "pipeline-1" && query.Get("status") == "completed" {
+				page.Items = []RunResponse{run}
+			}
+			json.NewEncoder(w).Encode(page)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	cases := []struct {
+		name    string
+		raw     map[string]interface{}
+		wantErr bool
+	}{
+		{"by id", map[string]interface{}{"id": "run-1"}, false},
+		{"by filters", map[string]interface{}{"pipeline_id": "pipeline-1", "status": "completed"}, false},
+		{"by name", map[string]interface{}{"name": "training-2024_01_01"}, false},
+		{"unknown name", map[string]interface{}{"name": "missing"}, true},
+		{"no match", map[string]interface{}{"pipeline_id": "pipeline-1", "status": "failed"}, true},
+		{"unknown id", map[string]interface{}{"id": "missing"}, true},
+		{"no filters", map[string]interface{}{}, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, dataSourceRun().Schema, tc.raw)
+			diags := dataSourceRunRead(context.Background(), d, client)
+			if tc.wantErr {
+				if !diags.HasError() {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if diags.HasError() {
+				t.Fatalf("unexpected error: %v", diags)
+			}
+			if d.Id() != "run-1" || d.Get("status") != "completed" || d.Get("stack_id") != "stack-1" || d.Get("start_time") != startTime {
+				t.Errorf("unexpected run attributes: %v", d.State())
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceSecret() *schema.Resource {
+	return &schema.Resource{
+		Description: "Data source for the metadata of a ZenML secret. The secret's values are never read.",
+		ReadContext: dataSourceSecretRead,
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Description: "Name of the secret",
+				Type:        schema.TypeString,
+				Required:    true,
+			},
+			"workspace": {
+				Description: "Name of the workspace (defaults to 'default')",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "default",
+			},
+			"scope": {
+				Description: "Scope of the secret, 'user' or 'workspace'",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"keys": {
+				Description: "Names of the keys of the secret, sorted",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"references": {
+				Description: "References to the values of the secret by key, in the {{secret_name.key}} form accepted in stack component configurations",
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"created": {
+				Description: "Timestamp when the secret was created",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"updated": {
+				Description: "Timestamp when the secret was last updated",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func dataSourceSecretRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*Client)
+
+	name := d.Get("name").(string)
+	workspace := d.Get("workspace").(string)
+
+	secret, err := c.GetSecretByName(ctx, workspace, name)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error getting secret: %w", err))
+	}
+
+	d.SetId(secret.ID)
+
+	if secret.Body != nil {
+		references := make(map[string]string, len(secret.Body.Keys))
+		for _, key := range secret.Body.Keys {
+			references[key] = fmt.Sprintf("{{%s.%s}}", secret.Name, key)
+		}
+
+		if err := d.Set("scope", secret.Body.EffectiveScope()); err != nil {
+			return diag.FromErr(err)
+		}
+		if err := d.Set("keys", []string(secret.Body.Keys)); err != nil {
+			return diag.FromErr(err)
+		}
+		if err := d.Set("references", references); err != nil {
+			return diag.FromErr(err)
+		}
+		d.Set("created", secret.Body.Created)
+		d.Set("updated", secret.Body.Updated)
+	}
+
+	return nil
+}
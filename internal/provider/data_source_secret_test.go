@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDataSourceSecret(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/api/v1/secrets" || query.Get("workspace") != "default" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if query.Get("name") != "aws-credentials" {
+			w.Write([]byte(`{"index": 1, "total_pages": 1, "total": 0, "items": []}`))
+			return
+		}
+		w.Write([]byte(`{"index": 1, "total_pages": 1, "total": 1, "items": [{
+			"id": "secret-id",
+			"name": "aws-credentials",
+			"body": {"private": false, "values": {"secret_access_key": "hunter2", "access_key_id": null}}
+		}]}`))
+	}))
+
+	d := schema.TestResourceDataRaw(t, dataSourceSecret().Schema, map[string]interface{}{
+		"name": "aws-credentials",
+	})
+	if diags := dataSourceSecretRead(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	if d.Id() != "secret-id" {
+		t.Errorf("expected secret secret-id, got %q", d.Id())
+	}
+	if scope := d.Get("scope"); scope != "workspace" {
+		t.Errorf("expected workspace scope, got %q", scope)
+	}
+	expected := []interface{}{"access_key_id", "secret_access_key"}
+	if keys := d.Get("keys"); !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected keys %v, got %v", expected, keys)
+	}
+	if ref := d.Get("references.secret_access_key"); ref != "{{aws-credentials.secret_access_key}}" {
+		t.Errorf("unexpected reference %q", ref)
+	}
+	for key, value := range d.State().Attributes {
+		if strings.Contains(value, "hunter2") {
+			t.Errorf("expected no secret value in the state, found one in %s", key)
+		}
+	}
+
+	d = schema.TestResourceDataRaw(t, dataSourceSecret().Schema, map[string]interface{}{
+		"name": "missing",
+	})
+	diags := dataSourceSecretRead(context.Background(), d, client)
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "no secret named missing in workspace default") {
+		t.Errorf("expected a not found error, got %v", diags)
+	}
+}
@@ -3,6 +3,7 @@ package provider
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
@@ -30,6 +31,11 @@ func dataSourceServiceConnector() *schema.Resource {
 				Type:        schema.TypeString,
 				Optional:    true,
 			},
+			"connector_type": {
+				Description: "Type of the service connector to look up, e.g. 'aws'",
+				Type:        schema.TypeString,
+				Optional:    true,
+			},
 			"type": {
 				Description: "Type of the service connector",
 				Type:        schema.TypeString,
@@ -41,7 +47,7 @@ func dataSourceServiceConnector() *schema.Resource {
 				Computed:    true,
 			},
 			"configuration": {
-				Description: "Configuration of the service connector",
+				Description: "Configuration of the service connector, without its secret values",
 				Type:        schema.TypeMap,
 				Computed:    true,
 				Elem: &schema.Schema{
@@ -58,8 +64,9 @@ func dataSourceServiceConnector() *schema.Resource {
 				},
 			},
 			"resource_type": {
-				Description: "Resource type associated with the service connector",
+				Description: "Resource type associated with the service connector. When set, looks up the connector that provides this resource type, e.g. 's3-bucket'",
 				Type:        schema.TypeString,
+				Optional:    true,
 				Computed:    true,
 			},
 			"resource_id": {
@@ -68,10 +75,42 @@ func dataSourceServiceConnector() *schema.Resource {
 				Computed:    true,
 			},
 			"expires_at": {
-				Description: "Expiration timestamp of the service connector",
+				Description: "When the credentials of the service connector expire, as an RFC 3339 timestamp in UTC",
 				Type:        schema.TypeString,
 				Computed:    true,
 			},
+			"expiration_seconds": {
+				Description: "Lifetime in seconds of the temporary credentials issued by the service connector, or 0",
+				Type:        schema.TypeInt,
+				Computed:    true,
+			},
+			"expiry_warning_window": {
+				Description:  "Warn when the credentials of the service connector expire within this duration, e.g. \"72h\"",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateDuration,
+			},
+			"components": {
+				Description: "Components that use the service connector",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
 			"created": {
 				Description: "Timestamp when the service connector was created",
 				Type:        schema.TypeString,
@@ -93,15 +132,34 @@ func dataSourceServiceConnectorRead(ctx context.Context, d *schema.ResourceData,
 	name := d.Get("name").(string)
 	id := d.Get("id").(string)
 
+	filter := map[string]string{}
+	if name != "" {
+		filter["name"] = name
+	}
+	if v, ok := d.GetOk("connector_type"); ok {
+		filter["connector_type"] = v.(string)
+	}
+	if v, ok := d.GetOk("resource_type"); ok {
+		filter["resource_type"] = v.(string)
+	}
+
 	var err error = nil
 	var connector *ServiceConnectorResponse = nil
 
 	if id != "" {
 		connector, err = c.GetServiceConnector(ctx, id)
-	} else if name != "" {
+	} else if len(filter) == 1 && name != "" {
 		connector, err = c.GetServiceConnectorByName(ctx, workspace, name)
+		if errors.Is(err, ErrNotFound) {
+			connector, err = nil, nil
+		}
+	} else if len(filter) > 0 {
+		connector, err = c.FindServiceConnector(ctx, workspace, filter)
+		if errors.Is(err, ErrNotFound) {
+			connector, err = nil, nil
+		}
 	} else {
-		return diag.FromErr(fmt.Errorf("either 'id' or 'name' must be set"))
+		return diag.FromErr(fmt.Errorf("either 'id' or one of 'name', 'connector_type' and 'resource_type' must be set"))
 	}
 	if err != nil {
 		return diag.FromErr(fmt.Errorf("error getting service connector: %v", err))
@@ -158,16 +216,6 @@ func dataSourceServiceConnectorRead(ctx context.Context, d *schema.ResourceData,
 			}
 		}
 
-		if connector.Body.ExpiresAt != nil {
-			if err := d.Set("expires_at", *connector.Body.ExpiresAt); err != nil {
-				return diag.FromErr(err)
-			}
-		} else {
-			if err := d.Set("expires_at", ""); err != nil {
-				return diag.FromErr(err)
-			}
-		}
-
 		if err := d.Set("created", connector.Body.Created); err != nil {
 			return diag.FromErr(err)
 		}
@@ -182,7 +230,7 @@ func dataSourceServiceConnectorRead(ctx context.Context, d *schema.ResourceData,
 			return diag.FromErr(err)
 		}
 
-		if err := d.Set("configuration", connector.Metadata.Configuration); err != nil {
+		if err := d.Set("configuration", nonSecretConfiguration(connector.Metadata)); err != nil {
 			return diag.FromErr(err)
 		}
 
@@ -191,5 +239,38 @@ func dataSourceServiceConnectorRead(ctx context.Context, d *schema.ResourceData,
 		}
 	}
 
-	return nil
+	components, err := c.ListComponentsByConnector(ctx, connector.ID)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error listing components of service connector: %w", err))
+	}
+	linked := make([]interface{}, len(components))
+	for i, component := range components {
+		componentType := ""
+		if component.Body != nil {
+			componentType = component.Body.Type
+		}
+		linked[i] = map[string]interface{}{
+			"id":   component.ID,
+			"name": component.Name,
+			"type": componentType,
+		}
+	}
+	if err := d.Set("components", linked); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return setConnectorExpiry(d, connector)
+}
+
+// nonSecretConfiguration returns the configuration of a service connector
+// without the values that are stored as secrets. Servers usually return
+// secrets separately, but some versions merge them into the configuration.
+func nonSecretConfiguration(metadata *ServiceConnectorResponseMetadata) map[string]interface{} {
+	configuration := make(map[string]interface{}, len(metadata.Configuration))
+	for k, v := range metadata.Configuration {
+		if _, ok := metadata.Secrets[k]; !ok {
+			configuration[k] = v
+		}
+	}
+	return configuration
 }
@@ -0,0 +1,121 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourceServiceConnectorResources() *schema.Resource {
+	return &schema.Resource{
+		Description: "Data source for the resources accessible through a ZenML service connector",
+		ReadContext: dataSourceServiceConnectorResourcesRead,
+		Schema: map[string]*schema.Schema{
+			"connector_id": {
+				Description: "ID of the service connector",
+				Type:        schema.TypeString,
+				Required:    true,
+			},
+			"resource_type": {
+				Description: "Only list resources of this resource type",
+				Type:        schema.TypeString,
+				Optional:    true,
+			},
+			"cache_ttl": {
+				Description:  "Number of seconds for which the listed resources are cached and reused by other reads of the same connector and resource type (defaults to 0, no caching)",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"resources": {
+				Description: "Resources accessible through the service connector, grouped by resource type",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"resource_type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"resource_ids": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+						"error": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+			"error": {
+				Description: "Error reported by the service connector when listing its resources",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func dataSourceServiceConnectorResourcesRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*Client)
+
+	connectorID := d.Get("connector_id").(string)
+	resourceType := d.Get("resource_type").(string)
+	cacheTTL := time.Duration(d.Get("cache_ttl").(int)) * time.Second
+
+	result, err := c.ListServiceConnectorResources(ctx, connectorID, resourceType, cacheTTL)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error listing service connector resources: %v", err))
+	}
+
+	var diags diag.Diagnostics
+
+	d.SetId(fmt.Sprintf("%s/%s", connectorID, resourceType))
+
+	resources := make([]map[string]interface{}, 0, len(result.Resources))
+	for _, r := range result.Resources {
+		// Resource types that don't support instances have no IDs to list
+		resourceIDs := r.ResourceIDs
+		if resourceIDs == nil {
+			resourceIDs = []string{}
+		}
+		resourceError := ""
+		if r.Error != nil {
+			resourceError = *r.Error
+		}
+		resources = append(resources, map[string]interface{}{
+			"resource_type": r.ResourceType,
+			"resource_ids":  resourceIDs,
+			"error":         resourceError,
+		})
+	}
+	if err := d.Set("resources", resources); err != nil {
+		return diag.FromErr(err)
+	}
+
+	connectorError := ""
+	if result.Error != nil {
+		connectorError = *result.Error
+		// Connectors that can't enumerate their resources are reported but
+		// don't fail the read
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  fmt.Sprintf("Service connector %s could not list its resources", connectorID),
+			Detail:   connectorError,
+		})
+	}
+	if err := d.Set("error", connectorError); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return diags
+}
@@ -0,0 +1,106 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func testConnectorResourcesHandler(calls *int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		if r.URL.Path == "/api/v1/service_connectors/broken/verify" {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"id":    "broken",
+				"error": "access denied",
+			})
+			return
+		}
+		// Ignore the resource type filter to exercise client-side filtering
+		json.NewEncoder(w).Encode(ServiceConnectorResources{
+			ID: "test",
+			Resources: []ServiceConnectorTypedResources{
+				{ResourceType: "s3-bucket", ResourceIDs: []string{"s3://a", "s3://b"}},
+				{ResourceType: "aws-generic"},
+			},
+		})
+	})
+}
+
+func TestListServiceConnectorResourcesCache(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, testConnectorResourcesHandler(&calls))
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.ListServiceConnectorResources(ctx, "test", "", time.Minute); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("expected cached reads to make 1 request, got %d", n)
+	}
+
+	// A different resource type is cached separately
+	if _, err := client.ListServiceConnectorResources(ctx, "test", "s3-bucket", time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("expected a new request for another resource type, got %d requests", n)
+	}
+
+	// Without a TTL the cache is bypassed
+	if _, err := client.ListServiceConnectorResources(ctx, "test", "", 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Errorf("expected an uncached request, got %d requests", n)
+	}
+}
+
+func TestDataSourceServiceConnectorResources_filter(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, testConnectorResourcesHandler(&calls))
+
+	d := schema.TestResourceDataRaw(t, dataSourceServiceConnectorResources().Schema, map[string]interface{}{
+		"connector_id":  "test",
+		"resource_type": "s3-bucket",
+	})
+	if diags := dataSourceServiceConnectorResourcesRead(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	if got := d.Get("resources.#").(int); got != 1 {
+		t.Fatalf("expected 1 resource type, got %d", got)
+	}
+	if got := d.Get("resources.0.resource_type").(string); got != "s3-bucket" {
+		t.Errorf("expected resource type s3-bucket, got %q", got)
+	}
+	if got := d.Get("resources.0.resource_ids.#").(int); got != 2 {
+		t.Errorf("expected 2 resource IDs, got %d", got)
+	}
+}
+
+func TestDataSourceServiceConnectorResources_cannotList(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, testConnectorResourcesHandler(&calls))
+
+	d := schema.TestResourceDataRaw(t, dataSourceServiceConnectorResources().Schema, map[string]interface{}{
+		"connector_id": "broken",
+	})
+	diags := dataSourceServiceConnectorResourcesRead(context.Background(), d, client)
+	if diags.HasError() {
+		t.Fatalf("expected a warning rather than an error, got %v", diags)
+	}
+	if len(diags) != 1 {
+		t.Fatalf("expected 1 warning, got %d", len(diags))
+	}
+	if got := d.Get("error").(string); got != "access denied" {
+		t.Errorf("expected connector error to be exposed, got %q", got)
+	}
+}
@@ -0,0 +1,153 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDataSourceServiceConnector_resourceType(t *testing.T) {
+	connectors := []ServiceConnectorResponse{
+		{
+			ID:   "s3-id",
+			Name: "s3",
+			Body: &ServiceConnectorResponseBody{
+				ConnectorType: json.RawMessage(`"aws"`),
+				ResourceTypes: []string{"s3-bucket"},
+			},
+			Metadata: &ServiceConnectorResponseMetadata{
+				Workspace:     &WorkspaceResponse{Name: "default"},
+				Configuration: map[string]interface{}{"region": "eu-west-1", "aws_secret_access_key": "hunter2"},
+				Secrets:       map[string]interface{}{"aws_secret_access_key": "**********"},
+			},
+		},
+		{
+			ID:   "ecr-id",
+			Name: "ecr",
+			Body: &ServiceConnectorResponseBody{
+				ConnectorType: json.RawMessage(`"aws"`),
+				ResourceTypes: []string{"docker-registry"},
+			},
+		},
+	}
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		var items []ServiceConnectorResponse
+		for _, connector := range connectors {
+			if query.Get("connector_type") != "aws" {
+				continue
+			}
+			if rt := query.Get("resource_type"); rt != "" && rt != connector.Body.ResourceTypes[0] {
+				continue
+			}
+			items = append(items, connector)
+		}
+		json.NewEncoder(w).Encode(Page[ServiceConnectorResponse]{Index: 1, TotalPages: 1, Total: len(items), Items: items})
+	}))
+
+	d := schema.TestResourceDataRaw(t, dataSourceServiceConnector().Schema, map[string]interface{}{
+		"connector_type": "aws",
+		"resource_type":  "s3-bucket",
+	})
+	if diags := dataSourceServiceConnectorRead(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if d.Id() != "s3-id" {
+		t.Errorf("expected connector s3-id, got %q", d.Id())
+	}
+	configuration := d.Get("configuration").(map[string]interface{})
+	if _, ok := configuration["aws_secret_access_key"]; ok {
+		t.Errorf("expected secret values to be omitted, got %v", configuration)
+	}
+	if configuration["region"] != "eu-west-1" {
+		t.Errorf("expected the region to be set, got %v", configuration)
+	}
+
+	// Without the resource type both AWS connectors match
+	d = schema.TestResourceDataRaw(t, dataSourceServiceConnector().Schema, map[string]interface{}{
+		"connector_type": "aws",
+	})
+	diags := dataSourceServiceConnectorRead(context.Background(), d, client)
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "2 service connectors") {
+		t.Errorf("expected an ambiguous match error, got %v", diags)
+	}
+}
+
+func TestGetServiceConnectorByName(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		connector := ServiceConnectorResponse{
+			ID:   "gcp-id",
+			Name: r.URL.Query().Get("name"),
+			Metadata: &ServiceConnectorResponseMetadata{
+				Configuration: map[string]interface{}{"project_id": "ml", "service_account_json": "{}"},
+				Secrets:       map[string]interface{}{"service_account_json": "**********"},
+			},
+		}
+		var items []ServiceConnectorResponse
+		switch connector.Name {
+		case "gcp":
+			items = []ServiceConnectorResponse{connector}
+		case "duplicate":
+			items = []ServiceConnectorResponse{connector, connector}
+		}
+		json.NewEncoder(w).Encode(Page[ServiceConnectorResponse]{Index: 1, TotalPages: 1, Total: len(items), Items: items})
+	}))
+	ctx := context.Background()
+
+	connector, err := client.GetServiceConnectorByName(ctx, "default", "gcp")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := connector.Metadata.Configuration["service_account_json"]; ok || connector.Metadata.Secrets != nil {
+		t.Errorf("expected the secrets to be removed, got %+v", connector.Metadata)
+	}
+	if connector.Metadata.Configuration["project_id"] != "ml" {
+		t.Errorf("expected the configuration to be kept, got %v", connector.Metadata.Configuration)
+	}
+
+	if _, err := client.GetServiceConnectorByName(ctx, "default", "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := client.GetServiceConnectorByName(ctx, "default", "duplicate"); !errors.Is(err, ErrAmbiguous) {
+		t.Errorf("expected ErrAmbiguous, got %v", err)
+	}
+}
+
+func TestDataSourceServiceConnector_components(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/service_connectors/connector-id":
+			json.NewEncoder(w).Encode(ServiceConnectorResponse{ID: "connector-id", Name: "aws"})
+		case "/api/v1/components":
+			if r.URL.Query().Get("connector_id") != "connector-id" {
+				t.Errorf("expected the components to be filtered by connector, got %s", r.URL.RawQuery)
+			}
+			json.NewEncoder(w).Encode(Page[ComponentResponse]{Index: 1, TotalPages: 1, Total: 2, Items: []ComponentResponse{
+				{ID: "store-id", Name: "s3", Body: &ComponentResponseBody{Type: "artifact_store"}},
+				{ID: "registry-id", Name: "ecr", Body: &ComponentResponseBody{Type: "container_registry"}},
+			}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, dataSourceServiceConnector().Schema, map[string]interface{}{
+		"id": "connector-id",
+	})
+	if diags := dataSourceServiceConnectorRead(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	want := []interface{}{
+		map[string]interface{}{"id": "store-id", "name": "s3", "type": "artifact_store"},
+		map[string]interface{}{"id": "registry-id", "name": "ecr", "type": "container_registry"},
+	}
+	if got := d.Get("components"); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the linked components %v, got %v", want, got)
+	}
+}
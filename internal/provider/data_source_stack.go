@@ -3,7 +3,9 @@ package provider
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"sort"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -31,7 +33,7 @@ func dataSourceStack() *schema.Resource {
 				Optional:    true,
 			},
 			"components": {
-				Description: "Components configured in the stack",
+				Description: "Components configured in the stack, ordered by type and name",
 				Type:        schema.TypeList,
 				Computed:    true,
 				Elem: &schema.Resource{
@@ -52,6 +54,60 @@ func dataSourceStack() *schema.Resource {
 							Type:     schema.TypeString,
 							Computed: true,
 						},
+						"configuration": {
+							Type:      schema.TypeMap,
+							Computed:  true,
+							Sensitive: true,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+						"labels": {
+							Type:     schema.TypeMap,
+							Computed: true,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+						"connector_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"created": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"updated": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+			"components_by_type": {
+				Description: "IDs and names of the components of the stack grouped by type, ordered by type",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"ids": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+						"names": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
 					},
 				},
 			},
@@ -63,6 +119,19 @@ func dataSourceStack() *schema.Resource {
 					Type: schema.TypeString,
 				},
 			},
+			"uses_static_credentials": {
+				Description: "Whether any component of the stack authenticates with static credentials in its configuration instead of a service connector",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+			"static_credential_components": {
+				Description: "IDs of the components that authenticate with static credentials instead of a service connector",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
 			"created": {
 				Description: "Timestamp when the stack was created",
 				Type:        schema.TypeString,
@@ -89,7 +158,7 @@ func dataSourceStackRead(ctx context.Context, d *schema.ResourceData, m interfac
 
 	if id != "" {
 		// Get stack by ID
-		stack, err = c.GetStack(ctx, id)
+		stack, err = c.GetStackHydrated(ctx, id)
 		if err != nil {
 			return diag.FromErr(fmt.Errorf("error getting stack: %v", err))
 		}
@@ -99,6 +168,7 @@ func dataSourceStackRead(ctx context.Context, d *schema.ResourceData, m interfac
 			Filter: map[string]string{
 				"name":      name,
 				"workspace": workspace,
+				"hydrate":   "true",
 			},
 		}
 
@@ -134,40 +204,51 @@ func dataSourceStackRead(ctx context.Context, d *schema.ResourceData, m interfac
 			return diag.FromErr(err)
 		}
 
-		// Handle components
-		var components []map[string]string
-
-		// We need to keep a sorted list of components, otherwise the order
-		// of components will change on each read
-
-		// Extract keys
-		keys := make([]string, 0, len(stack.Metadata.Components))
-		for key, _ := range stack.Metadata.Components {
-			keys = append(keys, key)
+		componentsByType, err := stackComponentsByType(ctx, c, stack)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("error listing stack components: %v", err))
 		}
 
-		// Sort keys
-		sort.Strings(keys)
+		// We need to keep the components sorted, otherwise their order
+		// would change on each read
+		types := make([]string, 0, len(componentsByType))
+		for componentType := range componentsByType {
+			types = append(types, componentType)
+		}
+		sort.Strings(types)
 
-		// Iterate over sorted keys
-		for _, key := range keys {
-			componentList := stack.Metadata.Components[key]
-			var componentData map[string]string
-			for _, component := range componentList {
-				componentData = map[string]string{
-					"id":     component.ID,
-					"name":   component.Name,
-					"type":   component.Body.Type,
-					"flavor": component.Body.Flavor,
-				}
-				// Only take the first component of each type
-				break
+		var stackComponents []ComponentResponse
+		components := []map[string]interface{}{}
+		groups := make([]map[string]interface{}, 0, len(types))
+		for _, componentType := range types {
+			ids := []string{}
+			names := []string{}
+			for _, component := range componentsByType[componentType] {
+				stackComponents = append(stackComponents, component)
+				components = append(components, flattenStackDataComponent(component))
+				ids = append(ids, component.ID)
+				names = append(names, component.Name)
 			}
-			components = append(components, componentData)
+			groups = append(groups, map[string]interface{}{
+				"type":  componentType,
+				"ids":   ids,
+				"names": names,
+			})
 		}
 		if err := d.Set("components", components); err != nil {
 			return diag.FromErr(err)
 		}
+		if err := d.Set("components_by_type", groups); err != nil {
+			return diag.FromErr(err)
+		}
+
+		staticComponents := staticCredentialComponents(stackComponents)
+		if err := d.Set("uses_static_credentials", len(staticComponents) > 0); err != nil {
+			return diag.FromErr(err)
+		}
+		if err := d.Set("static_credential_components", staticComponents); err != nil {
+			return diag.FromErr(err)
+		}
 	}
 
 	if stack.Body != nil {
@@ -182,3 +263,109 @@ func dataSourceStackRead(ctx context.Context, d *schema.ResourceData, m interfac
 
 	return nil
 }
+
+// stackComponentsByType returns the components of a stack grouped by type.
+// The components embedded in a hydrated stack are used as they are. If the
+// server only returned their IDs, their details are listed separately.
+func stackComponentsByType(ctx context.Context, c *Client, stack *StackResponse) (map[string][]ComponentResponse, error) {
+	if stack.Metadata.Components.Hydrated() {
+		var components []ComponentResponse
+		for _, items := range stack.Metadata.Components {
+			components = append(components, items...)
+		}
+		return groupComponentsByType(components), nil
+	}
+	return c.GetStackComponentsForStack(ctx, stack.ID)
+}
+
+// staticCredentialKeyPatterns are substrings of component configuration keys
+// that hold credentials.
+var staticCredentialKeyPatterns = []string{
+	"secret",
+	"password",
+	"token",
+	"credential",
+	"access_key",
+	"private_key",
+	"api_key",
+	"service_account",
+}
+
+// flattenStackDataComponent converts a component into an element of the
+// components attribute.
+func flattenStackDataComponent(component ComponentResponse) map[string]interface{} {
+	data := map[string]interface{}{
+		"id":   component.ID,
+		"name": component.Name,
+	}
+	if component.Body != nil {
+		data["type"] = component.Body.Type
+		data["flavor"] = component.Body.Flavor
+		data["created"] = component.Body.Created
+		data["updated"] = component.Body.Updated
+	}
+	if component.Metadata != nil {
+		data["configuration"] = flattenConfiguration(component.Metadata.Configuration)
+		data["labels"] = component.Metadata.Labels
+		if component.Metadata.Connector != nil {
+			data["connector_id"] = component.Metadata.Connector.ID
+		}
+	}
+	return data
+}
+
+// staticCredentialComponents returns the IDs of the given components that
+// authenticate with static credentials instead of a service connector.
+func staticCredentialComponents(components []ComponentResponse) []string {
+	ids := []string{}
+	for i := range components {
+		if componentUsesStaticCredentials(&components[i]) {
+			ids = append(ids, components[i].ID)
+		}
+	}
+	return ids
+}
+
+// componentUsesStaticCredentials reports whether a component is not bound to
+// a service connector but has credentials set in its configuration.
+func componentUsesStaticCredentials(component *ComponentResponse) bool {
+	if component.Metadata == nil || component.Metadata.Connector != nil {
+		return false
+	}
+	for key, value := range component.Metadata.Configuration {
+		if value == nil || value == "" || isSecretReference(value) {
+			continue
+		}
+		if isCredentialKey(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// isCredentialKey reports whether a configuration key holds credentials.
+// Keys of names, like service_account_name, only identify credentials.
+func isCredentialKey(key string) bool {
+	lowerKey := strings.ToLower(key)
+	if strings.HasSuffix(lowerKey, "_name") {
+		return false
+	}
+	for _, pattern := range staticCredentialKeyPatterns {
+		if strings.Contains(lowerKey, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// secretReferencePattern matches a reference to the value of a ZenML secret,
+// e.g. {{aws.secret_access_key}}, which the server resolves when the
+// component is used.
+var secretReferencePattern = regexp.MustCompile(`^\{\{\s*[^{}.\s]+\.[^{}\s]+\s*\}\}$`)
+
+// isSecretReference reports whether a configuration value only references
+// a secret instead of holding credentials itself.
+func isSecretReference(value interface{}) bool {
+	s, ok := value.(string)
+	return ok && secretReferencePattern.MatchString(strings.TrimSpace(s))
+}
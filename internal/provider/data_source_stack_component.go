@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"regexp"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -27,14 +29,16 @@ func dataSourceStackComponent() *schema.Resource {
 				Default:     "default",
 			},
 			"name": {
-				Description: "Name of the stack component",
-				Type:        schema.TypeString,
-				Optional:    true,
+				Description:  "Name of the stack component",
+				Type:         schema.TypeString,
+				Optional:     true,
+				RequiredWith: []string{"type"},
 			},
 			"type": {
-				Description: "Type of the stack component",
-				Type:        schema.TypeString,
-				Optional:    true,
+				Description:  "Type of the stack component, required with name since component names are only unique per type",
+				Type:         schema.TypeString,
+				Optional:     true,
+				RequiredWith: []string{"name"},
 				ValidateFunc: validation.StringInSlice([]string{
 					"alerter",
 					"annotator",
@@ -55,6 +59,21 @@ func dataSourceStackComponent() *schema.Resource {
 				Type:        schema.TypeString,
 				Computed:    true,
 			},
+			"flavor_display_name": {
+				Description: "Human-readable name of the flavor",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"flavor_docs_url": {
+				Description: "URL of the documentation of the flavor",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"integration": {
+				Description: "Integration that provides the flavor, e.g. 'gcp'",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
 			"configuration": {
 				Description: "Configuration of the stack component",
 				Type:        schema.TypeMap,
@@ -64,6 +83,14 @@ func dataSourceStackComponent() *schema.Resource {
 				},
 				Sensitive: true,
 			},
+			"non_secret_configuration": {
+				Description: "Configuration of the stack component without the secret fields of its flavor and credential keys, safe to output",
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
 			"labels": {
 				Description: "Labels associated with the stack component",
 				Type:        schema.TypeMap,
@@ -118,6 +145,21 @@ func dataSourceStackComponent() *schema.Resource {
 				Type:        schema.TypeString,
 				Computed:    true,
 			},
+			"resolve_env_vars": {
+				Description: "Also expose the configuration with ${ENV_VAR} references resolved in resolved_configuration",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			"resolved_configuration": {
+				Description: "Preview of the configuration with ${ENV_VAR} references resolved against the environment of the Terraform process, which may differ from the environment pipelines run in. Credential values and secret references are never resolved. The raw configuration is in configuration.",
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+				Sensitive: true,
+			},
 		},
 	}
 }
@@ -139,27 +181,10 @@ func dataSourceStackComponentRead(ctx context.Context, d *schema.ResourceData, m
 			return diag.FromErr(fmt.Errorf("error getting stack component: %v", err))
 		}
 	} else if name != "" && componentType != "" {
-		// List components with filters
-		params := &ListParams{
-			Filter: map[string]string{
-				"name":      name,
-				"workspace": workspace,
-				"type":      componentType,
-			},
-		}
-
-		components, err := c.ListStackComponents(ctx, workspace, params)
+		component, err = c.GetComponentByNameAndType(ctx, workspace, name, componentType)
 		if err != nil {
-			return diag.FromErr(fmt.Errorf("error listing stack components: %v", err))
-		}
-
-		if len(components.Items) == 0 {
-			return diag.FromErr(fmt.Errorf("no component found with name %s and type %s in workspace %s",
-				name, componentType, workspace))
+			return diag.FromErr(fmt.Errorf("error getting stack component: %v", err))
 		}
-
-		component = &components.Items[0]
-
 	} else {
 		return diag.FromErr(fmt.Errorf("either 'id' or 'name' and 'type' must be set"))
 	}
@@ -176,11 +201,24 @@ func dataSourceStackComponentRead(ctx context.Context, d *schema.ResourceData, m
 		return diag.FromErr(err)
 	}
 
+	var flavor *FlavorResponse
 	if component.Body != nil {
+		if err := d.Set("type", component.Body.Type); err != nil {
+			return diag.FromErr(err)
+		}
+
 		if err := d.Set("flavor", component.Body.Flavor); err != nil {
 			return diag.FromErr(err)
 		}
 
+		flavor, err = c.GetFlavor(ctx, component.Body.Type, component.Body.Flavor)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("error getting flavor: %v", err))
+		}
+		if err := setFlavorDetails(d, flavor); err != nil {
+			return diag.FromErr(err)
+		}
+
 		if err := d.Set("created", component.Body.Created); err != nil {
 			return diag.FromErr(err)
 		}
@@ -191,7 +229,12 @@ func dataSourceStackComponentRead(ctx context.Context, d *schema.ResourceData, m
 	}
 
 	if component.Metadata != nil {
-		if err := d.Set("configuration", component.Metadata.Configuration); err != nil {
+		if err := d.Set("configuration", flattenConfiguration(component.Metadata.Configuration)); err != nil {
+			return diag.FromErr(err)
+		}
+
+		nonSecret := nonSecretComponentConfiguration(component.Metadata.Configuration, flavor)
+		if err := d.Set("non_secret_configuration", flattenConfiguration(nonSecret)); err != nil {
 			return diag.FromErr(err)
 		}
 
@@ -199,6 +242,13 @@ func dataSourceStackComponentRead(ctx context.Context, d *schema.ResourceData, m
 			return diag.FromErr(err)
 		}
 
+		if d.Get("resolve_env_vars").(bool) {
+			resolved := resolveEnvVars(component.Metadata.Configuration, os.LookupEnv)
+			if err := d.Set("resolved_configuration", flattenConfiguration(resolved)); err != nil {
+				return diag.FromErr(err)
+			}
+		}
+
 		if component.Metadata.Connector != nil {
 
 			connectorType := ""
@@ -253,3 +303,70 @@ func dataSourceStackComponentRead(ctx context.Context, d *schema.ResourceData, m
 
 	return nil
 }
+
+// setFlavorDetails sets the human-readable details of a component's flavor.
+// Custom flavors that were removed from the server have no details.
+func setFlavorDetails(d *schema.ResourceData, flavor *FlavorResponse) error {
+	displayName, docsURL, integration := "", "", ""
+	if flavor != nil && flavor.Body != nil {
+		if flavor.Body.DisplayName != nil {
+			displayName = *flavor.Body.DisplayName
+		}
+		if flavor.Body.Integration != nil {
+			integration = *flavor.Body.Integration
+		}
+	}
+	if flavor != nil && flavor.Metadata != nil && flavor.Metadata.DocsURL != nil {
+		docsURL = *flavor.Metadata.DocsURL
+	}
+	if err := d.Set("flavor_display_name", displayName); err != nil {
+		return err
+	}
+	if err := d.Set("flavor_docs_url", docsURL); err != nil {
+		return err
+	}
+	return d.Set("integration", integration)
+}
+
+// nonSecretComponentConfiguration returns the configuration of a component
+// without the fields its flavor marks as secret. Credential keys are omitted
+// as well, since the flavor may be unknown or not mark all of its secrets.
+func nonSecretComponentConfiguration(configuration map[string]interface{}, flavor *FlavorResponse) map[string]interface{} {
+	secretKeys := map[string]bool{}
+	if flavor != nil {
+		secretKeys = flavor.SecretConfigKeys()
+	}
+	result := make(map[string]interface{}, len(configuration))
+	for key, value := range configuration {
+		if secretKeys[key] || isCredentialKey(key) {
+			continue
+		}
+		result[key] = value
+	}
+	return result
+}
+
+// envVarPattern matches ${ENV_VAR} references in configuration values.
+var envVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// resolveEnvVars returns a copy of the configuration with ${ENV_VAR}
+// references in string values replaced by the values returned by lookup.
+// Unset variables are left as they are. Values of credential keys are never
+// resolved, so that secrets don't end up in the Terraform state.
+func resolveEnvVars(configuration map[string]interface{}, lookup func(string) (string, bool)) map[string]interface{} {
+	resolved := make(map[string]interface{}, len(configuration))
+	for key, value := range configuration {
+		s, ok := value.(string)
+		if !ok || isCredentialKey(key) {
+			resolved[key] = value
+			continue
+		}
+		resolved[key] = envVarPattern.ReplaceAllStringFunc(s, func(ref string) string {
+			if v, ok := lookup(envVarPattern.FindStringSubmatch(ref)[1]); ok {
+				return v
+			}
+			return ref
+		})
+	}
+	return resolved
+}
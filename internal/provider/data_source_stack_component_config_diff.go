@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceStackComponentConfigDiff() *schema.Resource {
+	return &schema.Resource{
+		Description: "Data source for the configuration of a ZenML stack component that differs from its flavor defaults",
+		ReadContext: dataSourceStackComponentConfigDiffRead,
+		Schema: map[string]*schema.Schema{
+			"component_id": {
+				Description: "ID of the stack component",
+				Type:        schema.TypeString,
+				Required:    true,
+			},
+			"overrides": {
+				Description: "Configuration values that differ from the flavor defaults. Values that aren't strings are JSON-encoded.",
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Sensitive:   true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"unknown_keys": {
+				Description: "Configuration keys that are not part of the flavor's configuration schema. These are always included in overrides.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+		},
+	}
+}
+
+func dataSourceStackComponentConfigDiffRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*Client)
+
+	componentID := d.Get("component_id").(string)
+	component, err := c.GetComponent(ctx, componentID)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error getting stack component: %v", err))
+	}
+	if component == nil || component.Body == nil {
+		return diag.FromErr(fmt.Errorf("stack component not found: %s", componentID))
+	}
+
+	flavor, err := c.GetFlavor(ctx, component.Body.Type, component.Body.Flavor)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error getting flavor: %v", err))
+	}
+	if flavor == nil {
+		return diag.FromErr(fmt.Errorf("flavor %s not found for component type %s", component.Body.Flavor, component.Body.Type))
+	}
+
+	var configuration map[string]interface{}
+	if component.Metadata != nil {
+		configuration = component.Metadata.Configuration
+	}
+	defaults, known := flavor.ConfigDefaults()
+	overrides, unknown := configOverrides(configuration, defaults, known)
+
+	d.SetId(componentID)
+	if err := d.Set("overrides", flattenConfiguration(overrides)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("unknown_keys", unknown); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+// configOverrides returns the configuration values that differ from the
+// given defaults, and the sorted configuration keys that are not known to
+// the flavor. Values for unknown keys can't be compared to a default, so
+// they are always reported as overrides. Unset values of keys without a
+// default are not considered overrides.
+func configOverrides(configuration, defaults map[string]interface{}, known map[string]bool) (map[string]interface{}, []string) {
+	overrides := map[string]interface{}{}
+	unknown := []string{}
+	for key, value := range configuration {
+		if !known[key] {
+			overrides[key] = value
+			unknown = append(unknown, key)
+			continue
+		}
+		def, hasDefault := defaults[key]
+		if !hasDefault {
+			if value != nil {
+				overrides[key] = value
+			}
+			continue
+		}
+		if !reflect.DeepEqual(value, def) {
+			overrides[key] = value
+		}
+	}
+	sort.Strings(unknown)
+	return overrides, unknown
+}
@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDataSourceStackComponentConfigDiff(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/components/store-id":
+			json.NewEncoder(w).Encode(ComponentResponse{
+				ID:   "store-id",
+				Body: &ComponentResponseBody{Type: "artifact_store", Flavor: "s3"},
+				Metadata: &ComponentResponseMetadata{
+					Configuration: map[string]interface{}{
+						"path":          "s3://bucket",
+						"key":           nil,
+						"client_kwargs": map[string]interface{}{"region_name": "eu-west-1"},
+						"config_kwargs": map[string]interface{}{},
+						"legacy_option": true,
+					},
+				},
+			})
+		case "/api/v1/flavors":
+			query := r.URL.Query()
+			if query.Get("type") != "artifact_store" || query.Get("name") != "s3" {
+				t.Errorf("unexpected flavor query %q", r.URL.RawQuery)
+			}
+			json.NewEncoder(w).Encode(Page[FlavorResponse]{
+				Items: []FlavorResponse{{
+					Name: "s3",
+					Metadata: &FlavorResponseMetadata{ConfigSchema: map[string]interface{}{
+						"properties": map[string]interface{}{
+							"path":          map[string]interface{}{"type": "string"},
+							"key":           map[string]interface{}{"type": "string"},
+							"client_kwargs": map[string]interface{}{"default": map[string]interface{}{}},
+							"config_kwargs": map[string]interface{}{"default": map[string]interface{}{}},
+						},
+					}},
+				}},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, dataSourceStackComponentConfigDiff().Schema, map[string]interface{}{
+		"component_id": "store-id",
+	})
+	if diags := dataSourceStackComponentConfigDiffRead(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	want := map[string]interface{}{
+		"path":          "s3://bucket",
+		"client_kwargs": `{"region_name":"eu-west-1"}`,
+		"legacy_option": "true",
+	}
+	if got := d.Get("overrides").(map[string]interface{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected overrides %v, got %v", want, got)
+	}
+	if got := d.Get("unknown_keys").([]interface{}); len(got) != 1 || got[0] != "legacy_option" {
+		t.Errorf("expected legacy_option to be reported as unknown, got %v", got)
+	}
+}
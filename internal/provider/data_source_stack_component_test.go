@@ -1,9 +1,15 @@
 package provider
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"net/http"
+	"reflect"
 	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func TestDataSourceStackComponent_basic(t *testing.T) {
@@ -28,3 +34,136 @@ func TestDataSourceStackComponent_basic(t *testing.T) {
 		},
 	})
 }
+
+func TestResolveEnvVars(t *testing.T) {
+	env := map[string]string{"BUCKET": "models", "REGION": "eu-west-1", "TOKEN": "hunter2"}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	resolved := resolveEnvVars(map[string]interface{}{
+		"path":       "s3://${BUCKET}/${REGION}/artifacts",
+		"unset":      "${UNSET_VAR}",
+		"api_token":  "${TOKEN}",
+		"secret_ref": "{{ aws.secret_key }}",
+		"retries":    3.0,
+	}, lookup)
+
+	want := map[string]interface{}{
+		"path":       "s3://models/eu-west-1/artifacts",
+		"unset":      "${UNSET_VAR}",
+		"api_token":  "${TOKEN}",
+		"secret_ref": "{{ aws.secret_key }}",
+		"retries":    3.0,
+	}
+	if !reflect.DeepEqual(resolved, want) {
+		t.Errorf("expected %v, got %v", want, resolved)
+	}
+}
+
+func TestDataSourceStackComponent_resolveEnvVars(t *testing.T) {
+	t.Setenv("ZENML_TEST_BUCKET", "models")
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ComponentResponse{
+			ID:   "store-id",
+			Body: &ComponentResponseBody{Type: "artifact_store", Flavor: "s3"},
+			Metadata: &ComponentResponseMetadata{
+				Configuration: map[string]interface{}{"path": "s3://${ZENML_TEST_BUCKET}"},
+			},
+		})
+	}))
+
+	for _, resolve := range []bool{false, true} {
+		d := schema.TestResourceDataRaw(t, dataSourceStackComponent().Schema, map[string]interface{}{
+			"id":               "store-id",
+			"resolve_env_vars": resolve,
+		})
+		if diags := dataSourceStackComponentRead(context.Background(), d, client); diags.HasError() {
+			t.Fatalf("unexpected error: %v", diags)
+		}
+		if got := d.Get("configuration.path"); got != "s3://${ZENML_TEST_BUCKET}" {
+			t.Errorf("expected the raw configuration to be kept, got %v", got)
+		}
+		got, _ := d.Get("resolved_configuration").(map[string]interface{})
+		if resolve && got["path"] != "s3://models" {
+			t.Errorf("expected the resolved path, got %v", got)
+		}
+		if !resolve && len(got) != 0 {
+			t.Errorf("expected no resolved configuration unless requested, got %v", got)
+		}
+	}
+}
+
+func TestDataSourceStackComponent_flavorDetails(t *testing.T) {
+	displayName := "Amazon S3"
+	integration := "s3"
+	docsURL := "https://docs.zenml.io/stack-components/artifact-stores/s3"
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/components/store-id":
+			json.NewEncoder(w).Encode(ComponentResponse{
+				ID:   "store-id",
+				Name: "store",
+				Body: &ComponentResponseBody{Type: "artifact_store", Flavor: "s3"},
+				Metadata: &ComponentResponseMetadata{
+					Configuration: map[string]interface{}{
+						"path":          "s3://bucket",
+						"key":           "AKIA",
+						"secret":        "{{s3.secret}}",
+						"client_kwargs": map[string]interface{}{"region_name": "eu-west-1"},
+					},
+				},
+			})
+		case "/api/v1/flavors":
+			json.NewEncoder(w).Encode(Page[FlavorResponse]{
+				Items: []FlavorResponse{{
+					Name: "s3",
+					Body: &FlavorResponseBody{
+						Type:        "artifact_store",
+						Integration: &integration,
+						DisplayName: &displayName,
+					},
+					Metadata: &FlavorResponseMetadata{
+						DocsURL: &docsURL,
+						ConfigSchema: map[string]interface{}{
+							"properties": map[string]interface{}{
+								"path": map[string]interface{}{"type": "string"},
+								"key":  map[string]interface{}{"type": "string", "sensitive": true},
+							},
+						},
+					},
+				}},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, dataSourceStackComponent().Schema, map[string]interface{}{
+		"id": "store-id",
+	})
+	if diags := dataSourceStackComponentRead(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	for key, want := range map[string]string{
+		"type":                "artifact_store",
+		"flavor":              "s3",
+		"flavor_display_name": displayName,
+		"flavor_docs_url":     docsURL,
+		"integration":         integration,
+	} {
+		if got := d.Get(key); got != want {
+			t.Errorf("expected %s %q, got %q", key, want, got)
+		}
+	}
+
+	want := map[string]interface{}{
+		"path":          "s3://bucket",
+		"client_kwargs": `{"region_name":"eu-west-1"}`,
+	}
+	if got := d.Get("non_secret_configuration"); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the secret fields to be omitted, got %v", got)
+	}
+}
@@ -0,0 +1,82 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourceStackPipelines() *schema.Resource {
+	return &schema.Resource{
+		Description: "Data source for the ZenML pipelines that ran on a stack",
+		ReadContext: dataSourceStackPipelinesRead,
+		Schema: map[string]*schema.Schema{
+			"stack_id": {
+				Description: "ID of the stack",
+				Type:        schema.TypeString,
+				Required:    true,
+			},
+			"since_days": {
+				Description:  "Only consider runs from the last number of days (defaults to 30)",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      30,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"pipelines": {
+				Description: "Pipelines that ran on the stack, most recently run first",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceStackPipelinesRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*Client)
+
+	stackID := d.Get("stack_id").(string)
+	since := time.Now().AddDate(0, 0, -d.Get("since_days").(int))
+
+	params := &ListParams{
+		Filter: map[string]string{
+			"created": "gte:" + formatFilterTime(since),
+		},
+	}
+
+	pipelines, err := c.ListPipelinesByStack(ctx, stackID, params)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error listing pipelines for stack: %v", err))
+	}
+
+	d.SetId(stackID)
+
+	result := make([]map[string]interface{}, 0, len(pipelines))
+	for _, pipeline := range pipelines {
+		result = append(result, map[string]interface{}{
+			"id":   pipeline.ID,
+			"name": pipeline.Name,
+		})
+	}
+	if err := d.Set("pipelines", result); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
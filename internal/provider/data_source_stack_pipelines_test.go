@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func testRunsHandler(t *testing.T, runs []RunResponse) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("stack_id") != "stack-1" {
+			t.Errorf("expected stack_id filter, got %q", query.Get("stack_id"))
+		}
+		if !strings.HasPrefix(query.Get("created"), "gte:") {
+			t.Errorf("expected a bounded creation time filter, got %q", query.Get("created"))
+		}
+
+		page, _ := strconv.Atoi(query.Get("page"))
+		size, _ := strconv.Atoi(query.Get("size"))
+		totalPages := (len(runs) + size - 1) / size
+		start := (page - 1) * size
+		end := start + size
+		if end > len(runs) {
+			end = len(runs)
+		}
+		items := []RunResponse{}
+		if start < end {
+			items = runs[start:end]
+		}
+		json.NewEncoder(w).Encode(Page[RunResponse]{
+			Index:      page,
+			MaxSize:    size,
+			TotalPages: totalPages,
+			Total:      len(runs),
+			Items:      items,
+		})
+	})
+}
+
+func testRun(id, pipelineID string) RunResponse {
+	run := RunResponse{ID: id, Body: &RunResponseBody{Status: "completed"}}
+	if pipelineID != "" {
+		run.Body.Pipeline = &PipelineResponse{ID: pipelineID, Name: pipelineID + "-name"}
+	}
+	return run
+}
+
+func TestListPipelinesByStack(t *testing.T) {
+	runs := []RunResponse{
+		testRun("run-1", "training"),
+		testRun("run-2", "inference"),
+		testRun("run-3", "training"),
+		testRun("run-4", ""),
+		testRun("run-5", "evaluation"),
+	}
+	client := newTestClient(t, testRunsHandler(t, runs))
+
+	pipelines, err := client.ListPipelinesByStack(context.Background(), "stack-1", &ListParams{PageSize: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var ids []string
+	for _, p := range pipelines {
+		ids = append(ids, p.ID)
+	}
+	if got, want := strings.Join(ids, ","), "training,inference,evaluation"; got != want {
+		t.Errorf("expected pipelines %s, got %s", want, got)
+	}
+}
+
+func TestListPipelinesByStack_noRuns(t *testing.T) {
+	client := newTestClient(t, testRunsHandler(t, nil))
+
+	pipelines, err := client.ListPipelinesByStack(context.Background(), "stack-1", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pipelines == nil || len(pipelines) != 0 {
+		t.Errorf("expected an empty list of pipelines, got %v", pipelines)
+	}
+}
@@ -1,9 +1,16 @@
 package provider
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"net/http"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func TestDataSourceStack_basic(t *testing.T) {
@@ -25,3 +32,220 @@ func TestDataSourceStack_basic(t *testing.T) {
 		},
 	})
 }
+
+func TestDataSourceStack_staticCredentials(t *testing.T) {
+	components := map[string]ComponentResponse{
+		"orchestrator-id": {
+			ID:       "orchestrator-id",
+			Body:     &ComponentResponseBody{Type: "orchestrator", Flavor: "local"},
+			Metadata: &ComponentResponseMetadata{Configuration: map[string]interface{}{}},
+		},
+		"store-id": {
+			ID:   "store-id",
+			Name: "store",
+			Body: &ComponentResponseBody{Type: "artifact_store", Flavor: "s3"},
+			Metadata: &ComponentResponseMetadata{
+				Configuration: map[string]interface{}{"path": "s3://bucket"},
+				Connector:     &ServiceConnectorResponse{ID: "connector-id"},
+			},
+		},
+		"registry-id": {
+			ID:   "registry-id",
+			Body: &ComponentResponseBody{Type: "container_registry", Flavor: "default"},
+			Metadata: &ComponentResponseMetadata{
+				Configuration: map[string]interface{}{"uri": "registry.example.com", "password": "hunter2"},
+			},
+		},
+	}
+	stacks := map[string][]string{
+		"mixed":     {"orchestrator-id", "store-id", "registry-id"},
+		"connected": {"orchestrator-id", "store-id"},
+	}
+
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/components" {
+			page := Page[ComponentResponse]{Index: 1, TotalPages: 1}
+			for _, componentID := range stacks[r.URL.Query().Get("stack_id")] {
+				page.Items = append(page.Items, components[componentID])
+			}
+			json.NewEncoder(w).Encode(page)
+			return
+		}
+		id := strings.TrimPrefix(r.URL.Path, "/api/v1/stacks/")
+		stack := StackResponse{ID: id, Name: id, Metadata: &StackResponseMetadata{
+			Components: map[string][]ComponentResponse{},
+		}}
+		for _, componentID := range stacks[id] {
+			component := components[componentID]
+			stack.Metadata.Components[component.Body.Type] = []ComponentResponse{
+				{ID: component.ID, Body: component.Body},
+			}
+		}
+		json.NewEncoder(w).Encode(stack)
+	}))
+
+	cases := []struct {
+		stack      string
+		wantStatic bool
+		wantIDs    int
+	}{
+		{"mixed", true, 1},
+		{"connected", false, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.stack, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, dataSourceStack().Schema, map[string]interface{}{
+				"id": tc.stack,
+			})
+			if diags := dataSourceStackRead(context.Background(), d, client); diags.HasError() {
+				t.Fatalf("unexpected error: %v", diags)
+			}
+			if got := d.Get("uses_static_credentials").(bool); got != tc.wantStatic {
+				t.Errorf("expected uses_static_credentials %v, got %v", tc.wantStatic, got)
+			}
+			if got := d.Get("static_credential_components.#").(int); got != tc.wantIDs {
+				t.Errorf("expected %d static credential components, got %d", tc.wantIDs, got)
+			}
+			if got := d.Get("components.#").(int); got != len(stacks[tc.stack]) {
+				t.Errorf("expected %d components, got %d", len(stacks[tc.stack]), got)
+			}
+			if got := d.Get("components.0.type").(string); got != "artifact_store" {
+				t.Errorf("expected components to be ordered by type, got %q first", got)
+			}
+		})
+	}
+}
+
+func TestComponentUsesStaticCredentials(t *testing.T) {
+	cases := []struct {
+		name          string
+		configuration map[string]interface{}
+		want          bool
+	}{
+		{"credential", map[string]interface{}{"aws_secret_access_key": "AKIA"}, true},
+		{"secret reference", map[string]interface{}{"aws_secret_access_key": "{{aws.secret_access_key}}"}, false},
+		{"secret reference with spaces", map[string]interface{}{"password": " {{ registry.password }} "}, false},
+		{"embedded secret reference", map[string]interface{}{"password": "prefix-{{registry.password}}"}, true},
+		{"name of a service account", map[string]interface{}{"service_account_name": "pipeline-runner"}, false},
+		{"service account", map[string]interface{}{"service_account_json": "{}"}, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			component := &ComponentResponse{Metadata: &ComponentResponseMetadata{Configuration: tc.configuration}}
+			if got := componentUsesStaticCredentials(component); got != tc.want {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestFlattenStackDataComponent(t *testing.T) {
+	data := flattenStackDataComponent(ComponentResponse{
+		ID:   "store-id",
+		Name: "store",
+		Body: &ComponentResponseBody{Type: "artifact_store", Flavor: "s3"},
+		Metadata: &ComponentResponseMetadata{
+			Configuration: map[string]interface{}{"path": "s3://bucket", "retries": 3.0, "options": map[string]interface{}{"a": true}},
+			Connector:     &ServiceConnectorResponse{ID: "connector-id"},
+		},
+	})
+
+	configuration := data["configuration"].(map[string]interface{})
+	want := map[string]interface{}{"path": "s3://bucket", "retries": "3", "options": `{"a":true}`}
+	for key, value := range want {
+		if configuration[key] != value {
+			t.Errorf("expected configuration %s to be %v, got %v", key, value, configuration[key])
+		}
+	}
+	if data["connector_id"] != "connector-id" {
+		t.Errorf("expected connector_id to be set, got %v", data["connector_id"])
+	}
+}
+
+func TestDataSourceStack_componentsByType(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/components" {
+			json.NewEncoder(w).Encode(Page[ComponentResponse]{Index: 1, TotalPages: 1, Items: []ComponentResponse{
+				{ID: "sagemaker-id", Name: "sagemaker", Body: &ComponentResponseBody{Type: "step_operator"}},
+				{ID: "orchestrator-id", Name: "default", Body: &ComponentResponseBody{Type: "orchestrator"}},
+				{ID: "modal-id", Name: "modal", Body: &ComponentResponseBody{Type: "step_operator"}},
+			}})
+			return
+		}
+		json.NewEncoder(w).Encode(StackResponse{ID: "stack-id", Name: "stack", Metadata: &StackResponseMetadata{}})
+	}))
+
+	byType, err := client.GetStackComponentsForStack(context.Background(), "stack-id")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := byType["step_operator"]; len(got) != 2 || got[0].ID != "modal-id" || got[1].ID != "sagemaker-id" {
+		t.Errorf("expected both step operators ordered by name, got %+v", got)
+	}
+
+	d := schema.TestResourceDataRaw(t, dataSourceStack().Schema, map[string]interface{}{
+		"id": "stack-id",
+	})
+	if diags := dataSourceStackRead(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	want := []interface{}{
+		map[string]interface{}{"type": "orchestrator", "ids": []interface{}{"orchestrator-id"}, "names": []interface{}{"default"}},
+		map[string]interface{}{"type": "step_operator", "ids": []interface{}{"modal-id", "sagemaker-id"}, "names": []interface{}{"modal", "sagemaker"}},
+	}
+	if got := d.Get("components_by_type"); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got := d.Get("components.#").(int); got != 3 {
+		t.Errorf("expected 3 components, got %d", got)
+	}
+}
+
+func TestDataSourceStack_hydrated(t *testing.T) {
+	orchestrator := `{"id": "orchestrator-id", "name": "default",
+		"body": {"type": "orchestrator", "flavor_name": "local"},
+		"metadata": {"configuration": {"synchronous": true}}}`
+	cases := []struct {
+		name       string
+		components string
+		wantList   bool
+	}{
+		{"embedded components", `{"orchestrator": [` + orchestrator + `]}`, false},
+		{"component IDs", `{"orchestrator": ["orchestrator-id"]}`, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			listed := false
+			client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/v1/stacks/stack-id":
+					if r.URL.Query().Get("hydrate") != "true" {
+						t.Errorf("expected a hydrated stack to be requested, got %s", r.URL)
+					}
+					w.Write([]byte(`{"id": "stack-id", "name": "stack", "metadata": {"components": ` + tc.components + `}}`))
+				case "/api/v1/components":
+					listed = true
+					w.Write([]byte(`{"index": 1, "total_pages": 1, "items": [` + orchestrator + `]}`))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+
+			d := schema.TestResourceDataRaw(t, dataSourceStack().Schema, map[string]interface{}{
+				"id": "stack-id",
+			})
+			if diags := dataSourceStackRead(context.Background(), d, client); diags.HasError() {
+				t.Fatalf("unexpected error: %v", diags)
+			}
+			if listed != tc.wantList {
+				t.Errorf("expected the components to be listed separately: %v, got %v", tc.wantList, listed)
+			}
+			if got := d.Get("components.0.flavor"); got != "local" {
+				t.Errorf("expected the component details, got flavor %q", got)
+			}
+			if got := d.Get("components.0.configuration.synchronous"); got != "true" {
+				t.Errorf("expected the component configuration, got %v", got)
+			}
+		})
+	}
+}
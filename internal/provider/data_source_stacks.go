@@ -0,0 +1,220 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourceStacks() *schema.Resource {
+	return &schema.Resource{
+		Description: "Data source for listing ZenML stacks",
+		ReadContext: dataSourceStacksRead,
+		Schema: map[string]*schema.Schema{
+			"workspace": {
+				Description: "Name of the workspace to list stacks from (defaults to all workspaces)",
+				Type:        schema.TypeString,
+				Optional:    true,
+			},
+			"created_after": {
+				Description:  "Only list stacks created at or after this RFC 3339 timestamp",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsRFC3339Time,
+			},
+			"created_before": {
+				Description:  "Only list stacks created before this RFC 3339 timestamp, exclusive",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsRFC3339Time,
+			},
+			"updated_after": {
+				Description:  "Only list stacks last updated at or after this RFC 3339 timestamp",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsRFC3339Time,
+			},
+			"updated_before": {
+				Description:  "Only list stacks last updated before this RFC 3339 timestamp, exclusive",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsRFC3339Time,
+			},
+			"count_only": {
+				Description: "Only count the matching stacks in total, without listing them",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			"total": {
+				Description: "Number of matching stacks",
+				Type:        schema.TypeInt,
+				Computed:    true,
+			},
+			"stacks": {
+				Description: "Matching stacks, oldest first",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"created": {
+							Description: "Creation time as an RFC 3339 timestamp in UTC",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						"updated": {
+							Description: "Last update time as an RFC 3339 timestamp in UTC",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceStacksRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*Client)
+
+	var after, before time.Time
+	if v, ok := d.GetOk("created_after"); ok {
+		after, _ = time.Parse(time.RFC3339, v.(string))
+	}
+	if v, ok := d.GetOk("created_before"); ok {
+		before, _ = time.Parse(time.RFC3339, v.(string))
+	}
+	var updatedAfter, updatedBefore time.Time
+	if v, ok := d.GetOk("updated_after"); ok {
+		updatedAfter, _ = time.Parse(time.RFC3339, v.(string))
+	}
+	if v, ok := d.GetOk("updated_before"); ok {
+		updatedBefore, _ = time.Parse(time.RFC3339, v.(string))
+	}
+
+	params := &ListParams{
+		Filter: stacksCreatedFilter(after, before),
+	}
+	if v, ok := d.GetOk("workspace"); ok {
+		params.Filter["workspace"] = v.(string)
+	}
+	stacksTimeFilter(params.Filter, "updated", updatedAfter, updatedBefore)
+
+	d.SetId(fmt.Sprintf("%s/%s/%s/%s/%s", d.Get("workspace"), d.Get("created_after"), d.Get("created_before"), d.Get("updated_after"), d.Get("updated_before")))
+
+	// The server filters are only exact with at most one bound per field,
+	// to the second, otherwise the stacks are listed to check the bounds
+	exact := exactTimeRange(after, before) && exactTimeRange(updatedAfter, updatedBefore)
+	if d.Get("count_only").(bool) && exact {
+		total, err := c.CountStacks(ctx, params.Filter)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("error counting stacks: %v", err))
+		}
+		if err := d.Set("total", total); err != nil {
+			return diag.FromErr(err)
+		}
+		if err := d.Set("stacks", []map[string]interface{}{}); err != nil {
+			return diag.FromErr(err)
+		}
+		return nil
+	}
+
+	stacks, err := c.ListAllStacks(ctx, params)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error listing stacks: %v", err))
+	}
+
+	result := make([]map[string]interface{}, 0, len(stacks))
+	for _, stack := range stacks {
+		var created, updated time.Time
+		if stack.Body != nil {
+			created, err = parseServerTime(stack.Body.Created)
+			if err != nil {
+				return diag.FromErr(fmt.Errorf("error parsing creation time of stack %s: %v", stack.ID, err))
+			}
+			if stack.Body.Updated != "" {
+				updated, err = parseServerTime(stack.Body.Updated)
+				if err != nil {
+					return diag.FromErr(fmt.Errorf("error parsing update time of stack %s: %v", stack.ID, err))
+				}
+			}
+		}
+		// The server filters are a superset of the range, since they hold
+		// a single bound per field and are rounded to the second
+		if !inTimeRange(created, after, before) || !inTimeRange(updated, updatedAfter, updatedBefore) {
+			continue
+		}
+		data := map[string]interface{}{
+			"id":   stack.ID,
+			"name": stack.Name,
+		}
+		if !created.IsZero() {
+			data["created"] = created.Format(time.RFC3339)
+		}
+		if !updated.IsZero() {
+			data["updated"] = updated.Format(time.RFC3339)
+		}
+		result = append(result, data)
+	}
+
+	if err := d.Set("total", len(result)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("stacks", result); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+// stacksCreatedFilter returns the list filter for stacks created in the
+// given range, oldest first. See stacksTimeFilter for how the range is sent.
+func stacksCreatedFilter(after, before time.Time) map[string]string {
+	filter := map[string]string{
+		"sort_by": "asc:created",
+	}
+	stacksTimeFilter(filter, "created", after, before)
+	return filter
+}
+
+// stacksTimeFilter adds the filter on a timestamp field for the range from
+// after, inclusive, to before, exclusive. Zero times leave the range open.
+// The API only accepts one filter per field, so if both bounds are set only
+// the lower bound is sent, and the bounds are rounded to whole seconds so
+// that the filter includes the whole range. The caller must check the
+// results against the range with inTimeRange.
+func stacksTimeFilter(filter map[string]string, field string, after, before time.Time) {
+	switch {
+	case !after.IsZero():
+		filter[field+":gte"] = formatFilterBound("gte", after)
+	case !before.IsZero():
+		filter[field+":lt"] = formatFilterBound("lt", before)
+	}
+}
+
+// exactTimeRange reports whether stacksTimeFilter sends the range exactly,
+// so that the server's results need no further checks.
+func exactTimeRange(after, before time.Time) bool {
+	if !after.IsZero() && !before.IsZero() {
+		return false
+	}
+	return after.Equal(after.Truncate(time.Second)) && before.Equal(before.Truncate(time.Second))
+}
+
+// inTimeRange reports whether t is in the range from after, inclusive, to
+// before, exclusive. Zero times leave the range open.
+func inTimeRange(t, after, before time.Time) bool {
+	return (after.IsZero() || !t.Before(after)) && (before.IsZero() || t.Before(before))
+}
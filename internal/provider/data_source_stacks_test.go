@@ -0,0 +1,218 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDataSourceStacks_createdRange(t *testing.T) {
+	stacks := []StackResponse{
+		{ID: "a", Name: "a", Body: &StackResponseBody{Created: "2024-01-01T00:00:00"}},
+		{ID: "b", Name: "b", Body: &StackResponseBody{Created: "2024-01-15T08:30:00.123456"}},
+		{ID: "c", Name: "c", Body: &StackResponseBody{Created: "2024-01-31T23:59:59"}},
+		{ID: "d", Name: "d", Body: &StackResponseBody{Created: "2024-02-01T00:00:00"}},
+	}
+	var filters []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		filters = append(filters, query.Get("created"))
+		if query.Get("sort_by") != "asc:created" {
+			t.Errorf("expected stacks to be sorted by creation time, got %q", query.Get("sort_by"))
+		}
+		// Two stacks per page
+		page, _ := strconv.Atoi(query.Get("page"))
+		json.NewEncoder(w).Encode(Page[StackResponse]{
+			Index:      page,
+			MaxSize:    2,
+			TotalPages: 2,
+			Items:      stacks[(page-1)*2 : page*2],
+		})
+	}))
+
+	d := schema.TestResourceDataRaw(t, dataSourceStacks().Schema, map[string]interface{}{
+		// Bounds in other timezones are converted to UTC
+		"created_after":  "2024-01-01T02:00:00+02:00",
+		"created_before": "2024-02-01T00:00:00Z",
+	})
+	if diags := dataSourceStacksRead(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	for _, filter := range filters {
+		if filter != "gte:2024-01-01 00:00:00" {
+			t.Errorf("expected the creation time filter in UTC, got %q", filter)
+		}
+	}
+	if len(filters) != 2 {
+		t.Errorf("expected both pages to be read, got %d requests", len(filters))
+	}
+
+	if got := d.Get("stacks.#").(int); got != 3 {
+		t.Fatalf("expected 3 stacks in the range, got %d", got)
+	}
+	if got := d.Get("stacks.1.created").(string); got != "2024-01-15T08:30:00Z" {
+		t.Errorf("expected the creation time in UTC, got %q", got)
+	}
+	if got := d.Get("stacks.2.id").(string); got != "c" {
+		t.Errorf("expected the last stack in the range to be c, got %q", got)
+	}
+	if got := d.Get("total").(int); got != 3 {
+		t.Errorf("expected a total of 3 stacks, got %d", got)
+	}
+}
+
+func TestDataSourceStacks_countOnly(t *testing.T) {
+	requests := 0
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		query := r.URL.Query()
+		if query.Get("size") != "1" {
+			t.Errorf("expected a single stack to be requested, got size %q", query.Get("size"))
+		}
+		if query.Get("workspace") != "ml" {
+			t.Errorf("expected the workspace filter, got %q", r.URL.RawQuery)
+		}
+		json.NewEncoder(w).Encode(Page[StackResponse]{
+			Index:      1,
+			MaxSize:    1,
+			TotalPages: 42,
+			Total:      42,
+			Items:      []StackResponse{{ID: "a", Name: "a"}},
+		})
+	}))
+
+	d := schema.TestResourceDataRaw(t, dataSourceStacks().Schema, map[string]interface{}{
+		"workspace":  "ml",
+		"count_only": true,
+	})
+	if diags := dataSourceStacksRead(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	if requests != 1 {
+		t.Errorf("expected a single request, got %d", requests)
+	}
+	if got := d.Get("total").(int); got != 42 {
+		t.Errorf("expected the total reported by the server, got %d", got)
+	}
+	if got := d.Get("stacks.#").(int); got != 0 {
+		t.Errorf("expected no stacks to be listed, got %d", got)
+	}
+}
+
+func TestStacksCreatedFilter(t *testing.T) {
+	before := time.Date(2024, 2, 1, 1, 0, 0, 0, time.FixedZone("CET", 3600))
+	params := &ListParams{Page: 1, PageSize: 10, Filter: stacksCreatedFilter(time.Time{}, before)}
+	if got := listQuery(params).Get("created"); got != "lt:2024-02-01 00:00:00" {
+		t.Errorf("expected an upper bound filter in UTC, got %q", got)
+	}
+
+	params.Filter = stacksCreatedFilter(time.Time{}, time.Time{})
+	if got := listQuery(params).Get("created"); got != "" {
+		t.Errorf("expected no creation time filter, got %q", got)
+	}
+}
+
+func TestDataSourceStacks_updatedRange(t *testing.T) {
+	stacks := []StackResponse{
+		{ID: "a", Name: "a", Body: &StackResponseBody{Created: "2024-01-01T00:00:00", Updated: "2024-03-01T00:00:00"}},
+		{ID: "b", Name: "b", Body: &StackResponseBody{Created: "2024-01-02T00:00:00", Updated: "2024-03-31T00:00:00"}},
+		{ID: "c", Name: "c", Body: &StackResponseBody{Created: "2024-01-03T00:00:00", Updated: "2024-04-01T00:00:01"}},
+	}
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("updated"); got != "gte:2024-03-01 00:00:00" {
+			t.Errorf("expected the lower bound of the update time to be sent, got %q", got)
+		}
+		json.NewEncoder(w).Encode(Page[StackResponse]{Index: 1, MaxSize: 10, TotalPages: 1, Items: stacks})
+	}))
+
+	d := schema.TestResourceDataRaw(t, dataSourceStacks().Schema, map[string]interface{}{
+		"updated_after":  "2024-03-01T00:00:00Z",
+		"updated_before": "2024-04-01T00:00:00Z",
+		"count_only":     true,
+	})
+	if diags := dataSourceStacksRead(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	// Both bounds of a range are needed, so the stacks are listed to count
+	// them
+	if got := d.Get("total").(int); got != 2 {
+		t.Errorf("expected 2 stacks updated in the range, got %d", got)
+	}
+	if got := d.Get("stacks.1.updated").(string); got != "2024-03-31T00:00:00Z" {
+		t.Errorf("expected the update time in UTC, got %q", got)
+	}
+}
+
+func TestDataSourceStacks_subSecondBounds(t *testing.T) {
+	stacks := []StackResponse{
+		{ID: "a", Name: "a", Body: &StackResponseBody{Created: "2024-01-01T00:00:00.2"}},
+		{ID: "b", Name: "b", Body: &StackResponseBody{Created: "2024-01-01T00:00:00.7"}},
+		{ID: "c", Name: "c", Body: &StackResponseBody{Created: "2024-02-01T00:00:00.3"}},
+		{ID: "d", Name: "d", Body: &StackResponseBody{Created: "2024-02-01T00:00:00.5"}},
+		{ID: "e", Name: "e", Body: &StackResponseBody{Created: "2024-02-01T00:00:00.9"}},
+	}
+	var filter string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filter = r.URL.Query().Get("created")
+		if r.URL.Query().Get("size") == "1" {
+			t.Error("expected the stacks to be listed to check sub-second bounds")
+		}
+		json.NewEncoder(w).Encode(Page[StackResponse]{Index: 1, MaxSize: 10, TotalPages: 1, Items: stacks})
+	}))
+
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		filter string
+		ids    []string
+	}{
+		{
+			name:   "lower bound",
+			config: map[string]interface{}{"created_after": "2024-01-01T00:00:00.5Z", "count_only": true},
+			filter: "gte:2024-01-01 00:00:00",
+			ids:    []string{"b", "c", "d", "e"},
+		},
+		{
+			name:   "upper bound",
+			config: map[string]interface{}{"created_before": "2024-02-01T00:00:00.5Z", "count_only": true},
+			filter: "lt:2024-02-01 00:00:01",
+			ids:    []string{"a", "b", "c"},
+		},
+		{
+			name:   "both bounds",
+			config: map[string]interface{}{"created_after": "2024-01-01T00:00:00.5Z", "created_before": "2024-02-01T00:00:00.5Z"},
+			filter: "gte:2024-01-01 00:00:00",
+			ids:    []string{"b", "c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, dataSourceStacks().Schema, tt.config)
+			if diags := dataSourceStacksRead(context.Background(), d, client); diags.HasError() {
+				t.Fatalf("unexpected error: %v", diags)
+			}
+			if filter != tt.filter {
+				t.Errorf("expected the filter %q, got %q", tt.filter, filter)
+			}
+			var ids []string
+			for i := 0; i < d.Get("stacks.#").(int); i++ {
+				ids = append(ids, d.Get("stacks."+strconv.Itoa(i)+".id").(string))
+			}
+			if !reflect.DeepEqual(ids, tt.ids) {
+				t.Errorf("expected the stacks %v, got %v", tt.ids, ids)
+			}
+			if got := d.Get("total").(int); got != len(tt.ids) {
+				t.Errorf("expected a total of %d stacks, got %d", len(tt.ids), got)
+			}
+		})
+	}
+}
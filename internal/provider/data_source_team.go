@@ -0,0 +1,65 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceTeam() *schema.Resource {
+	return &schema.Resource{
+		Description: "Data source for looking up a ZenML Pro team by name",
+		ReadContext: dataSourceTeamRead,
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Description: "Name of the team",
+				Type:        schema.TypeString,
+				Required:    true,
+			},
+			"description": {
+				Description: "Description of the team",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"member_count": {
+				Description: "Number of members of the team",
+				Type:        schema.TypeInt,
+				Computed:    true,
+			},
+			"created": {
+				Description: "Timestamp when the team was created",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"updated": {
+				Description: "Timestamp when the team was last updated",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func dataSourceTeamRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*Client)
+
+	team, err := c.GetTeamByName(ctx, d.Get("name").(string))
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error getting team: %w", err))
+	}
+
+	d.SetId(team.ID)
+
+	if team.Body != nil {
+		d.Set("member_count", team.Body.MemberCount)
+		d.Set("created", team.Body.Created)
+		d.Set("updated", team.Body.Updated)
+	}
+	if team.Metadata != nil && team.Metadata.Description != nil {
+		d.Set("description", *team.Metadata.Description)
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDataSourceTeam(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/teams" || r.URL.Query().Get("name") != "ml-platform" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		w.Write([]byte(`{"index": 1, "total_pages": 1, "total": 1, "items": [{
+			"id": "team-id",
+			"name": "ml-platform",
+			"body": {"member_count": 4},
+			"metadata": {"description": "Owners of the production stacks"}
+		}]}`))
+	}))
+
+	d := schema.TestResourceDataRaw(t, dataSourceTeam().Schema, map[string]interface{}{
+		"name": "ml-platform",
+	})
+	if diags := dataSourceTeamRead(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if d.Id() != "team-id" {
+		t.Errorf("expected team team-id, got %q", d.Id())
+	}
+	if d.Get("member_count") != 4 || d.Get("description") != "Owners of the production stacks" {
+		t.Errorf("unexpected team attributes %v", d.State().Attributes)
+	}
+}
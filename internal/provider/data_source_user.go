@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceUser() *schema.Resource {
+	return &schema.Resource{
+		Description: "Data source for looking up a ZenML user or service account by name. Personal fields such as the email address are never read.",
+		ReadContext: dataSourceUserRead,
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Description: "Name of the user",
+				Type:        schema.TypeString,
+				Required:    true,
+			},
+			"full_name": {
+				Description: "Display name of the user",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"active": {
+				Description: "Whether the user account is active",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+			"is_service_account": {
+				Description: "Whether the user is a service account",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+			"is_admin": {
+				Description: "Whether the user is a server administrator",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+			"created": {
+				Description: "Timestamp when the user was created",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func dataSourceUserRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*Client)
+
+	user, err := c.GetUserByName(ctx, d.Get("name").(string))
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error getting user: %w", err))
+	}
+
+	d.SetId(user.ID)
+
+	// Only the body is read: the metadata holds the email address and
+	// other personal fields
+	if user.Body != nil {
+		d.Set("full_name", user.Body.FullName)
+		d.Set("active", user.Body.Active)
+		d.Set("is_service_account", user.Body.IsServiceAccount)
+		d.Set("is_admin", user.Body.IsAdmin)
+		d.Set("created", user.Body.Created)
+	}
+
+	return nil
+}
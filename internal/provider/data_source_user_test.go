@@ -0,0 +1,62 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDataSourceUser(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/users" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		switch r.URL.Query().Get("name") {
+		case "alice":
+			w.Write([]byte(`{"index": 1, "total_pages": 1, "total": 1, "items": [{
+				"id": "user-id",
+				"name": "alice",
+				"body": {"full_name": "Alice", "active": true, "is_admin": true},
+				"metadata": {"email": "alice@example.com"}
+			}]}`))
+		case "bob":
+			w.Write([]byte(`{"index": 1, "total_pages": 1, "total": 2, "items": [
+				{"id": "bob-1", "name": "bob"},
+				{"id": "bob-2", "name": "bob"}
+			]}`))
+		default:
+			w.Write([]byte(`{"index": 1, "total_pages": 1, "total": 0, "items": []}`))
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, dataSourceUser().Schema, map[string]interface{}{
+		"name": "alice",
+	})
+	if diags := dataSourceUserRead(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if d.Id() != "user-id" || d.Get("full_name") != "Alice" || d.Get("is_admin") != true {
+		t.Errorf("unexpected user %s: %v", d.Id(), d.State().Attributes)
+	}
+	for key, value := range d.State().Attributes {
+		if strings.Contains(value, "alice@example.com") {
+			t.Errorf("expected the email address not to be in the state, found it in %s", key)
+		}
+	}
+
+	for name, want := range map[string]string{
+		"bob":     "ambiguous match: 2 users named bob",
+		"missing": "not found: no user named missing",
+	} {
+		d := schema.TestResourceDataRaw(t, dataSourceUser().Schema, map[string]interface{}{
+			"name": name,
+		})
+		diags := dataSourceUserRead(context.Background(), d, client)
+		if !diags.HasError() || !strings.Contains(diags[0].Summary, want) {
+			t.Errorf("expected %q, got %v", want, diags)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+// diagnostics.go
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+// maxListedItems bounds the number of item IDs listed in the detail of a
+// compacted diagnostic.
+const maxListedItems = 10
+
+// ItemError is the error of a single item of a bulk operation, e.g. one of
+// the components deleted by DeleteComponentsByFilter.
+type ItemError struct {
+	// Op is the operation that failed, e.g. "deleting"
+	Op string
+	// Kind is the kind of item, e.g. "component"
+	Kind string
+	ID   string
+	Err  error
+}
+
+func (e *ItemError) Error() string {
+	return fmt.Sprintf("error %s %s %s: %v", e.Op, e.Kind, e.ID, e.Err)
+}
+
+func (e *ItemError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError is the error of a bulk operation in which some items failed.
+// It unwraps to the *ItemError of each failed item, so that errors.Is and
+// errors.As match the error of any of them.
+type MultiError struct {
+	Errors []*ItemError
+}
+
+// newMultiError returns a *MultiError of the failed items, skipping nil
+// errors, or nil if no item failed.
+func newMultiError(errs []*ItemError) error {
+	var failed []*ItemError
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return &MultiError{Errors: failed}
+}
+
+// Error summarizes the failures on the first line, e.g. "failed on 2
+// components", followed by the error of each item on its own line.
+func (e *MultiError) Error() string {
+	if len(e.Errors) == 1 {
+		return e.Errors[0].Error()
+	}
+	kind := e.Errors[0].Kind
+	lines := make([]string, 0, len(e.Errors)+1)
+	for _, err := range e.Errors {
+		if err.Kind != kind {
+			kind = "item"
+		}
+	}
+	lines = append(lines, fmt.Sprintf("failed on %d %ss:", len(e.Errors), kind))
+	for _, err := range e.Errors {
+		lines = append(lines, err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e *MultiError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// IDs returns the IDs of the failed items, in the order they failed.
+func (e *MultiError) IDs() []string {
+	ids := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		ids[i] = err.ID
+	}
+	return ids
+}
+
+// bulkErrorDiagnostics converts the error of a bulk operation into
+// diagnostics. Item errors that share an operation and cause are compacted
+// into a single diagnostic with a count, e.g. "failed on 37 components:
+// unauthorized", so that a common root cause isn't reported hundreds of
+// times. Distinct errors are preserved in the order they first occur.
+func bulkErrorDiagnostics(err error) diag.Diagnostics {
+	if err == nil {
+		return nil
+	}
+
+	type group struct {
+		op, kind, cause string
+		ids             []string
+	}
+	var diags diag.Diagnostics
+	var groups []*group
+	index := map[string]*group{}
+	for _, e := range flattenErrors(err) {
+		var itemErr *ItemError
+		if !errors.As(e, &itemErr) {
+			diags = append(diags, diag.FromErr(e)...)
+			continue
+		}
+		// Every request has its own ID, which would keep errors with the
+		// same cause apart
+		cause := itemErr.Err.Error()
+		key := itemErr.Op + "\x00" + itemErr.Kind + "\x00" + withoutRequestDetails(cause)
+		g, ok := index[key]
+		if !ok {
+			g = &group{op: itemErr.Op, kind: itemErr.Kind, cause: cause}
+			index[key] = g
+			groups = append(groups, g)
+		} else {
+			g.cause = withoutRequestDetails(cause)
+		}
+		g.ids = append(g.ids, itemErr.ID)
+	}
+
+	for _, g := range groups {
+		if len(g.ids) == 1 {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("error %s %s %s: %s", g.op, g.kind, g.ids[0], g.cause),
+			})
+			continue
+		}
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  fmt.Sprintf("failed on %d %ss: %s", len(g.ids), g.kind, g.cause),
+			Detail:   fmt.Sprintf("Error %s %ss %s", g.op, g.kind, listItems(g.ids)),
+		})
+	}
+	return compactDiagnostics(diags)
+}
+
+// compactDiagnostics merges identical diagnostics into one that states how
+// often it was repeated.
+func compactDiagnostics(diags diag.Diagnostics) diag.Diagnostics {
+	var result diag.Diagnostics
+	counts := map[string]int{}
+	first := map[string]int{}
+	for _, d := range diags {
+		key := fmt.Sprintf("%d\x00%s\x00%s\x00%#v", d.Severity, d.Summary, d.Detail, d.AttributePath)
+		if _, ok := first[key]; !ok {
+			first[key] = len(result)
+			result = append(result, d)
+		}
+		counts[key]++
+	}
+	for key, n := range counts {
+		if n > 1 {
+			result[first[key]].Summary = fmt.Sprintf("%s (repeated %d times)", result[first[key]].Summary, n)
+		}
+	}
+	return result
+}
+
+// flattenErrors returns the errors joined into err with errors.Join or a
+// *MultiError, recursively.
+func flattenErrors(err error) []error {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+	var errs []error
+	for _, e := range joined.Unwrap() {
+		if e != nil {
+			errs = append(errs, flattenErrors(e)...)
+		}
+	}
+	return errs
+}
+
+// listItems formats sorted item IDs, listing at most maxListedItems of them.
+func listItems(ids []string) string {
+	sorted := append([]string{}, ids...)
+	sort.Strings(sorted)
+	if len(sorted) <= maxListedItems {
+		return strings.Join(sorted, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(sorted[:maxListedItems], ", "), len(sorted)-maxListedItems)
+}
+
+// inUseDiagnostics reports a resource that can't be deleted because other
+// resources still use it, naming them. Resources check this before deleting,
+// because the server rejects the delete with an opaque conflict.
+func inUseDiagnostics(resource, user string, names []string, remedy string) diag.Diagnostics {
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  fmt.Sprintf("%s is still used by %s", resource, pluralize(len(names), user)),
+		Detail:   fmt.Sprintf("The %s can't be deleted while it is used by %s. %s", strings.ToLower(resource), listItems(names), remedy),
+	}}
+}
@@ -0,0 +1,129 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+func TestBulkErrorDiagnostics(t *testing.T) {
+	unauthorized := &APIError{StatusCode: 401, Detail: "unauthorized"}
+	var errs []error
+	for i := 0; i < 37; i++ {
+		errs = append(errs, &ItemError{Op: "deleting", Kind: "component", ID: fmt.Sprintf("c%02d", i), Err: unauthorized})
+	}
+	errs = append(errs,
+		&ItemError{Op: "deleting", Kind: "component", ID: "locked", Err: errors.New("component is in use")},
+		errors.New("error listing components: timeout"),
+	)
+
+	diags := bulkErrorDiagnostics(errors.Join(errs...))
+	if len(diags) != 3 {
+		t.Fatalf("expected 3 diagnostics, got %d: %v", len(diags), diags)
+	}
+
+	summaries := []string{diags[0].Summary, diags[1].Summary, diags[2].Summary}
+	want := []string{
+		"error listing components: timeout",
+		"failed on 37 components: " + unauthorized.Error(),
+		"error deleting component locked: component is in use",
+	}
+	if strings.Join(summaries, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected summaries\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(summaries, "\n"))
+	}
+	if !strings.HasSuffix(diags[1].Detail, "c09 and 27 more") {
+		t.Errorf("expected the listed components to be truncated, got %q", diags[1].Detail)
+	}
+
+	if diags := bulkErrorDiagnostics(nil); diags != nil {
+		t.Errorf("expected no diagnostics without an error, got %v", diags)
+	}
+}
+
+func TestCompactDiagnostics(t *testing.T) {
+	diags := compactDiagnostics(diag.Diagnostics{
+		{Severity: diag.Error, Summary: "unauthorized"},
+		{Severity: diag.Warning, Summary: "unauthorized"},
+		{Severity: diag.Error, Summary: "unauthorized"},
+		{Severity: diag.Error, Summary: "not found"},
+	})
+
+	want := []string{"unauthorized (repeated 2 times)", "unauthorized", "not found"}
+	if len(diags) != len(want) {
+		t.Fatalf("expected %d diagnostics, got %v", len(want), diags)
+	}
+	for i, summary := range want {
+		if diags[i].Summary != summary {
+			t.Errorf("expected diagnostic %d to be %q, got %q", i, summary, diags[i].Summary)
+		}
+	}
+}
+
+func TestBulkErrorDiagnosticsIgnoresRequestIDs(t *testing.T) {
+	var errs []error
+	for i := 0; i < 3; i++ {
+		errs = append(errs, &ItemError{Op: "deleting", Kind: "component", ID: fmt.Sprintf("c%d", i), Err: fmt.Errorf("after %d attempts in %dms: %w", i+1, 10*i, &APIError{
+			StatusCode: 403,
+			Detail:     "forbidden",
+			RequestID:  fmt.Sprintf("request-%d", i),
+		})})
+	}
+
+	diags := bulkErrorDiagnostics(errors.Join(errs...))
+	if len(diags) != 1 {
+		t.Fatalf("expected the errors to be compacted into 1 diagnostic, got %d: %v", len(diags), diags)
+	}
+	if want := "failed on 3 components: API request failed with status 403: forbidden"; diags[0].Summary != want {
+		t.Errorf("expected summary %q, got %q", want, diags[0].Summary)
+	}
+}
+
+func TestMultiError(t *testing.T) {
+	if err := newMultiError([]*ItemError{nil, nil}); err != nil {
+		t.Fatalf("expected no error without failed items, got %v", err)
+	}
+
+	errInUse := errors.New("component is in use")
+	notFound := &APIError{StatusCode: 404, Detail: "not found"}
+	err := newMultiError([]*ItemError{
+		{Op: "deleting", Kind: "component", ID: "a", Err: errInUse},
+		nil,
+		{Op: "deleting", Kind: "component", ID: "b", Err: fmt.Errorf("request failed: %w", notFound)},
+	})
+	// Rolling back a batch wraps its error
+	err = fmt.Errorf("error creating stack: %w", err)
+
+	if !errors.Is(err, errInUse) {
+		t.Errorf("expected the error to match the error of the first item")
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr != notFound {
+		t.Errorf("expected the error to unwrap to the API error of the second item, got %v", apiErr)
+	}
+	var itemErr *ItemError
+	if !errors.As(err, &itemErr) || itemErr.ID != "a" {
+		t.Errorf("expected the error to unwrap to the first item error, got %v", itemErr)
+	}
+	if errors.Is(err, context.Canceled) {
+		t.Errorf("expected the error not to match an error of no item")
+	}
+
+	var multiErr *MultiError
+	if !errors.As(err, &multiErr) {
+		t.Fatalf("expected a *MultiError, got %T", err)
+	}
+	if ids := multiErr.IDs(); len(ids) != 2 || ids[0] != "a" || ids[1] != "b" {
+		t.Errorf("expected the IDs of the failed items, got %v", ids)
+	}
+	want := "failed on 2 components:\nerror deleting component a: component is in use\nerror deleting component b: request failed: " + notFound.Error()
+	if multiErr.Error() != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, multiErr.Error())
+	}
+	if n := len(flattenErrors(multiErr)); n != 2 {
+		t.Errorf("expected the item errors to be flattened for diagnostics, got %d errors", n)
+	}
+}
@@ -0,0 +1,236 @@
+// filter.go
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// FilterBuilder builds the list parameters of a filtered list request, e.g.
+//
+//	params, err := NewFilter().
+//		Equals("workspace", "default").
+//		Contains("name", "prod").
+//		Size(50).
+//		Build()
+//
+// Conditions on different fields are combined by the server. Setting the
+// same field and operator twice keeps the last value.
+type FilterBuilder struct {
+	params ListParams
+	errs   []error
+}
+
+// NewFilter returns an empty filter builder.
+func NewFilter() *FilterBuilder {
+	return &FilterBuilder{params: ListParams{Filter: map[string]string{}}}
+}
+
+// Where adds a condition on a field with one of the operators supported by
+// the server, e.g. "contains". An empty operator filters on equality.
+// Unsupported operators and invalid fields make Build fail.
+func (b *FilterBuilder) Where(field, operator, value string) *FilterBuilder {
+	if field == "" || strings.Contains(field, ":") {
+		b.errs = append(b.errs, fmt.Errorf("invalid filter field %q", field))
+		return b
+	}
+	if operator != "" && !filterOperators[operator] {
+		b.errs = append(b.errs, fmt.Errorf("unsupported filter operator %q for field %q", operator, field))
+		return b
+	}
+	if operator == "" {
+		if prefix, _, ok := strings.Cut(value, ":"); ok && filterOperators[prefix] {
+			// The server would parse the prefix as an operator, so spell
+			// out the equality
+			operator = "equals"
+		}
+	}
+	key := field
+	if operator != "" {
+		key += ":" + operator
+	}
+	b.params.Filter[key] = value
+	return b
+}
+
+// Equals filters on fields equal to value.
+func (b *FilterBuilder) Equals(field, value string) *FilterBuilder {
+	return b.Where(field, "", value)
+}
+
+// NotEquals filters on fields that differ from value.
+func (b *FilterBuilder) NotEquals(field, value string) *FilterBuilder {
+	return b.Where(field, "notequals", value)
+}
+
+// Contains filters on fields that contain value.
+func (b *FilterBuilder) Contains(field, value string) *FilterBuilder {
+	return b.Where(field, "contains", value)
+}
+
+// StartsWith filters on fields that start with value.
+func (b *FilterBuilder) StartsWith(field, value string) *FilterBuilder {
+	return b.Where(field, "startswith", value)
+}
+
+// EndsWith filters on fields that end with value.
+func (b *FilterBuilder) EndsWith(field, value string) *FilterBuilder {
+	return b.Where(field, "endswith", value)
+}
+
+// OneOf filters on fields equal to any of the values.
+func (b *FilterBuilder) OneOf(field string, values ...string) *FilterBuilder {
+	if len(values) == 0 {
+		b.errs = append(b.errs, fmt.Errorf("no values to filter field %q on", field))
+		return b
+	}
+	// The server expects the values as a JSON list
+	list, _ := json.Marshal(values)
+	return b.Where(field, "oneof", string(list))
+}
+
+// Repeated filters on a field with each of the values, sent as repeated
+// query parameters, e.g. "tag=a&tag=b" to filter on several tags. How the
+// values combine depends on the field. Repeating the field adds values.
+func (b *FilterBuilder) Repeated(field string, values ...string) *FilterBuilder {
+	if field == "" || strings.Contains(field, ":") {
+		b.errs = append(b.errs, fmt.Errorf("invalid filter field %q", field))
+		return b
+	}
+	if len(values) == 0 {
+		b.errs = append(b.errs, fmt.Errorf("no values to filter field %q on", field))
+		return b
+	}
+	if b.params.MultiFilter == nil {
+		b.params.MultiFilter = map[string][]string{}
+	}
+	for _, value := range values {
+		if prefix, _, ok := strings.Cut(value, ":"); ok && filterOperators[prefix] {
+			// As in Where, the server would parse the prefix as an
+			// operator
+			value = "equals:" + value
+		}
+		b.params.MultiFilter[field] = append(b.params.MultiFilter[field], value)
+	}
+	return b
+}
+
+// GreaterThan filters on fields greater than value, e.g. a timestamp.
+func (b *FilterBuilder) GreaterThan(field, value string) *FilterBuilder {
+	return b.Where(field, "gt", value)
+}
+
+// GreaterOrEqual filters on fields greater than or equal to value.
+func (b *FilterBuilder) GreaterOrEqual(field, value string) *FilterBuilder {
+	return b.Where(field, "gte", value)
+}
+
+// LessThan filters on fields less than value.
+func (b *FilterBuilder) LessThan(field, value string) *FilterBuilder {
+	return b.Where(field, "lt", value)
+}
+
+// LessOrEqual filters on fields less than or equal to value.
+func (b *FilterBuilder) LessOrEqual(field, value string) *FilterBuilder {
+	return b.Where(field, "lte", value)
+}
+
+// Page sets the page to fetch, starting at 1.
+func (b *FilterBuilder) Page(page int) *FilterBuilder {
+	b.params.Page = page
+	return b
+}
+
+// Size sets the page size.
+func (b *FilterBuilder) Size(size int) *FilterBuilder {
+	b.params.PageSize = size
+	return b
+}
+
+// Build returns the list parameters, or the errors of all invalid
+// conditions.
+func (b *FilterBuilder) Build() (*ListParams, error) {
+	if len(b.errs) > 0 {
+		return nil, errors.Join(b.errs...)
+	}
+	params := b.params
+	params.Filter = make(map[string]string, len(b.params.Filter))
+	for k, v := range b.params.Filter {
+		params.Filter[k] = v
+	}
+	if b.params.MultiFilter != nil {
+		params.MultiFilter = make(map[string][]string, len(b.params.MultiFilter))
+		for k, v := range b.params.MultiFilter {
+			params.MultiFilter[k] = slices.Clone(v)
+		}
+	}
+	return &params, nil
+}
+
+// CreatedAfter filters on resources created at or after t.
+func (p *ListParams) CreatedAfter(t time.Time) *ListParams {
+	return p.timeFilter("created", "gte", t)
+}
+
+// CreatedBefore filters on resources created at or before t.
+func (p *ListParams) CreatedBefore(t time.Time) *ListParams {
+	return p.timeFilter("created", "lte", t)
+}
+
+// UpdatedAfter filters on resources last updated at or after t.
+func (p *ListParams) UpdatedAfter(t time.Time) *ListParams {
+	return p.timeFilter("updated", "gte", t)
+}
+
+// UpdatedBefore filters on resources last updated at or before t.
+func (p *ListParams) UpdatedBefore(t time.Time) *ListParams {
+	return p.timeFilter("updated", "lte", t)
+}
+
+// filterTimeFormat is the format of timestamps in filters. These were first
+// sent as RFC 3339, but the server parses filter values with
+// "%Y-%m-%d %H:%M:%S" and rejects the "T" separator and zone suffix, so
+// timestamps are sent in its format instead, to the second.
+const filterTimeFormat = "2006-01-02 15:04:05"
+
+// formatFilterTime formats a timestamp the way the API expects it in
+// filters, in UTC. Fractions of a second are truncated.
+func formatFilterTime(t time.Time) string {
+	return t.UTC().Format(filterTimeFormat)
+}
+
+// formatFilterBound formats a timestamp bound for a filter with the given
+// operator. Since the server only gets whole seconds, an upper bound with a
+// fraction of a second is rounded up rather than truncated, so that the
+// filter never excludes a resource within the bound. Callers that need the
+// exact bound have to check it again on the results.
+func formatFilterBound(operator string, t time.Time) string {
+	if (operator == "lt" || operator == "lte") && !t.Truncate(time.Second).Equal(t) {
+		t = t.Truncate(time.Second).Add(time.Second)
+	}
+	return formatFilterTime(t)
+}
+
+// timeFilter filters a timestamp field with an operator, to the second (see
+// formatFilterBound). The server accepts a single filter per field, so it
+// replaces any other filter on the field.
+func (p *ListParams) timeFilter(field, operator string, t time.Time) *ListParams {
+	if p.Filter == nil {
+		p.Filter = map[string]string{}
+	}
+	for k := range p.Filter {
+		if f, _ := splitFilterKey(k); f == field {
+			delete(p.Filter, k)
+		}
+	}
+	if p.RawFilterKeys {
+		p.Filter[field] = operator + ":" + formatFilterBound(operator, t)
+	} else {
+		p.Filter[field+":"+operator] = formatFilterBound(operator, t)
+	}
+	return p
+}
@@ -0,0 +1,122 @@
+// internal/provider/filter_test.go
+package provider
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilterBuilder(t *testing.T) {
+	params, err := NewFilter().
+		Equals("workspace", "default").
+		Contains("name", "stag").
+		OneOf("type", "orchestrator", "artifact_store").
+		Page(2).
+		Size(50).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `name=contains%3Astag&page=2&size=50&type=oneof%3A%5B%22orchestrator%22%2C%22artifact_store%22%5D&workspace=default`
+	if got := listQuery(params).Encode(); got != want {
+		t.Errorf("expected query %q, got %q", want, got)
+	}
+}
+
+func TestFilterBuilderEscapesOperatorPrefix(t *testing.T) {
+	params, err := NewFilter().Equals("name", "contains:prod").Equals("url", "https://zenml.io").Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	query := listQuery(params)
+	if got := query.Get("name"); got != "equals:contains:prod" {
+		t.Errorf("expected a value with an operator prefix to be escaped, got %q", got)
+	}
+	if got := query.Get("url"); got != "https://zenml.io" {
+		t.Errorf("expected other values to be sent as is, got %q", got)
+	}
+}
+
+func TestFilterBuilderRepeated(t *testing.T) {
+	builder := NewFilter().Equals("workspace", "default").Repeated("tag", "prod", "gpu").Repeated("tag", "eu").Size(50)
+	params, err := builder.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "page=0&size=50&tag=prod&tag=gpu&tag=eu&workspace=default"
+	if got := listQuery(params).Encode(); got != want {
+		t.Errorf("expected query %q, got %q", want, got)
+	}
+
+	// Built parameters don't share values with the builder
+	builder.Repeated("tag", "us")
+	if got := listQuery(params)["tag"]; len(got) != 3 {
+		t.Errorf("expected the built parameters to keep 3 tags, got %v", got)
+	}
+
+	// Values that look like an operator are sent as equalities
+	params, err = NewFilter().Repeated("tag", "contains:x", "plain").Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := listQuery(params)["tag"], []string{"equals:contains:x", "plain"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected tags %v, got %v", want, got)
+	}
+
+	if _, err := NewFilter().Repeated("tag").Build(); err == nil || !strings.Contains(err.Error(), `field "tag"`) {
+		t.Errorf("expected an error for a filter without values, got %v", err)
+	}
+}
+
+func TestFilterBuilderErrors(t *testing.T) {
+	_, err := NewFilter().
+		Where("name", "like", "prod").
+		Equals("name:contains", "prod").
+		OneOf("type").
+		Build()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{`operator "like"`, `field "name:contains"`, `field "type"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected the error to mention %s, got %v", want, err)
+		}
+	}
+}
+
+func TestListParamsTimeFilters(t *testing.T) {
+	cutoff := time.Date(2024, 3, 1, 12, 30, 0, 0, time.FixedZone("CET", 3600))
+
+	params := (&ListParams{}).CreatedBefore(cutoff).UpdatedAfter(cutoff)
+	query := listQuery(params)
+	if got := query.Get("created"); got != "lte:2024-03-01 11:30:00" {
+		t.Errorf("expected the creation time filter in UTC, got %q", got)
+	}
+	if got := query.Get("updated"); got != "gte:2024-03-01 11:30:00" {
+		t.Errorf("expected the update time filter in UTC, got %q", got)
+	}
+
+	// A later filter on the same field replaces the earlier one
+	params.CreatedAfter(cutoff)
+	if got := listQuery(params)["created"]; len(got) != 1 || got[0] != "gte:2024-03-01 11:30:00" {
+		t.Errorf("expected a single creation time filter, got %q", got)
+	}
+
+	raw := (&ListParams{RawFilterKeys: true}).UpdatedBefore(cutoff)
+	if got := listQuery(raw).Get("updated"); got != "lte:2024-03-01 11:30:00" {
+		t.Errorf("expected the operator in the value with raw filter keys, got %q", got)
+	}
+
+	// Only whole seconds are sent, so an upper bound is rounded up to keep
+	// resources created within the last second before it
+	precise := cutoff.Add(250 * time.Millisecond)
+	query = listQuery((&ListParams{}).CreatedBefore(precise).UpdatedAfter(precise))
+	if got := query.Get("created"); got != "lte:2024-03-01 11:30:01" {
+		t.Errorf("expected the upper bound to be rounded up, got %q", got)
+	}
+	if got := query.Get("updated"); got != "gte:2024-03-01 11:30:00" {
+		t.Errorf("expected the lower bound to be truncated, got %q", got)
+	}
+}
@@ -0,0 +1,105 @@
+// json.go
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// normalizeJSON returns the canonical form of a JSON document: object keys
+// sorted, no insignificant whitespace and numbers in canonical form.
+// Documents that are semantically equal have the same canonical form.
+func normalizeJSON(value string) (string, error) {
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return "", err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return "", fmt.Errorf("invalid data after the JSON document")
+	}
+	return encodeJSON(normalizeNumbers(v))
+}
+
+// normalizeNumbers replaces the numbers of a decoded JSON document with
+// their canonical form, so that numbers are compared by value: 1.5 and 1.50,
+// or 100 and 1e2, are the same number. Integers are kept exact, other
+// numbers are compared as float64.
+func normalizeNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = normalizeNumbers(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = normalizeNumbers(value)
+		}
+	case json.Number:
+		if i, ok := new(big.Int).SetString(v.String(), 10); ok {
+			return json.Number(i.String())
+		}
+		f, err := v.Float64()
+		if err != nil {
+			// Out of the range of float64
+			return v
+		}
+		if f == math.Trunc(f) {
+			i, _ := big.NewFloat(f).Int(nil)
+			return json.Number(i.String())
+		}
+		return json.Number(strconv.FormatFloat(f, 'g', -1, 64))
+	}
+	return v
+}
+
+// encodeJSON encodes a value in canonical form. encoding/json sorts map
+// keys, but escapes HTML characters unless told otherwise.
+func encodeJSON(v interface{}) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// suppressEquivalentJSON suppresses diffs between JSON documents that only
+// differ in formatting or key order. On map attributes it applies to each
+// value, so values that aren't JSON are compared as is.
+func suppressEquivalentJSON(k, old, new string, d *schema.ResourceData) bool {
+	o, err := normalizeJSON(old)
+	if err != nil {
+		return false
+	}
+	n, err := normalizeJSON(new)
+	if err != nil {
+		return false
+	}
+	return o == n
+}
+
+// flattenConfiguration converts a component configuration into a map of
+// strings. Values that aren't strings are JSON-encoded in canonical form.
+func flattenConfiguration(configuration map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(configuration))
+	for key, value := range configuration {
+		if s, ok := value.(string); ok {
+			result[key] = s
+			continue
+		}
+		encoded, _ := encodeJSON(value)
+		result[key] = encoded
+	}
+	return result
+}
@@ -0,0 +1,85 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestNormalizeJSON(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{`{"b": 1, "a": [1, 2]}`, `{"a":[1,2],"b":1}`},
+		{"{\n  \"a\": {\"y\": true, \"x\": null}\n}", `{"a":{"x":null,"y":true}}`},
+		{`{"url": "https://example.com/?a=1&b=<2>"}`, `{"url":"https://example.com/?a=1&b=<2>"}`},
+		{`{"big": 12345678901234567890, "float": 1.50}`, `{"big":12345678901234567890,"float":1.5}`},
+		{`[1.0, 1e2, -0.250, 2.5E-3]`, `[1,100,-0.25,0.0025]`},
+	}
+	for _, tt := range tests {
+		got, err := normalizeJSON(tt.value)
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", tt.value, err)
+		}
+		if got != tt.want {
+			t.Errorf("expected %s, got %s", tt.want, got)
+		}
+	}
+
+	for _, value := range []string{"not json", `{"a": 1} {"b": 2}`, `{"a": 1}}`} {
+		if _, err := normalizeJSON(value); err == nil {
+			t.Errorf("expected an error for %s", value)
+		}
+	}
+}
+
+func TestResourceStackComponentEquivalentConfiguration(t *testing.T) {
+	state := &terraform.InstanceState{
+		ID: "component-id",
+		Attributes: map[string]string{
+			"id":                         "component-id",
+			"workspace":                  "default",
+			"name":                       "orchestrator",
+			"type":                       "orchestrator",
+			"flavor":                     "kubernetes",
+			"config_merge_strategy":      "replace",
+			"skip_config_validation":     "false",
+			"skip_flavor_validation":     "false",
+			"force_delete":               "false",
+			"configuration.%":            "2",
+			"configuration.context":      "prod",
+			"configuration.pod_settings": `{"node_selectors":{"pool":"gpu"},"tolerations":[{"key":"gpu"}]}`,
+		},
+	}
+
+	tests := []struct {
+		name        string
+		podSettings string
+		wantDiff    bool
+	}{
+		{"reordered keys and whitespace", "{\n  \"tolerations\": [ {\"key\": \"gpu\"} ],\n  \"node_selectors\": {\"pool\": \"gpu\"}\n}", false},
+		{"changed value", `{"node_selectors": {"pool": "cpu"}, "tolerations": [{"key": "gpu"}]}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := terraform.NewResourceConfigRaw(map[string]interface{}{
+				"name":   "orchestrator",
+				"type":   "orchestrator",
+				"flavor": "kubernetes",
+				"configuration": map[string]interface{}{
+					"context":      "prod",
+					"pod_settings": tt.podSettings,
+				},
+			})
+			diff, err := resourceStackComponent().Diff(context.Background(), state, config, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if hasDiff := diff != nil && !diff.Empty(); hasDiff != tt.wantDiff {
+				t.Errorf("expected a diff %t, got %v", tt.wantDiff, diff)
+			}
+		})
+	}
+}
@@ -3,6 +3,8 @@ package provider
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
 )
 
 // Page represents a paginated response from the API
@@ -14,13 +16,28 @@ type Page[T any] struct {
 	Items      []T   `json:"items"`
 }
 
+// HasNext reports whether there are more pages after this one.
+func (p *Page[T]) HasNext() bool {
+	return p != nil && p.Index < p.TotalPages
+}
+
 // APIError represents an error response from the API
 type APIError struct {
-	Detail string `json:"detail"`
+	StatusCode int    `json:"-"`
+	Detail     string `json:"detail"`
+	// Body is the raw response body, whether or not it could be decoded
+	// into Detail
+	Body []byte `json:"-"`
+	// RequestID is the ID the server echoed in its X-Request-ID header, or
+	// the one sent with the request
+	RequestID string `json:"-"`
 }
 
 func (e *APIError) Error() string {
-	return e.Detail
+	if e.RequestID != "" {
+		return fmt.Sprintf("API request failed with status %d: %s (request ID %s)", e.StatusCode, e.Detail, e.RequestID)
+	}
+	return fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, e.Detail)
 }
 
 // ServerInfo represents the server information response from the API
@@ -33,6 +50,9 @@ type ServerInfo struct {
 	ServerURL 		string     `json:"server_url"`
 	DashboardURL 	string     `json:"dashboard_url"`
 	Metadata 		map[string]string `json:"metadata"`
+	// Page size limits, only reported by some server versions
+	DefaultPageSize int     `json:"default_page_size,omitempty"`
+	MaxPageSize     int     `json:"max_page_size,omitempty"`
 }
 
 // StackRequest represents a request to create a new stack
@@ -40,12 +60,13 @@ type StackRequest struct {
 	Name        string                     `json:"name"`
 	Components  map[string][]string        `json:"components"`          // Change to UUID strings
 	Labels 		map[string]string          `json:"labels"`
+	IsShared    *bool                      `json:"is_shared,omitempty"` // Servers with stack sharing
 }
 
 // StackResponse represents a stack response from the API
 type StackResponse struct {
-	ID       string                `json:"id"`
-	Name     string                `json:"name"`
+	ID       string                `json:"id" zenml:"required"`
+	Name     string                `json:"name" zenml:"required"`
 	Body     *StackResponseBody    `json:"body,omitempty"`
 	Metadata *StackResponseMetadata `json:"metadata,omitempty"`
 }
@@ -54,19 +75,86 @@ type StackResponseBody struct {
 	Created string      `json:"created"`
 	Updated string      `json:"updated"`
 	User    *UserResponse `json:"user,omitempty"`
+	IsShared *bool        `json:"is_shared,omitempty"` // Servers with stack sharing
 }
 
 type StackResponseMetadata struct {
 	Workspace     *WorkspaceResponse              `json:"workspace"`
-	Components    map[string][]ComponentResponse  `json:"components"`
+	Components    StackComponents                 `json:"components"`
 	Labels        map[string]string               `json:"labels,omitempty"`
 }
 
+// StackComponents are the components of a stack by type. Hydrated stacks
+// embed the components, others may only reference them by ID, in which case
+// only the IDs are set.
+type StackComponents map[string][]ComponentResponse
+
+func (s *StackComponents) UnmarshalJSON(data []byte) error {
+	var raw map[string][]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw == nil {
+		*s = nil
+		return nil
+	}
+	components := make(StackComponents, len(raw))
+	for componentType, items := range raw {
+		components[componentType] = make([]ComponentResponse, len(items))
+		for i, item := range items {
+			var id string
+			if err := json.Unmarshal(item, &id); err == nil {
+				components[componentType][i] = ComponentResponse{ID: id}
+				continue
+			}
+			if err := json.Unmarshal(item, &components[componentType][i]); err != nil {
+				return err
+			}
+		}
+	}
+	*s = components
+	return nil
+}
+
+// Hydrated reports whether all components are embedded with their body and
+// metadata, rather than only referenced by ID. Without any components, it
+// can't tell and reports false.
+func (s StackComponents) Hydrated() bool {
+	if len(s) == 0 {
+		return false
+	}
+	for _, components := range s {
+		for _, component := range components {
+			if component.Body == nil || component.Metadata == nil {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // StackUpdate represents an update to an existing stack
 type StackUpdate struct {
 	Name          *string                        `json:"name,omitempty"`
 	Components    map[string][]string            `json:"components,omitempty"`    // Only UUIDs for updates
-	Labels        map[string]string              `json:"labels,omitempty"`
+	// Labels replace all labels of the stack; nil leaves them unchanged
+	// and an empty map removes them
+	Labels        *map[string]string             `json:"labels,omitempty"`
+	IsShared      *bool                          `json:"is_shared,omitempty"` // Servers with stack sharing
+}
+
+// StackValidationResponse represents the result of validating a stack
+type StackValidationResponse struct {
+	Valid    bool                     `json:"valid"`
+	Messages []StackValidationMessage `json:"messages"`
+}
+
+// StackValidationMessage is a problem found while validating a stack
+type StackValidationMessage struct {
+	// Severity is either "error" or "warning"
+	Severity      string  `json:"severity"`
+	Message       string  `json:"message"`
+	ComponentType *string `json:"component_type,omitempty"`
 }
 
 // ComponentRequest represents a request to create a new component
@@ -79,13 +167,14 @@ type ComponentRequest struct {
 	Configuration     map[string]interface{}     `json:"configuration"`
 	ConnectorID       *string                    `json:"connector,omitempty"`
 	ConnectorResourceID *string                  `json:"connector_resource_id,omitempty"`
+	ConnectorResourceType *string                `json:"connector_resource_type,omitempty"`
 	Labels            map[string]string          `json:"labels,omitempty"`
 }
 
 // ComponentResponse represents a stack component response from the API
 type ComponentResponse struct {
-	ID       string                    `json:"id"`
-	Name     string                    `json:"name"`
+	ID       string                    `json:"id" zenml:"required"`
+	Name     string                    `json:"name" zenml:"required"`
 	Body     *ComponentResponseBody    `json:"body,omitempty"`
 	Metadata *ComponentResponseMetadata `json:"metadata,omitempty"`
 }
@@ -104,17 +193,42 @@ type ComponentResponseMetadata struct {
 	Configuration       map[string]interface{}   `json:"configuration"`
 	Labels              map[string]string        `json:"labels,omitempty"`
 	ConnectorResourceID *string                  `json:"connector_resource_id,omitempty"`
+	ConnectorResourceType *string                `json:"connector_resource_type,omitempty"`
 	Connector          *ServiceConnectorResponse `json:"connector,omitempty"`
 }
 
+// FlavorResponse represents a stack component flavor response from the API
+type FlavorResponse struct {
+	ID       string                  `json:"id" zenml:"required"`
+	Name     string                  `json:"name"`
+	Body     *FlavorResponseBody     `json:"body,omitempty"`
+	Metadata *FlavorResponseMetadata `json:"metadata,omitempty"`
+}
+
+type FlavorResponseBody struct {
+	Type        string  `json:"type"`
+	Integration *string `json:"integration,omitempty"`
+	DisplayName *string `json:"display_name,omitempty"`
+}
+
+type FlavorResponseMetadata struct {
+	// ConfigSchema is the JSON schema of the flavor's configuration
+	ConfigSchema map[string]interface{} `json:"config_schema"`
+	DocsURL      *string                `json:"docs_url,omitempty"`
+}
+
 // ComponentUpdate represents an update to an existing component
 type ComponentUpdate struct {
 	Name               *string                   `json:"name,omitempty"`
 	Type               *string                   `json:"type,omitempty"`
 	Flavor             *string                   `json:"flavor,omitempty"`
-	Configuration      map[string]interface{}    `json:"configuration,omitempty"`
-	ConnectorID        *string                   `json:"connector,omitempty"`
-	ConnectorResourceID *string                  `json:"connector_resource_id,omitempty"`
+	// The configuration replaces the stored one: nil leaves it unchanged,
+	// an empty map clears it
+	Configuration      *map[string]interface{}   `json:"configuration,omitempty"`
+	// The connector linkage is always sent: null detaches the connector
+	ConnectorID        *string                   `json:"connector"`
+	ConnectorResourceID *string                  `json:"connector_resource_id"`
+	ConnectorResourceType *string                `json:"connector_resource_type"`
 	Labels             map[string]string         `json:"labels,omitempty"`
 }
 
@@ -157,8 +271,8 @@ type ServiceConnectorType struct {
 
 // ServiceConnectorResponse represents a service connector response from the API
 type ServiceConnectorResponse struct {
-	ID          string                           `json:"id"`
-	Name        string                           `json:"name"`
+	ID          string                           `json:"id" zenml:"required"`
+	Name        string                           `json:"name" zenml:"required"`
 	Body        *ServiceConnectorResponseBody    `json:"body,omitempty"`
 	Metadata    *ServiceConnectorResponseMetadata `json:"metadata,omitempty"`
 }
@@ -174,11 +288,29 @@ type ServiceConnectorResponseBody struct {
 	ExpiresAt      *string                       `json:"expires_at,omitempty"`
 }
 
+// ConnectorTypeName returns the connector type, e.g. "aws", which servers
+// send either as a string or as the full connector type.
+func (b *ServiceConnectorResponseBody) ConnectorTypeName() string {
+	var name string
+	if err := json.Unmarshal(b.ConnectorType, &name); err == nil {
+		return name
+	}
+	var connectorType ServiceConnectorType
+	if err := json.Unmarshal(b.ConnectorType, &connectorType); err == nil {
+		return connectorType.ConnectorType
+	}
+	return ""
+}
+
 type ServiceConnectorResponseMetadata struct {
 	Workspace      *WorkspaceResponse            `json:"workspace"`
 	Configuration  map[string]interface{}        `json:"configuration"`
 	SecretID       *string                       `json:"secret_id,omitempty"`
+	Secrets        map[string]interface{}        `json:"secrets,omitempty"` // Values are masked by the server
 	Labels         map[string]string             `json:"labels,omitempty"`
+	// ExpirationSeconds is the lifetime of temporary credentials issued
+	// by the connector, e.g. STS tokens
+	ExpirationSeconds *int                       `json:"expiration_seconds,omitempty"`
 }
 
 
@@ -204,7 +336,9 @@ type ServiceConnectorUpdate struct {
 	Name           *string                       `json:"name,omitempty"`
 	Configuration  map[string]interface{}        `json:"configuration,omitempty"`
 	Secrets        map[string]string             `json:"secrets,omitempty"`
-	Labels         map[string]string             `json:"labels,omitempty"`
+	// Labels replace all labels of the connector; nil leaves them unchanged
+	// and an empty map removes them
+	Labels         map[string]string             `json:"labels"`
 	ResourceTypes  []string                      `json:"resource_types"`
 	ResourceID     *string                       `json:"resource_id,omitempty"`
 	ExpiresAt      *string                       `json:"expires_at,omitempty"`
@@ -212,7 +346,7 @@ type ServiceConnectorUpdate struct {
 
 // UserResponse represents a user response from the API
 type UserResponse struct {
-	ID               string           `json:"id"`
+	ID               string           `json:"id" zenml:"required"`
 	Name             string           `json:"name"`
 	Body             *UserResponseBody `json:"body,omitempty"`
 	Metadata         *UserResponseMetadata `json:"metadata,omitempty"`
@@ -245,11 +379,501 @@ type UserMetadata struct {
 	OverviewTourDone      bool     `json:"overview_tour_done"`
 }
 
+// TeamResponse represents a team response from the API
+type TeamResponse struct {
+	ID       string               `json:"id" zenml:"required"`
+	Name     string               `json:"name"`
+	Body     *TeamResponseBody     `json:"body,omitempty"`
+	Metadata *TeamResponseMetadata `json:"metadata,omitempty"`
+}
+
+type TeamResponseBody struct {
+	Created     string `json:"created"`
+	Updated     string `json:"updated"`
+	MemberCount int    `json:"member_count"`
+}
+
+type TeamResponseMetadata struct {
+	Description *string `json:"description,omitempty"`
+}
+
 // WorkspaceResponse represents a workspace response from the API
 type WorkspaceResponse struct {
-	ID          string    `json:"id"`
+	ID          string    `json:"id" zenml:"required"`
 	Name        string    `json:"name"`
 	Description string    `json:"description,omitempty"`
 	Created     string    `json:"created"`
 	Updated     string    `json:"updated"`
 }
+
+// TagRequest represents a request to create a new tag
+type TagRequest struct {
+	Name  string  `json:"name"`
+	Color *string `json:"color,omitempty"`
+}
+
+// TagResponse represents a tag response from the API
+type TagResponse struct {
+	ID   string           `json:"id" zenml:"required"`
+	Name string           `json:"name"`
+	Body *TagResponseBody `json:"body,omitempty"`
+}
+
+type TagResponseBody struct {
+	Created     string `json:"created"`
+	Updated     string `json:"updated"`
+	Color       string `json:"color"`
+	TaggedCount int    `json:"tagged_count"`
+}
+
+// TagUpdate represents an update to an existing tag
+type TagUpdate struct {
+	Name  *string `json:"name,omitempty"`
+	Color *string `json:"color,omitempty"`
+}
+
+// TagResourceRequest represents a request to attach a tag to a resource
+type TagResourceRequest struct {
+	TagID        string `json:"tag_id"`
+	ResourceID   string `json:"resource_id"`
+	ResourceType string `json:"resource_type"`
+}
+
+// ModelRequest represents a request to create a new model
+type ModelRequest struct {
+	User        string   `json:"user"`
+	Workspace   string   `json:"workspace"`
+	Name        string   `json:"name"`
+	License     *string  `json:"license,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// ModelResponse represents a model response from the API
+type ModelResponse struct {
+	ID       string                 `json:"id" zenml:"required"`
+	Name     string                 `json:"name"`
+	Body     *ModelResponseBody     `json:"body,omitempty"`
+	Metadata *ModelResponseMetadata `json:"metadata,omitempty"`
+}
+
+type ModelResponseBody struct {
+	Created string        `json:"created"`
+	Updated string        `json:"updated"`
+	User    *UserResponse `json:"user,omitempty"`
+	Tags    []TagResponse `json:"tags,omitempty"`
+}
+
+type ModelResponseMetadata struct {
+	Workspace   *WorkspaceResponse `json:"workspace"`
+	License     *string            `json:"license,omitempty"`
+	Description *string            `json:"description,omitempty"`
+}
+
+// ModelUpdate represents an update to an existing model
+type ModelUpdate struct {
+	License     *string  `json:"license,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	AddTags     []string `json:"add_tags,omitempty"`
+	RemoveTags  []string `json:"remove_tags,omitempty"`
+}
+
+// ModelVersionRequest represents a request to create a new model version
+type ModelVersionRequest struct {
+	User        string   `json:"user"`
+	Workspace   string   `json:"workspace"`
+	Model       string   `json:"model"`
+	Name        *string  `json:"name,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// ModelVersionResponse represents a model version response from the API
+type ModelVersionResponse struct {
+	ID       string                        `json:"id" zenml:"required"`
+	Name     string                        `json:"name"`
+	Body     *ModelVersionResponseBody     `json:"body,omitempty"`
+	Metadata *ModelVersionResponseMetadata `json:"metadata,omitempty"`
+}
+
+type ModelVersionResponseBody struct {
+	Created string         `json:"created"`
+	Updated string         `json:"updated"`
+	User    *UserResponse  `json:"user,omitempty"`
+	Stage   *string        `json:"stage,omitempty"`
+	Number  int            `json:"number"`
+	Model   *ModelResponse `json:"model,omitempty"`
+}
+
+type ModelVersionResponseMetadata struct {
+	Workspace   *WorkspaceResponse `json:"workspace"`
+	Description *string            `json:"description,omitempty"`
+}
+
+// ModelVersionUpdate represents an update to an existing model version
+type ModelVersionUpdate struct {
+	Model       string  `json:"model"`
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Stage       *string `json:"stage,omitempty"`
+	// Force moves the version to the stage even if another version of the
+	// same model already occupies it, demoting that version.
+	Force bool `json:"force"`
+}
+
+// PipelineResponse represents a pipeline response from the API
+type PipelineResponse struct {
+	ID   string                `json:"id" zenml:"required"`
+	Name string                `json:"name"`
+	Body *PipelineResponseBody `json:"body,omitempty"`
+}
+
+type PipelineResponseBody struct {
+	Created string        `json:"created"`
+	Updated string        `json:"updated"`
+	User    *UserResponse `json:"user,omitempty"`
+}
+
+// RunResponse represents a pipeline run response from the API
+type RunResponse struct {
+	ID       string               `json:"id" zenml:"required"`
+	Name     string               `json:"name"`
+	Body     *RunResponseBody     `json:"body,omitempty"`
+	Metadata *RunResponseMetadata `json:"metadata,omitempty"`
+}
+
+type RunResponseBody struct {
+	Created  string            `json:"created"`
+	Updated  string            `json:"updated"`
+	User     *UserResponse     `json:"user,omitempty"`
+	Status   string            `json:"status"`
+	Stack    *StackResponse    `json:"stack,omitempty"`
+	Pipeline *PipelineResponse `json:"pipeline,omitempty"`
+}
+
+type RunResponseMetadata struct {
+	Workspace *WorkspaceResponse `json:"workspace"`
+	StartTime *string            `json:"start_time,omitempty"`
+	EndTime   *string            `json:"end_time,omitempty"`
+}
+
+// ArtifactResponse represents an artifact response from the API
+type ArtifactResponse struct {
+	ID   string                `json:"id" zenml:"required"`
+	Name string                `json:"name"`
+	Body *ArtifactResponseBody `json:"body,omitempty"`
+}
+
+type ArtifactResponseBody struct {
+	Created string        `json:"created"`
+	Updated string        `json:"updated"`
+	Tags    []TagResponse `json:"tags,omitempty"`
+}
+
+// ArtifactVersionResponse represents an artifact version response from the
+// API
+type ArtifactVersionResponse struct {
+	ID   string                       `json:"id" zenml:"required"`
+	Body *ArtifactVersionResponseBody `json:"body,omitempty"`
+}
+
+type ArtifactVersionResponseBody struct {
+	Created  string            `json:"created"`
+	Updated  string            `json:"updated"`
+	Artifact *ArtifactResponse `json:"artifact,omitempty"`
+	Version  string            `json:"version"`
+	URI      string            `json:"uri"`
+	Type     string            `json:"type"`
+	DataType *SourceSpec       `json:"data_type,omitempty"`
+}
+
+// SourceSpec identifies a Python class by module and attribute
+type SourceSpec struct {
+	Module    string `json:"module"`
+	Attribute string `json:"attribute"`
+	Type      string `json:"type,omitempty"`
+}
+
+// ProjectRequest represents a request to create a new project
+type ProjectRequest struct {
+	Name        string  `json:"name"`
+	DisplayName *string `json:"display_name,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+// ProjectResponse represents a project response from the API
+type ProjectResponse struct {
+	ID       string                   `json:"id" zenml:"required"`
+	Name     string                   `json:"name"`
+	Body     *ProjectResponseBody     `json:"body,omitempty"`
+	Metadata *ProjectResponseMetadata `json:"metadata,omitempty"`
+}
+
+type ProjectResponseBody struct {
+	Created     string `json:"created"`
+	Updated     string `json:"updated"`
+	DisplayName string `json:"display_name"`
+}
+
+type ProjectResponseMetadata struct {
+	Description string `json:"description"`
+}
+
+// ProjectUpdate represents an update to an existing project
+type ProjectUpdate struct {
+	DisplayName *string `json:"display_name,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+// EventSourceRequest represents a request to create a new event source
+type EventSourceRequest struct {
+	User          string                 `json:"user"`
+	Workspace     string                 `json:"workspace"`
+	Name          string                 `json:"name"`
+	Description   string                 `json:"description"`
+	Flavor        string                 `json:"flavor"`
+	PluginSubtype string                 `json:"plugin_subtype"`
+	Configuration map[string]interface{} `json:"configuration"`
+}
+
+// EventSourceResponse represents an event source response from the API
+type EventSourceResponse struct {
+	ID       string                       `json:"id" zenml:"required"`
+	Name     string                       `json:"name"`
+	Body     *EventSourceResponseBody     `json:"body,omitempty"`
+	Metadata *EventSourceResponseMetadata `json:"metadata,omitempty"`
+}
+
+type EventSourceResponseBody struct {
+	Created       string        `json:"created"`
+	Updated       string        `json:"updated"`
+	User          *UserResponse `json:"user,omitempty"`
+	Flavor        string        `json:"flavor"`
+	PluginSubtype string        `json:"plugin_subtype"`
+	IsActive      bool          `json:"is_active"`
+}
+
+type EventSourceResponseMetadata struct {
+	Workspace     *WorkspaceResponse     `json:"workspace"`
+	Description   string                 `json:"description"`
+	Configuration map[string]interface{} `json:"configuration"`
+}
+
+// EventSourceUpdate represents an update to an existing event source
+type EventSourceUpdate struct {
+	Name          *string                `json:"name,omitempty"`
+	Description   *string                `json:"description,omitempty"`
+	Configuration map[string]interface{} `json:"configuration,omitempty"`
+	IsActive      *bool                  `json:"is_active,omitempty"`
+}
+
+// TriggerRequest represents a request to create a new trigger, which runs
+// an action when its event source emits an event that matches its filter
+type TriggerRequest struct {
+	User          string                 `json:"user"`
+	Workspace     string                 `json:"workspace"`
+	Name          string                 `json:"name"`
+	Description   string                 `json:"description"`
+	EventSourceID string                 `json:"event_source_id"`
+	EventFilter   map[string]interface{} `json:"event_filter,omitempty"`
+	ActionFlavor  string                 `json:"action_flavor"`
+	ActionSubtype string                 `json:"action_subtype"`
+	Action        map[string]interface{} `json:"action"`
+}
+
+// TriggerResponse represents a trigger response from the API
+type TriggerResponse struct {
+	ID       string                   `json:"id" zenml:"required"`
+	Name     string                   `json:"name"`
+	Body     *TriggerResponseBody     `json:"body,omitempty"`
+	Metadata *TriggerResponseMetadata `json:"metadata,omitempty"`
+}
+
+type TriggerResponseBody struct {
+	Created       string        `json:"created"`
+	Updated       string        `json:"updated"`
+	User          *UserResponse `json:"user,omitempty"`
+	ActionFlavor  string        `json:"action_flavor"`
+	ActionSubtype string        `json:"action_subtype"`
+	IsActive      bool          `json:"is_active"`
+}
+
+type TriggerResponseMetadata struct {
+	Workspace   *WorkspaceResponse     `json:"workspace"`
+	Description string                 `json:"description"`
+	EventFilter map[string]interface{} `json:"event_filter"`
+	Action      map[string]interface{} `json:"action"`
+	EventSource *EventSourceResponse   `json:"event_source,omitempty"`
+}
+
+// TriggerUpdate represents an update to an existing trigger
+type TriggerUpdate struct {
+	Name        *string                `json:"name,omitempty"`
+	Description *string                `json:"description,omitempty"`
+	EventFilter map[string]interface{} `json:"event_filter,omitempty"`
+	Action      map[string]interface{} `json:"action,omitempty"`
+	IsActive    *bool                  `json:"is_active,omitempty"`
+}
+
+// SecretResponse represents a secret response from the API. Only the names
+// of the secret's keys are decoded, never the values.
+type SecretResponse struct {
+	ID       string                  `json:"id" zenml:"required"`
+	Name     string                  `json:"name" zenml:"required"`
+	Body     *SecretResponseBody     `json:"body,omitempty"`
+	Metadata *SecretResponseMetadata `json:"metadata,omitempty"`
+}
+
+type SecretResponseBody struct {
+	Created string        `json:"created"`
+	Updated string        `json:"updated"`
+	User    *UserResponse `json:"user,omitempty"`
+	Scope   string        `json:"scope,omitempty"`   // Older servers
+	Private *bool         `json:"private,omitempty"` // Newer servers, replaces the scope
+	Keys    SecretKeys    `json:"values"`
+}
+
+type SecretResponseMetadata struct {
+	Workspace *WorkspaceResponse `json:"workspace"`
+}
+
+// SecretKeys holds the sorted names of the keys of a secret. It decodes from
+// the secret's values object and discards the values, so that they are never
+// held in memory, whether the server masked them or not.
+type SecretKeys []string
+
+func (k *SecretKeys) UnmarshalJSON(data []byte) error {
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	keys := make(SecretKeys, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	*k = keys
+	return nil
+}
+
+// EffectiveScope returns the scope of the secret, "user" or "workspace",
+// mapping the private flag of newer servers to the scope it replaces.
+func (b *SecretResponseBody) EffectiveScope() string {
+	if b.Scope != "" || b.Private == nil {
+		return b.Scope
+	}
+	if *b.Private {
+		return "user"
+	}
+	return "workspace"
+}
+
+// CodeRepositoryRequest represents a request to create a new code repository
+type CodeRepositoryRequest struct {
+	User        string                 `json:"user"`
+	Workspace   string                 `json:"workspace"`
+	Name        string                 `json:"name"`
+	Source      SourceSpec             `json:"source"`
+	Config      map[string]interface{} `json:"config"`
+	LogoURL     *string                `json:"logo_url,omitempty"`
+	Description *string                `json:"description,omitempty"`
+}
+
+// CodeRepositoryResponse represents a code repository response from the API
+type CodeRepositoryResponse struct {
+	ID       string                          `json:"id" zenml:"required"`
+	Name     string                          `json:"name"`
+	Body     *CodeRepositoryResponseBody     `json:"body,omitempty"`
+	Metadata *CodeRepositoryResponseMetadata `json:"metadata,omitempty"`
+}
+
+type CodeRepositoryResponseBody struct {
+	Created string        `json:"created"`
+	Updated string        `json:"updated"`
+	User    *UserResponse `json:"user,omitempty"`
+	Source  *SourceSpec   `json:"source,omitempty"`
+	LogoURL *string       `json:"logo_url,omitempty"`
+}
+
+type CodeRepositoryResponseMetadata struct {
+	Workspace   *WorkspaceResponse     `json:"workspace"`
+	Config      map[string]interface{} `json:"config"`
+	Description *string                `json:"description,omitempty"`
+}
+
+// CodeRepositoryUpdate represents an update to an existing code repository
+type CodeRepositoryUpdate struct {
+	Name        *string                `json:"name,omitempty"`
+	Config      map[string]interface{} `json:"config,omitempty"`
+	LogoURL     *string                `json:"logo_url,omitempty"`
+	Description *string                `json:"description,omitempty"`
+}
+
+// ConfigDefaults returns the default values of the flavor's configuration
+// and the set of keys known to its configuration schema.
+func (f *FlavorResponse) ConfigDefaults() (defaults map[string]interface{}, known map[string]bool) {
+	defaults = map[string]interface{}{}
+	known = map[string]bool{}
+	if f.Metadata == nil {
+		return defaults, known
+	}
+	properties, _ := f.Metadata.ConfigSchema["properties"].(map[string]interface{})
+	for key, property := range properties {
+		known[key] = true
+		if p, ok := property.(map[string]interface{}); ok {
+			if value, ok := p["default"]; ok {
+				defaults[key] = value
+			}
+		}
+	}
+	return defaults, known
+}
+
+// RequiredConfigKeys returns the configuration keys the flavor's
+// configuration schema requires.
+func (f *FlavorResponse) RequiredConfigKeys() []string {
+	keys := []string{}
+	if f.Metadata == nil {
+		return keys
+	}
+	required, _ := f.Metadata.ConfigSchema["required"].([]interface{})
+	for _, key := range required {
+		if k, ok := key.(string); ok {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// SecretConfigKeys returns the configuration keys the flavor's configuration
+// schema marks as sensitive, i.e. the keys of secret fields.
+func (f *FlavorResponse) SecretConfigKeys() map[string]bool {
+	keys := map[string]bool{}
+	if f.Metadata == nil {
+		return keys
+	}
+	properties, _ := f.Metadata.ConfigSchema["properties"].(map[string]interface{})
+	for key, property := range properties {
+		if p, ok := property.(map[string]interface{}); ok {
+			if sensitive, _ := p["sensitive"].(bool); sensitive {
+				keys[key] = true
+			}
+		}
+	}
+	return keys
+}
+
+// AllowsAdditionalConfig reports whether the flavor accepts configuration
+// keys that are not part of its configuration schema. As in JSON Schema,
+// extra keys are allowed unless additionalProperties is explicitly false, and
+// a schema without properties can't tell extra keys apart from known ones.
+func (f *FlavorResponse) AllowsAdditionalConfig() bool {
+	if f.Metadata == nil {
+		return true
+	}
+	if properties, _ := f.Metadata.ConfigSchema["properties"].(map[string]interface{}); len(properties) == 0 {
+		return true
+	}
+	allowed, ok := f.Metadata.ConfigSchema["additionalProperties"].(bool)
+	return !ok || allowed
+}
@@ -3,11 +3,22 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
+// defaultResourceTimeout bounds the operations of resources that support a
+// timeouts block unless it sets their timeout, like the SDK does for
+// resources that don't support one.
+const defaultResourceTimeout = 20 * time.Minute
+
 func Provider() *schema.Provider {
 	return &schema.Provider{
 		Schema: map[string]*schema.Schema{
@@ -28,17 +39,113 @@ func Provider() *schema.Provider {
 				Sensitive:   true,
 				DefaultFunc: schema.EnvDefaultFunc("ZENML_API_TOKEN", nil),
 			},
+			"token_auth": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ZENML_TOKEN_AUTH", true),
+				Description: "Exchange the API key for a short-lived access token at the login endpoint, as ZenML servers require. Disable it for servers that accept the API key itself as a bearer token",
+			},
+			"headers": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Description: "Additional headers sent with every request, e.g. headers required by an API gateway in front of the server",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"default_labels": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Description: "Labels added to all stacks and components managed by the provider. Labels set on a resource take precedence",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"strict_decoding": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ZENML_STRICT_DECODING", false),
+				Description: "Fail on server responses with unknown fields or empty required fields, to detect changes of the server's API early",
+			},
+			"compress_requests": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Gzip-encode large request bodies. Only enable it if the server, or a proxy in front of it, decompresses request bodies",
+			},
+			"stack_wait_timeout": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      int(defaultStackWaitTimeout / time.Second),
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Number of seconds to wait for a created stack to become readable, for servers that don't read their own writes immediately",
+			},
+			"stack_wait_interval": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      int(defaultStackWaitInterval / time.Second),
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Number of seconds between attempts to read a created stack",
+			},
+			"request_timeout": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      int(defaultTimeout / time.Second),
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Number of seconds after which API requests time out, including retries, unless operation_timeouts sets a timeout for their operation. 0 disables the timeout",
+			},
+			"circuit_breaker_threshold": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      defaultBreakerThreshold,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Number of requests in a row that must fail to reach the server for the provider to stop sending requests for circuit_breaker_cooldown seconds. 0 disables the circuit breaker",
+			},
+			"circuit_breaker_cooldown": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      int(defaultBreakerCooldown / time.Second),
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Number of seconds the provider stops sending requests once the circuit breaker opened",
+			},
+			"operation_timeouts": {
+				Type:         schema.TypeMap,
+				Optional:     true,
+				Description:  "Number of seconds after which API requests of an operation time out, by operation: read, list, create, update, delete or validate",
+				ValidateFunc: validateOperationTimeouts,
+				Elem: &schema.Schema{
+					Type: schema.TypeInt,
+				},
+			},
 		},
 		ResourcesMap: map[string]*schema.Resource{
 			"zenml_stack":             resourceStack(),
 			"zenml_stack_component":   resourceStackComponent(),
 			"zenml_service_connector": resourceServiceConnector(),
+			"zenml_model":             resourceModel(),
+			"zenml_model_version":     resourceModelVersion(),
+			"zenml_code_repository":   resourceCodeRepository(),
+			"zenml_tag":               resourceTag(),
+			"zenml_project":           resourceProject(),
+			"zenml_event_source":      resourceEventSource(),
+			"zenml_trigger":           resourceTrigger(),
 		},
 		DataSourcesMap: map[string]*schema.Resource{
-			"zenml_server":            dataSourceServer(),
-			"zenml_stack":             dataSourceStack(),
-			"zenml_stack_component":   dataSourceStackComponent(),
-			"zenml_service_connector": dataSourceServiceConnector(),
+			"zenml_server":                      dataSourceServer(),
+			"zenml_stack":                       dataSourceStack(),
+			"zenml_stacks":                      dataSourceStacks(),
+			"zenml_stack_component":             dataSourceStackComponent(),
+			"zenml_stack_component_config_diff": dataSourceStackComponentConfigDiff(),
+			"zenml_service_connector":           dataSourceServiceConnector(),
+			"zenml_service_connector_resources": dataSourceServiceConnectorResources(),
+			"zenml_stack_pipelines":             dataSourceStackPipelines(),
+			"zenml_run":                         dataSourceRun(),
+			"zenml_expiring_service_connectors": dataSourceExpiringServiceConnectors(),
+			"zenml_artifact":                    dataSourceArtifact(),
+			"zenml_artifact_version":            dataSourceArtifactVersion(),
+			"zenml_secret":                      dataSourceSecret(),
+			"zenml_user":                        dataSourceUser(),
+			"zenml_team":                        dataSourceTeam(),
 		},
 		ConfigureContextFunc: providerConfigure,
 	}
@@ -77,14 +184,67 @@ or use the ZENML_API_KEY environment variable to set the API key.`,
 		}
 	}
 
-	client := NewClient(serverURL, apiKey, apiToken)
+	opts := []ClientOption{
+		WithStrictDecoding(d.Get("strict_decoding").(bool)),
+		WithRequestCompression(d.Get("compress_requests").(bool)),
+		WithStackWait(
+			time.Duration(d.Get("stack_wait_timeout").(int))*time.Second,
+			time.Duration(d.Get("stack_wait_interval").(int))*time.Second),
+	}
+	if d.Get("token_auth").(bool) {
+		opts = append(opts, WithTokenAuth())
+	}
+	opts = append(opts, WithTimeout(time.Duration(d.Get("request_timeout").(int))*time.Second))
+	opts = append(opts, WithCircuitBreaker(
+		d.Get("circuit_breaker_threshold").(int),
+		defaultBreakerWindow,
+		time.Duration(d.Get("circuit_breaker_cooldown").(int))*time.Second))
+	for op, v := range d.Get("operation_timeouts").(map[string]interface{}) {
+		if v.(int) < 0 {
+			return nil, diag.Errorf("operation_timeouts: timeout of %s must not be negative", op)
+		}
+		opts = append(opts, WithOperationTimeout(op, time.Duration(v.(int))*time.Second))
+	}
+	for k, v := range d.Get("headers").(map[string]interface{}) {
+		opts = append(opts, WithHeader(k, v.(string)))
+	}
+	if v := d.Get("default_labels").(map[string]interface{}); len(v) > 0 {
+		labels := make(map[string]string, len(v))
+		for k, v := range v {
+			labels[k] = v.(string)
+		}
+		opts = append(opts, WithDefaultLabels(labels))
+	}
+
+	client := NewClient(serverURL, apiKey, apiToken, opts...)
 	if client == nil {
 		return nil, diag.Errorf("failed to create client")
 	}
 
-	// Test the client connection
-	// You might want to add a simple API call here to verify the connection
-	client.GetServerInfo(ctx)
+	if info, err := client.GetServerInfo(ctx); err == nil {
+		client.ConfigurePageSizes(info)
+		client.ServerVersion = info.Version
+	}
+
+	// Fail fast on an unreachable server or bad credentials, instead of on
+	// the first resource
+	if err := client.Ping(ctx); err != nil {
+		return nil, diag.FromErr(err)
+	}
 
 	return client, diags
 }
+
+// validateOperationTimeouts checks the operations of operation_timeouts.
+// Negative timeouts are rejected when configuring the provider, as the
+// values may not be known yet.
+func validateOperationTimeouts(v interface{}, k string) ([]string, []error) {
+	var errs []error
+	for op := range v.(map[string]interface{}) {
+		if _, ok := defaultOperationTimeouts[op]; !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown operation %q, expected one of %s", k, op,
+				strings.Join(slices.Sorted(maps.Keys(defaultOperationTimeouts)), ", ")))
+		}
+	}
+	return nil, errs
+}
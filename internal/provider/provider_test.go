@@ -1,6 +1,8 @@
 package provider
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
@@ -41,3 +43,13 @@ func testAccPreCheck(t *testing.T) {
 		)
 	}
 }
+
+// newTestClient returns a client pointed at an httptest server backed by the
+// given handler. The client is configured with a non-expiring API token so
+// that no login requests are made.
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewClient(server.URL, "", "test-token")
+}
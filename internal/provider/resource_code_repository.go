@@ -0,0 +1,234 @@
+// resource_code_repository.go
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func resourceCodeRepository() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceCodeRepositoryCreate,
+		ReadContext:   resourceCodeRepositoryRead,
+		UpdateContext: resourceCodeRepositoryUpdate,
+		DeleteContext: resourceCodeRepositoryDelete,
+
+		Schema: map[string]*schema.Schema{
+			"workspace": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "default",
+				ForceNew: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"source": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The fully-qualified name of the code repository class",
+				ValidateFunc: func(v interface{}, k string) ([]string, []error) {
+					if _, err := parseSourceSpec(v.(string)); err != nil {
+						return nil, []error{fmt.Errorf("%s: %v", k, err)}
+					}
+					return nil, nil
+				},
+			},
+			"config": {
+				Type:     schema.TypeString,
+				Required: true,
+				// The configuration may contain access tokens
+				Sensitive:        true,
+				Description:      "The code repository configuration as a JSON object",
+				ValidateFunc:     validation.StringIsJSON,
+				DiffSuppressFunc: suppressEquivalentJSON,
+			},
+			"logo_url": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+		},
+
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+	}
+}
+
+// parseSourceSpec splits the fully-qualified name of a Python class into
+// its module and attribute.
+func parseSourceSpec(source string) (SourceSpec, error) {
+	i := strings.LastIndex(source, ".")
+	if i <= 0 || i == len(source)-1 {
+		return SourceSpec{}, fmt.Errorf("expected a fully-qualified class name like module.Class, got %q", source)
+	}
+	return SourceSpec{Module: source[:i], Attribute: source[i+1:]}, nil
+}
+
+func expandCodeRepositoryConfig(d *schema.ResourceData) (map[string]interface{}, error) {
+	config := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(d.Get("config").(string)), &config); err != nil {
+		return nil, fmt.Errorf("error parsing config: %w", err)
+	}
+	return config, nil
+}
+
+func resourceCodeRepositoryCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	// Get the current user
+	user, err := client.GetCurrentUser(ctx)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error getting current user: %w", err))
+	}
+
+	workspaceName := d.Get("workspace").(string)
+
+	// Get the workspace ID
+	workspace, err := client.GetWorkspaceByName(ctx, workspaceName)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error getting workspace: %w", err))
+	}
+	if workspace == nil {
+		return diag.FromErr(fmt.Errorf("workspace not found: %s", workspaceName))
+	}
+
+	source, err := parseSourceSpec(d.Get("source").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	config, err := expandCodeRepositoryConfig(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	repository := CodeRepositoryRequest{
+		User:      user.ID,
+		Workspace: workspace.ID,
+		Name:      d.Get("name").(string),
+		Source:    source,
+		Config:    config,
+	}
+
+	if v, ok := d.GetOk("logo_url"); ok {
+		logoURL := v.(string)
+		repository.LogoURL = &logoURL
+	}
+
+	if v, ok := d.GetOk("description"); ok {
+		description := v.(string)
+		repository.Description = &description
+	}
+
+	resp, err := client.CreateCodeRepository(ctx, repository)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error creating code repository: %w", err))
+	}
+
+	d.SetId(resp.ID)
+	return resourceCodeRepositoryRead(ctx, d, m)
+}
+
+func resourceCodeRepositoryRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	repository, err := client.GetCodeRepository(ctx, d.Id())
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error getting code repository: %w", err))
+	}
+	if repository == nil {
+		// Handle 404 by removing from state
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("name", repository.Name)
+
+	if repository.Body != nil {
+		if repository.Body.Source != nil {
+			d.Set("source", repository.Body.Source.Module+"."+repository.Body.Source.Attribute)
+		}
+		if repository.Body.LogoURL != nil {
+			d.Set("logo_url", *repository.Body.LogoURL)
+		}
+	}
+
+	if repository.Metadata != nil {
+		if repository.Metadata.Workspace != nil && repository.Metadata.Workspace.Name != "default" {
+			d.Set("workspace", repository.Metadata.Workspace.Name)
+		}
+		if repository.Metadata.Config != nil {
+			config, err := encodeJSON(repository.Metadata.Config)
+			if err != nil {
+				return diag.FromErr(fmt.Errorf("error encoding config: %w", err))
+			}
+			d.Set("config", config)
+		}
+		if repository.Metadata.Description != nil {
+			d.Set("description", *repository.Metadata.Description)
+		}
+	}
+
+	return nil
+}
+
+func resourceCodeRepositoryUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	update := CodeRepositoryUpdate{}
+
+	if d.HasChange("name") {
+		name := d.Get("name").(string)
+		update.Name = &name
+	}
+
+	if d.HasChange("config") {
+		config, err := expandCodeRepositoryConfig(d)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		update.Config = config
+	}
+
+	if d.HasChange("logo_url") {
+		logoURL := d.Get("logo_url").(string)
+		update.LogoURL = &logoURL
+	}
+
+	if d.HasChange("description") {
+		description := d.Get("description").(string)
+		update.Description = &description
+	}
+
+	_, err := client.UpdateCodeRepository(ctx, d.Id(), update)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error updating code repository: %w", err))
+	}
+
+	return resourceCodeRepositoryRead(ctx, d, m)
+}
+
+func resourceCodeRepositoryDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	err := client.DeleteCodeRepository(ctx, d.Id())
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error deleting code repository: %w", err))
+	}
+
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,70 @@
+// internal/provider/resource_code_repository_test.go
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestParseSourceSpec(t *testing.T) {
+	source, err := parseSourceSpec("zenml.integrations.github.code_repositories.GitHubCodeRepository")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if source.Module != "zenml.integrations.github.code_repositories" || source.Attribute != "GitHubCodeRepository" {
+		t.Errorf("unexpected source %+v", source)
+	}
+
+	for _, invalid := range []string{"", "GitHubCodeRepository", ".Class", "module."} {
+		if _, err := parseSourceSpec(invalid); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestResourceCodeRepositoryRead(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/code_repositories/repo-id" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(CodeRepositoryResponse{
+			ID:   "repo-id",
+			Name: "repo",
+			Body: &CodeRepositoryResponseBody{
+				Source: &SourceSpec{Module: "zenml.integrations.github.code_repositories", Attribute: "GitHubCodeRepository"},
+			},
+			Metadata: &CodeRepositoryResponseMetadata{
+				Config: map[string]interface{}{"owner": "zenml-io", "repository": "zenml"},
+			},
+		})
+	}))
+
+	resource := resourceCodeRepository()
+	d := schema.TestResourceDataRaw(t, resource.Schema, map[string]interface{}{
+		"config": `{"repository": "zenml", "owner": "zenml-io"}`,
+	})
+	d.SetId("repo-id")
+
+	if diags := resourceCodeRepositoryRead(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if got := d.Get("source").(string); got != "zenml.integrations.github.code_repositories.GitHubCodeRepository" {
+		t.Errorf("unexpected source %q", got)
+	}
+	if !suppressEquivalentJSON("config", `{"repository": "zenml", "owner": "zenml-io"}`, d.Get("config").(string), d) {
+		t.Errorf("expected the read config to be equivalent, got %s", d.Get("config"))
+	}
+
+	d.SetId("missing")
+	if diags := resourceCodeRepositoryRead(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if d.Id() != "" {
+		t.Errorf("expected a missing code repository to be removed from state")
+	}
+}
@@ -0,0 +1,249 @@
+// resource_event_source.go
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func resourceEventSource() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceEventSourceCreate,
+		ReadContext:   resourceEventSourceRead,
+		UpdateContext: resourceEventSourceUpdate,
+		DeleteContext: resourceEventSourceDelete,
+
+		Schema: map[string]*schema.Schema{
+			"workspace": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "default",
+				ForceNew: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"flavor": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The flavor of the event source, e.g. 'github'",
+			},
+			"plugin_subtype": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "webhook",
+				ForceNew:    true,
+				Description: "The subtype of the event source plugin",
+			},
+			"configuration": {
+				Type:     schema.TypeString,
+				Required: true,
+				// The configuration may contain webhook secrets
+				Sensitive:        true,
+				Description:      "The event source configuration as a JSON object",
+				ValidateFunc:     validation.StringIsJSON,
+				DiffSuppressFunc: suppressEquivalentJSON,
+			},
+			"is_active": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+			"created": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+	}
+}
+
+// expandJSONObject parses a JSON object attribute. Unset attributes are
+// returned as nil.
+func expandJSONObject(d *schema.ResourceData, key string) (map[string]interface{}, error) {
+	value := d.Get(key).(string)
+	if value == "" {
+		return nil, nil
+	}
+	object := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(value), &object); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", key, err)
+	}
+	return object, nil
+}
+
+// flattenJSONObject sets a JSON object attribute from the value read from
+// the server.
+func flattenJSONObject(d *schema.ResourceData, key string, object map[string]interface{}) error {
+	if object == nil {
+		return nil
+	}
+	encoded, err := encodeJSON(object)
+	if err != nil {
+		return fmt.Errorf("error encoding %s: %w", key, err)
+	}
+	return d.Set(key, encoded)
+}
+
+func resourceEventSourceCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	// Get the current user
+	user, err := client.GetCurrentUser(ctx)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error getting current user: %w", err))
+	}
+
+	workspaceName := d.Get("workspace").(string)
+
+	// Get the workspace ID
+	workspace, err := client.GetWorkspaceByName(ctx, workspaceName)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error getting workspace: %w", err))
+	}
+	if workspace == nil {
+		return diag.FromErr(fmt.Errorf("workspace not found: %s", workspaceName))
+	}
+
+	configuration, err := expandJSONObject(d, "configuration")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	source := EventSourceRequest{
+		User:          user.ID,
+		Workspace:     workspace.ID,
+		Name:          d.Get("name").(string),
+		Description:   d.Get("description").(string),
+		Flavor:        d.Get("flavor").(string),
+		PluginSubtype: d.Get("plugin_subtype").(string),
+		Configuration: configuration,
+	}
+
+	resp, err := client.CreateEventSource(ctx, source)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error creating event source: %w", err))
+	}
+
+	d.SetId(resp.ID)
+
+	// Event sources are created active
+	if !d.Get("is_active").(bool) {
+		isActive := false
+		if _, err := client.UpdateEventSource(ctx, resp.ID, EventSourceUpdate{IsActive: &isActive}); err != nil {
+			return diag.FromErr(fmt.Errorf("error deactivating event source: %w", err))
+		}
+	}
+
+	return resourceEventSourceRead(ctx, d, m)
+}
+
+func resourceEventSourceRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	source, err := client.GetEventSource(ctx, d.Id())
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error getting event source: %w", err))
+	}
+	if source == nil {
+		// Handle 404 by removing from state
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("name", source.Name)
+
+	if source.Body != nil {
+		d.Set("flavor", source.Body.Flavor)
+		d.Set("plugin_subtype", source.Body.PluginSubtype)
+		d.Set("is_active", source.Body.IsActive)
+		d.Set("created", source.Body.Created)
+	}
+
+	if source.Metadata != nil {
+		if source.Metadata.Workspace != nil && source.Metadata.Workspace.Name != "default" {
+			d.Set("workspace", source.Metadata.Workspace.Name)
+		}
+		d.Set("description", source.Metadata.Description)
+		if err := flattenJSONObject(d, "configuration", source.Metadata.Configuration); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	return nil
+}
+
+func resourceEventSourceUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	update := EventSourceUpdate{}
+
+	if d.HasChange("name") {
+		name := d.Get("name").(string)
+		update.Name = &name
+	}
+
+	if d.HasChange("description") {
+		description := d.Get("description").(string)
+		update.Description = &description
+	}
+
+	if d.HasChange("configuration") {
+		configuration, err := expandJSONObject(d, "configuration")
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		update.Configuration = configuration
+	}
+
+	if d.HasChange("is_active") {
+		isActive := d.Get("is_active").(bool)
+		update.IsActive = &isActive
+	}
+
+	_, err := client.UpdateEventSource(ctx, d.Id(), update)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error updating event source: %w", err))
+	}
+
+	return resourceEventSourceRead(ctx, d, m)
+}
+
+func resourceEventSourceDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	triggers, err := client.ListTriggersByEventSource(ctx, d.Id())
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error listing triggers of the event source: %w", err))
+	}
+	if len(triggers) > 0 {
+		names := make([]string, 0, len(triggers))
+		for _, trigger := range triggers {
+			names = append(names, trigger.Name)
+		}
+		return inUseDiagnostics("Event source", "trigger", names,
+			"Delete these triggers first, or reference the event source from their zenml_trigger resources so that Terraform deletes them before it.")
+	}
+
+	err = client.DeleteEventSource(ctx, d.Id())
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error deleting event source: %w", err))
+	}
+
+	d.SetId("")
+	return nil
+}
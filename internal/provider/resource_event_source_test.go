@@ -0,0 +1,53 @@
+// internal/provider/resource_event_source_test.go
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestResourceEventSourceDelete(t *testing.T) {
+	triggers := []TriggerResponse{{ID: "trigger-id", Name: "on-push"}}
+	deleted := false
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v1/triggers":
+			if got := r.URL.Query().Get("event_source_id"); got != "source-id" {
+				t.Errorf("expected triggers to be filtered by event source, got %q", got)
+			}
+			json.NewEncoder(w).Encode(Page[TriggerResponse]{Index: 1, TotalPages: 1, Total: len(triggers), Items: triggers})
+		case r.Method == "DELETE" && r.URL.Path == "/api/v1/event_sources/source-id":
+			deleted = true
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceEventSource().Schema, map[string]interface{}{
+		"name":          "github",
+		"flavor":        "github",
+		"configuration": "{}",
+	})
+	d.SetId("source-id")
+
+	diags := resourceEventSourceDelete(context.Background(), d, client)
+	if len(diags) != 1 || !strings.Contains(diags[0].Detail, "on-push") {
+		t.Fatalf("expected a diagnostic naming the trigger, got %v", diags)
+	}
+	if deleted {
+		t.Error("expected the event source not to be deleted")
+	}
+
+	triggers = nil
+	if diags := resourceEventSourceDelete(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if !deleted {
+		t.Error("expected the event source to be deleted")
+	}
+}
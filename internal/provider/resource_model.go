@@ -0,0 +1,195 @@
+// resource_model.go
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceModel() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceModelCreate,
+		ReadContext:   resourceModelRead,
+		UpdateContext: resourceModelUpdate,
+		DeleteContext: resourceModelDelete,
+
+		Schema: map[string]*schema.Schema{
+			"workspace": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "default",
+				ForceNew: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				// Models are identified by name and cannot be renamed
+				ForceNew: true,
+			},
+			"license": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"tags": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+		},
+
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+	}
+}
+
+func resourceModelCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	// Get the current user
+	user, err := client.GetCurrentUser(ctx)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error getting current user: %w", err))
+	}
+
+	workspaceName := d.Get("workspace").(string)
+
+	// Get the workspace ID
+	workspace, err := client.GetWorkspaceByName(ctx, workspaceName)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error getting workspace: %w", err))
+	}
+	if workspace == nil {
+		return diag.FromErr(fmt.Errorf("workspace not found: %s", workspaceName))
+	}
+
+	model := ModelRequest{
+		User:      user.ID,
+		Workspace: workspace.ID,
+		Name:      d.Get("name").(string),
+	}
+
+	if v, ok := d.GetOk("license"); ok {
+		license := v.(string)
+		model.License = &license
+	}
+
+	if v, ok := d.GetOk("description"); ok {
+		description := v.(string)
+		model.Description = &description
+	}
+
+	if v, ok := d.GetOk("tags"); ok {
+		for _, tag := range v.(*schema.Set).List() {
+			model.Tags = append(model.Tags, tag.(string))
+		}
+	}
+
+	resp, err := client.CreateModel(ctx, model)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error creating model: %w", err))
+	}
+
+	d.SetId(resp.ID)
+	return resourceModelRead(ctx, d, m)
+}
+
+func resourceModelRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	model, err := client.GetModel(ctx, d.Id())
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error getting model: %w", err))
+	}
+	if model == nil {
+		// Handle 404 by removing from state
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("name", model.Name)
+
+	if model.Body != nil {
+		tags := make([]string, 0, len(model.Body.Tags))
+		for _, tag := range model.Body.Tags {
+			tags = append(tags, tag.Name)
+		}
+		d.Set("tags", tags)
+	}
+
+	if model.Metadata != nil {
+		if model.Metadata.Workspace != nil && model.Metadata.Workspace.Name != "default" {
+			d.Set("workspace", model.Metadata.Workspace.Name)
+		}
+		// Values cleared on the server are cleared in the state as well
+		license, description := "", ""
+		if model.Metadata.License != nil {
+			license = *model.Metadata.License
+		}
+		if model.Metadata.Description != nil {
+			description = *model.Metadata.Description
+		}
+		d.Set("license", license)
+		d.Set("description", description)
+	}
+
+	return nil
+}
+
+func resourceModelUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	update := ModelUpdate{}
+
+	if d.HasChange("license") {
+		license := d.Get("license").(string)
+		update.License = &license
+	}
+
+	if d.HasChange("description") {
+		description := d.Get("description").(string)
+		update.Description = &description
+	}
+
+	// Tags are attached and detached individually, so we only send the
+	// difference between the current and the desired tags
+	if d.HasChange("tags") {
+		o, n := d.GetChange("tags")
+		oldTags := o.(*schema.Set)
+		newTags := n.(*schema.Set)
+		for _, tag := range newTags.Difference(oldTags).List() {
+			update.AddTags = append(update.AddTags, tag.(string))
+		}
+		for _, tag := range oldTags.Difference(newTags).List() {
+			update.RemoveTags = append(update.RemoveTags, tag.(string))
+		}
+	}
+
+	_, err := client.UpdateModel(ctx, d.Id(), update)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error updating model: %w", err))
+	}
+
+	return resourceModelRead(ctx, d, m)
+}
+
+func resourceModelDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	err := client.DeleteModel(ctx, d.Id())
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error deleting model: %w", err))
+	}
+
+	d.SetId("")
+	return nil
+}
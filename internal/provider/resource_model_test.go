@@ -0,0 +1,99 @@
+// internal/provider/resource_model_test.go
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccModel_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckModelDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccModelConfig("staging"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"zenml_model.test", "name", "test-model"),
+					resource.TestCheckResourceAttr(
+						"zenml_model.test", "license", "Apache 2.0"),
+					resource.TestCheckResourceAttr(
+						"zenml_model_version.test", "stage", "staging"),
+				),
+			},
+			{
+				Config: testAccModelConfig("production"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"zenml_model_version.test", "stage", "production"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckModelDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*Client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "zenml_model" {
+			continue
+		}
+
+		model, err := client.GetModel(context.Background(), rs.Primary.ID)
+		if err == nil && model != nil {
+			return fmt.Errorf("Model still exists")
+		}
+	}
+
+	return nil
+}
+
+func testAccModelConfig(stage string) string {
+	return fmt.Sprintf(`
+resource "zenml_model" "test" {
+	name        = "test-model"
+	license     = "Apache 2.0"
+	description = "Test model"
+	tags        = ["test"]
+}
+
+resource "zenml_model_version" "test" {
+	model_id = zenml_model.test.id
+	name     = "v1"
+	stage    = "%s"
+}
+`, stage)
+}
+
+func TestResourceModelReadClearedValues(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ModelResponse{
+			ID:       "model-id",
+			Name:     "classifier",
+			Metadata: &ModelResponseMetadata{Workspace: &WorkspaceResponse{Name: "default"}},
+		})
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceModel().Schema, map[string]interface{}{
+		"name":        "classifier",
+		"license":     "Apache-2.0",
+		"description": "Classifies things",
+	})
+	d.SetId("model-id")
+	if diags := resourceModelRead(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if license, description := d.Get("license"), d.Get("description"); license != "" || description != "" {
+		t.Errorf("expected the values cleared on the server to be cleared, got %q and %q", license, description)
+	}
+}
@@ -0,0 +1,206 @@
+// resource_model_version.go
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func resourceModelVersion() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceModelVersionCreate,
+		ReadContext:   resourceModelVersionRead,
+		UpdateContext: resourceModelVersionUpdate,
+		DeleteContext: resourceModelVersionDelete,
+
+		Schema: map[string]*schema.Schema{
+			"workspace": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "default",
+				ForceNew: true,
+			},
+			"model_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				// Versions cannot be moved between models
+				ForceNew: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Optional: true,
+				// The server names versions after their number by default
+				Computed: true,
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"stage": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "none",
+				ValidateFunc: validation.StringInSlice(validModelStages, false),
+			},
+			"force_stage": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Move the version to its stage even if another version of the model already occupies it, demoting that version",
+			},
+			"number": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+		},
+
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+	}
+}
+
+func resourceModelVersionCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	// Get the current user
+	user, err := client.GetCurrentUser(ctx)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error getting current user: %w", err))
+	}
+
+	workspaceName := d.Get("workspace").(string)
+
+	// Get the workspace ID
+	workspace, err := client.GetWorkspaceByName(ctx, workspaceName)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error getting workspace: %w", err))
+	}
+	if workspace == nil {
+		return diag.FromErr(fmt.Errorf("workspace not found: %s", workspaceName))
+	}
+
+	version := ModelVersionRequest{
+		User:      user.ID,
+		Workspace: workspace.ID,
+		Model:     d.Get("model_id").(string),
+	}
+
+	if v, ok := d.GetOk("name"); ok {
+		name := v.(string)
+		version.Name = &name
+	}
+
+	if v, ok := d.GetOk("description"); ok {
+		description := v.(string)
+		version.Description = &description
+	}
+
+	resp, err := client.CreateModelVersion(ctx, version)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error creating model version: %w", err))
+	}
+
+	d.SetId(resp.ID)
+
+	// New versions always start without a stage, so transition them to the
+	// desired stage separately
+	if stage := d.Get("stage").(string); stage != "none" {
+		update := ModelVersionUpdate{
+			Model: version.Model,
+			Stage: &stage,
+			Force: d.Get("force_stage").(bool),
+		}
+		if _, err := client.UpdateModelVersion(ctx, resp.ID, update); err != nil {
+			return diag.FromErr(fmt.Errorf("error setting model version stage: %w", err))
+		}
+	}
+
+	return resourceModelVersionRead(ctx, d, m)
+}
+
+func resourceModelVersionRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	version, err := client.GetModelVersion(ctx, d.Id())
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error getting model version: %w", err))
+	}
+	if version == nil {
+		// Handle 404 by removing from state
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("name", version.Name)
+
+	if version.Body != nil {
+		d.Set("number", version.Body.Number)
+		if version.Body.Model != nil {
+			d.Set("model_id", version.Body.Model.ID)
+		}
+		if version.Body.Stage != nil {
+			d.Set("stage", *version.Body.Stage)
+		} else {
+			d.Set("stage", "none")
+		}
+	}
+
+	if version.Metadata != nil {
+		if version.Metadata.Workspace != nil && version.Metadata.Workspace.Name != "default" {
+			d.Set("workspace", version.Metadata.Workspace.Name)
+		}
+		if version.Metadata.Description != nil {
+			d.Set("description", *version.Metadata.Description)
+		}
+	}
+
+	return nil
+}
+
+func resourceModelVersionUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	update := ModelVersionUpdate{
+		Model: d.Get("model_id").(string),
+		Force: d.Get("force_stage").(bool),
+	}
+
+	if d.HasChange("name") {
+		name := d.Get("name").(string)
+		update.Name = &name
+	}
+
+	if d.HasChange("description") {
+		description := d.Get("description").(string)
+		update.Description = &description
+	}
+
+	if d.HasChange("stage") {
+		stage := d.Get("stage").(string)
+		update.Stage = &stage
+	}
+
+	_, err := client.UpdateModelVersion(ctx, d.Id(), update)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error updating model version: %w", err))
+	}
+
+	return resourceModelVersionRead(ctx, d, m)
+}
+
+func resourceModelVersionDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	err := client.DeleteModelVersion(ctx, d.Id())
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error deleting model version: %w", err))
+	}
+
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,135 @@
+// resource_project.go
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceProject() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceProjectCreate,
+		ReadContext:   resourceProjectRead,
+		UpdateContext: resourceProjectUpdate,
+		DeleteContext: resourceProjectDelete,
+		CustomizeDiff: requireEndpoint("projects"),
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				// Other entities reference projects by name
+				ForceNew: true,
+			},
+			"display_name": {
+				Type:     schema.TypeString,
+				Optional: true,
+				// The server defaults the display name to the name
+				Computed: true,
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"created": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+	}
+}
+
+func resourceProjectCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	project := ProjectRequest{
+		Name: d.Get("name").(string),
+	}
+
+	if v, ok := d.GetOk("display_name"); ok {
+		displayName := v.(string)
+		project.DisplayName = &displayName
+	}
+
+	if v, ok := d.GetOk("description"); ok {
+		description := v.(string)
+		project.Description = &description
+	}
+
+	resp, err := client.CreateProject(ctx, project)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error creating project: %w", err))
+	}
+
+	d.SetId(resp.ID)
+	return resourceProjectRead(ctx, d, m)
+}
+
+func resourceProjectRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	project, err := client.GetProject(ctx, d.Id())
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error getting project: %w", err))
+	}
+	if project == nil {
+		// Handle 404 by removing from state
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("name", project.Name)
+
+	if project.Body != nil {
+		d.Set("display_name", project.Body.DisplayName)
+		d.Set("created", project.Body.Created)
+	}
+
+	if project.Metadata != nil {
+		d.Set("description", project.Metadata.Description)
+	}
+
+	return nil
+}
+
+func resourceProjectUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	update := ProjectUpdate{}
+
+	if d.HasChange("display_name") {
+		displayName := d.Get("display_name").(string)
+		update.DisplayName = &displayName
+	}
+
+	if d.HasChange("description") {
+		description := d.Get("description").(string)
+		update.Description = &description
+	}
+
+	_, err := client.UpdateProject(ctx, d.Id(), update)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error updating project: %w", err))
+	}
+
+	return resourceProjectRead(ctx, d, m)
+}
+
+func resourceProjectDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	err := client.DeleteProject(ctx, d.Id())
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error deleting project: %w", err))
+	}
+
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,49 @@
+// internal/provider/resource_project_test.go
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestResourceProjectCreate(t *testing.T) {
+	var created ProjectRequest
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/api/v1/projects":
+			json.NewDecoder(r.Body).Decode(&created)
+			json.NewEncoder(w).Encode(ProjectResponse{ID: "project-id", Name: created.Name})
+		case r.Method == "GET" && r.URL.Path == "/api/v1/projects/project-id":
+			json.NewEncoder(w).Encode(ProjectResponse{
+				ID:       "project-id",
+				Name:     "ml-platform",
+				Body:     &ProjectResponseBody{DisplayName: "ml-platform"},
+				Metadata: &ProjectResponseMetadata{Description: "Platform team"},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceProject().Schema, map[string]interface{}{
+		"name":        "ml-platform",
+		"description": "Platform team",
+	})
+	if diags := resourceProjectCreate(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	if created.Name != "ml-platform" || created.DisplayName != nil || created.Description == nil || *created.Description != "Platform team" {
+		t.Errorf("unexpected project request %+v", created)
+	}
+	if d.Id() != "project-id" {
+		t.Errorf("expected the project ID to be set, got %q", d.Id())
+	}
+	if got := d.Get("display_name").(string); got != "ml-platform" {
+		t.Errorf("expected the display name defaulted by the server to be read, got %q", got)
+	}
+}
@@ -4,6 +4,7 @@ package provider
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -53,6 +54,9 @@ func resourceServiceConnector() *schema.Resource {
 				Elem: &schema.Schema{
 					Type: schema.TypeString,
 				},
+				// Values that are JSON objects or lists, e.g. set with
+				// jsonencode, are read back in canonical form
+				DiffSuppressFunc: suppressEquivalentJSON,
 			},
 			"user": {
 				Type:     schema.TypeString,
@@ -72,6 +76,35 @@ func resourceServiceConnector() *schema.Resource {
 					Type: schema.TypeString,
 				},
 			},
+			"adopt_existing": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				Description: "If a service connector with the same name already exists in the workspace, " +
+					"manage it with this resource instead of creating a new one",
+			},
+			"verify_after_update": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Verify the service connector after an update and restore the previous configuration if the verification fails",
+			},
+			"expires_at": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "When the credentials of the service connector expire, as an RFC 3339 timestamp in UTC. Empty for credentials that don't expire",
+			},
+			"expiration_seconds": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Lifetime in seconds of the temporary credentials issued by the service connector, or 0",
+			},
+			"expiry_warning_window": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateDuration,
+				Description:  "Warn when the credentials of the service connector expire within this duration, e.g. \"72h\"",
+			},
 		},
 
 		CustomizeDiff: func(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
@@ -117,11 +150,7 @@ func getConnectorRequest(ctx context.Context, d *schema.ResourceData, client *Cl
 
 	// Handle configuration
 	if v, ok := d.GetOk("configuration"); ok {
-		configMap := make(map[string]interface{})
-		for k, v := range v.(map[string]interface{}) {
-			configMap[k] = v
-		}
-		connector.Configuration = configMap
+		connector.Configuration = v.(map[string]interface{})
 	}
 
 	// Handle resource type
@@ -160,6 +189,18 @@ func resourceServiceConnectorCreate(ctx context.Context, d *schema.ResourceData,
 		return diag.FromErr(err)
 	}
 
+	// Only adopt existing connectors when explicitly asked to, so we don't
+	// accidentally take over connectors we shouldn't manage
+	if d.Get("adopt_existing").(bool) {
+		existing, err := client.GetServiceConnectorByName(ctx, d.Get("workspace").(string), connector.Name)
+		if err == nil {
+			return resourceServiceConnectorAdopt(ctx, d, m, existing)
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return diag.FromErr(fmt.Errorf("error looking up existing service connector: %w", err))
+		}
+	}
+
 	err = retry.RetryContext(ctx, d.Timeout(schema.TimeoutCreate)-time.Minute, func() *retry.RetryError {
 		verify, err := client.VerifyServiceConnector(ctx, *connector)
 		if err != nil {
@@ -188,6 +229,23 @@ func resourceServiceConnectorCreate(ctx context.Context, d *schema.ResourceData,
 	return resourceServiceConnectorRead(ctx, d, m)
 }
 
+// resourceServiceConnectorAdopt manages an existing service connector with
+// the resource and brings it in line with the configuration. The type and
+// authentication method can't be updated, so they must match.
+func resourceServiceConnectorAdopt(ctx context.Context, d *schema.ResourceData, m interface{}, existing *ServiceConnectorResponse) diag.Diagnostics {
+	if existing.Body != nil {
+		connectorType, authMethod := existing.Body.ConnectorTypeName(), existing.Body.AuthMethod
+		if connectorType != d.Get("type").(string) || authMethod != d.Get("auth_method").(string) {
+			return diag.Errorf(
+				"the existing service connector %s has type %s and authentication method %s, which don't match the configuration",
+				existing.Name, connectorType, authMethod)
+		}
+	}
+
+	d.SetId(existing.ID)
+	return resourceServiceConnectorUpdate(ctx, d, m)
+}
+
 func resourceServiceConnectorRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	client := m.(*Client)
 
@@ -238,15 +296,29 @@ func resourceServiceConnectorRead(ctx context.Context, d *schema.ResourceData, m
 		d.Set("user", connector.Body.User.Name)
 	}
 
+	diags := setConnectorExpiry(d, connector)
+	if diags.HasError() {
+		return diags
+	}
+
 	if connector.Metadata != nil {
 		if connector.Metadata.Workspace.Name != "default" {
 			d.Set("workspace", connector.Metadata.Workspace.Name)
 		}
-		d.Set("configuration", connector.Metadata.Configuration)
-		d.Set("labels", connector.Metadata.Labels)
+		configuration := flattenConfiguration(connector.Metadata.Configuration)
+		// The server masks the values of secrets: keep the values from the
+		// state, so that updates only change the secrets that changed
+		current := d.Get("configuration").(map[string]interface{})
+		for key := range connector.Metadata.Secrets {
+			if value, ok := current[key]; ok {
+				configuration[key] = value
+			}
+		}
+		d.Set("configuration", configuration)
+		d.Set("labels", client.withoutDefaultLabels(connector.Metadata.Labels, d.Get("labels").(map[string]interface{})))
 	}
 
-	return nil
+	return diags
 }
 
 func resourceServiceConnectorUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
@@ -258,6 +330,22 @@ func resourceServiceConnectorUpdate(ctx context.Context, d *schema.ResourceData,
 		return diag.FromErr(err)
 	}
 
+	// Secrets are sent merged with the values stored on the server, so that
+	// only the secrets changed in the configuration are modified. They are
+	// only read when the configuration changes, so that users who can't
+	// read secrets can still rename a connector or change its labels.
+	var configuration map[string]interface{}
+	var secrets map[string]string
+	if d.HasChange("configuration") {
+		stored, err := client.GetServiceConnectorSecrets(ctx, d.Id())
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("error getting service connector secrets: %w", err))
+		}
+		o, n := d.GetChange("configuration")
+		configuration, secrets = serviceConnectorConfigurationUpdate(
+			stored, o.(map[string]interface{}), n.(map[string]interface{}))
+	}
+
 	err = retry.RetryContext(ctx, d.Timeout(schema.TimeoutCreate)-time.Minute, func() *retry.RetryError {
 		resources, err := client.VerifyServiceConnector(ctx, *connector)
 		if err != nil {
@@ -278,28 +366,22 @@ func resourceServiceConnectorUpdate(ctx context.Context, d *schema.ResourceData,
 		// The `configuration` field represents a full valid configuration update,
 		// not just a partial update. If it is set (i.e. not None) in the update,
 		// the value will replace the existing configuration value. For this
-		// reason, we always include the configuration in the update request.
+		// reason, we include the whole configuration whenever it changes.
 
 		// Handle configuration
-		configMap := make(map[string]interface{})
-		if v, ok := d.GetOk("configuration"); ok {
-			for k, v := range v.(map[string]interface{}) {
-				configMap[k] = v
-			}
-		}
-		update.Configuration = configMap
+		update.Configuration = configuration
+		update.Secrets = secrets
 
 		// The `labels` field is also a full labels update: if set (i.e. not
 		// `None`), all existing labels are removed and replaced by the new labels
-		// in the update.
-
-		labelsMap := make(map[string]string)
+		// in the update. An empty map removes the last label.
 		if d.HasChange("labels") {
+			labelsMap := make(map[string]string)
 			for k, v := range d.Get("labels").(map[string]interface{}) {
 				labelsMap[k] = v.(string)
 			}
+			update.Labels = labelsMap
 		}
-		update.Labels = labelsMap
 
 		// The `resource_id` field value is also a full replacement value: if not
 		// set in the request, the resource ID is removed from the service
@@ -320,7 +402,11 @@ func resourceServiceConnectorUpdate(ctx context.Context, d *schema.ResourceData,
 			update.ResourceTypes = []string{}
 		}
 
-		_, err = client.UpdateServiceConnector(ctx, d.Id(), update)
+		if d.Get("verify_after_update").(bool) {
+			_, err = client.UpdateAndVerifyServiceConnector(ctx, d.Id(), update)
+		} else {
+			_, err = client.UpdateServiceConnector(ctx, d.Id(), update)
+		}
 		if err != nil {
 			return retry.NonRetryableError(fmt.Errorf("Error updating service connector: %s", err))
 		}
@@ -335,14 +421,77 @@ func resourceServiceConnectorUpdate(ctx context.Context, d *schema.ResourceData,
 	return resourceServiceConnectorRead(ctx, d, m)
 }
 
+// serviceConnectorConfigurationUpdate splits the configuration of a service
+// connector update into the values sent as configuration and the secrets,
+// given the secrets stored on the server. The configuration replaces the
+// stored one, so it holds all values that aren't secrets. The secrets are
+// the stored ones, with the values that changed from old to new in the
+// configuration replaced and the keys removed from it deleted: secrets that
+// didn't change keep their stored value, even if it isn't the value in the
+// state, e.g. after a rotation outside of Terraform. Keys added to the
+// configuration that hold credentials are added to the secrets.
+func serviceConnectorConfigurationUpdate(stored map[string]string, old, new map[string]interface{}) (map[string]interface{}, map[string]string) {
+	configuration := map[string]interface{}{}
+	added := map[string]string{}
+	for key, value := range new {
+		if _, ok := stored[key]; ok {
+			continue
+		}
+		if _, ok := old[key]; !ok && isCredentialKey(key) {
+			added[key] = value.(string)
+			continue
+		}
+		configuration[key] = value
+	}
+
+	if len(stored) == 0 && len(added) == 0 {
+		return configuration, nil
+	}
+	secrets := added
+	for key, value := range stored {
+		newValue, ok := new[key]
+		if !ok {
+			continue
+		}
+		if oldValue, ok := old[key]; !ok || oldValue != newValue {
+			value = newValue.(string)
+		}
+		secrets[key] = value
+	}
+	return configuration, secrets
+}
+
 func resourceServiceConnectorDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	client := m.(*Client)
 
 	err := client.DeleteServiceConnector(ctx, d.Id())
 	if err != nil {
+		// The server refuses to delete connectors that components still
+		// use, without naming them
+		if components, listErr := client.ListComponentsByConnector(ctx, d.Id()); listErr == nil && len(components) > 0 {
+			return connectorInUseDiagnostics(d.Get("name").(string), components, err)
+		}
 		return diag.FromErr(fmt.Errorf("error deleting service connector: %s", err))
 	}
 
 	d.SetId("")
 	return nil
 }
+
+// connectorInUseDiagnostics reports a service connector that can't be
+// deleted because components use it, naming the components.
+func connectorInUseDiagnostics(name string, components []ComponentResponse, err error) diag.Diagnostics {
+	names := make([]string, len(components))
+	for i, component := range components {
+		names[i] = component.Name
+		if component.Body != nil && component.Body.Type != "" {
+			names[i] = fmt.Sprintf("%s (%s)", component.Name, component.Body.Type)
+		}
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  fmt.Sprintf("Service connector %q is used by %s", name, pluralize(len(components), "component")),
+		Detail: fmt.Sprintf("The service connector can't be deleted while components use it: %s. "+
+			"Remove the connector from these components or delete them first. The server responded: %v", listItems(names), err),
+	}}
+}
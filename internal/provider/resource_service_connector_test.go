@@ -3,11 +3,18 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
@@ -169,3 +176,446 @@ resource "zenml_service_connector" "test" {
 }
 `, workspace)
 }
+
+func TestServiceConnectorConfigurationUpdate(t *testing.T) {
+	stored := map[string]string{
+		"aws_access_key_id":     "stored-id",
+		"aws_secret_access_key": "stored-key",
+		"aws_session_token":     "stored-token",
+	}
+	old := map[string]interface{}{
+		"region":                "eu-west-1",
+		"aws_access_key_id":     "state-id",
+		"aws_secret_access_key": "old-key",
+		"aws_session_token":     "stored-token",
+	}
+	new := map[string]interface{}{
+		"region":                "eu-west-1",
+		"aws_access_key_id":     "state-id",
+		"aws_secret_access_key": "new-key",
+	}
+
+	configuration, secrets := serviceConnectorConfigurationUpdate(stored, old, new)
+	if expected := map[string]interface{}{"region": "eu-west-1"}; !reflect.DeepEqual(configuration, expected) {
+		t.Errorf("expected configuration %v, got %v", expected, configuration)
+	}
+	// The unchanged secret keeps its stored value, the changed one is
+	// replaced and the removed one deleted
+	expected := map[string]string{
+		"aws_access_key_id":     "stored-id",
+		"aws_secret_access_key": "new-key",
+	}
+	if !reflect.DeepEqual(secrets, expected) {
+		t.Errorf("expected secrets %v, got %v", expected, secrets)
+	}
+
+	if _, secrets := serviceConnectorConfigurationUpdate(nil, old, new); secrets != nil {
+		t.Errorf("expected no secrets for a connector without secrets, got %v", secrets)
+	}
+
+	// Added keys that hold credentials are sent as secrets
+	new["aws_session_token"] = "new-token"
+	new["role_name"] = "deploy"
+	configuration, secrets = serviceConnectorConfigurationUpdate(nil, map[string]interface{}{"region": "eu-west-1"}, new)
+	if expected := map[string]interface{}{"region": "eu-west-1", "role_name": "deploy"}; !reflect.DeepEqual(configuration, expected) {
+		t.Errorf("expected configuration %v, got %v", expected, configuration)
+	}
+	expected = map[string]string{
+		"aws_access_key_id":     "state-id",
+		"aws_secret_access_key": "new-key",
+		"aws_session_token":     "new-token",
+	}
+	if !reflect.DeepEqual(secrets, expected) {
+		t.Errorf("expected the added credentials as secrets %v, got %v", expected, secrets)
+	}
+}
+
+func TestResourceServiceConnectorUpdateKeepsSecrets(t *testing.T) {
+	var update ServiceConnectorUpdate
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v1/current-user":
+			json.NewEncoder(w).Encode(UserResponse{ID: "user"})
+		case r.Method == "GET" && r.URL.Path == "/api/v1/workspaces/default":
+			json.NewEncoder(w).Encode(WorkspaceResponse{ID: "workspace", Name: "default"})
+		case r.Method == "POST" && r.URL.Path == "/api/v1/service_connectors/verify":
+			json.NewEncoder(w).Encode(ServiceConnectorResources{})
+		case r.Method == "PUT" && r.URL.Path == "/api/v1/service_connectors/connector":
+			json.NewDecoder(r.Body).Decode(&update)
+			json.NewEncoder(w).Encode(ServiceConnectorResponse{ID: "connector"})
+		case r.Method == "GET" && r.URL.Path == "/api/v1/service_connectors/connector":
+			secret := "**********"
+			if r.URL.Query().Get("expand_secrets") == "true" {
+				secret = "stored-key"
+			}
+			json.NewEncoder(w).Encode(ServiceConnectorResponse{
+				ID:   "connector",
+				Name: "aws",
+				Body: &ServiceConnectorResponseBody{
+					ConnectorType: json.RawMessage(`"aws"`),
+					AuthMethod:    "secret-key",
+					User:          &UserResponse{Name: "user"},
+				},
+				Metadata: &ServiceConnectorResponseMetadata{
+					Workspace:     &WorkspaceResponse{Name: "default"},
+					Configuration: map[string]interface{}{"region": "eu-west-1"},
+					Secrets:       map[string]interface{}{"aws_secret_access_key": secret},
+				},
+			})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	r := resourceServiceConnector()
+	config := map[string]interface{}{
+		"name":        "aws",
+		"type":        "aws",
+		"auth_method": "secret-key",
+		"configuration": map[string]interface{}{
+			"region":                "eu-west-1",
+			"aws_secret_access_key": "stored-key",
+		},
+	}
+	prior := schema.TestResourceDataRaw(t, r.Schema, config)
+	prior.SetId("connector")
+	state := prior.State()
+
+	// An update unrelated to the secret
+	config["configuration"] = map[string]interface{}{
+		"region":                "us-east-1",
+		"aws_secret_access_key": "stored-key",
+	}
+	diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), client)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d, err := schema.InternalMap(r.Schema).Data(state, diff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if diags := resourceServiceConnectorUpdate(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if expected := map[string]interface{}{"region": "us-east-1"}; !reflect.DeepEqual(update.Configuration, expected) {
+		t.Errorf("expected configuration %v, got %v", expected, update.Configuration)
+	}
+	if expected := map[string]string{"aws_secret_access_key": "stored-key"}; !reflect.DeepEqual(update.Secrets, expected) {
+		t.Errorf("expected the unchanged secret to be kept, got %v", update.Secrets)
+	}
+	if value := d.Get("configuration.aws_secret_access_key"); value != "stored-key" {
+		t.Errorf("expected the masked secret to keep its value in the state, got %q", value)
+	}
+}
+
+func TestResourceServiceConnectorUpdateLabelsOnly(t *testing.T) {
+	var update map[string]interface{}
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v1/current-user":
+			json.NewEncoder(w).Encode(UserResponse{ID: "user"})
+		case r.Method == "GET" && r.URL.Path == "/api/v1/workspaces/default":
+			json.NewEncoder(w).Encode(WorkspaceResponse{ID: "workspace", Name: "default"})
+		case r.Method == "POST" && r.URL.Path == "/api/v1/service_connectors/verify":
+			json.NewEncoder(w).Encode(ServiceConnectorResources{})
+		case r.Method == "PUT" && r.URL.Path == "/api/v1/service_connectors/connector":
+			json.NewDecoder(r.Body).Decode(&update)
+			json.NewEncoder(w).Encode(ServiceConnectorResponse{ID: "connector"})
+		case r.Method == "GET" && r.URL.Path == "/api/v1/service_connectors/connector":
+			// The user can't read the secrets of the connector
+			if r.URL.Query().Get("expand_secrets") == "true" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			json.NewEncoder(w).Encode(ServiceConnectorResponse{
+				ID:   "connector",
+				Name: "aws",
+				Body: &ServiceConnectorResponseBody{
+					ConnectorType: json.RawMessage(`"aws"`),
+					AuthMethod:    "secret-key",
+					User:          &UserResponse{Name: "user"},
+				},
+				Metadata: &ServiceConnectorResponseMetadata{
+					Workspace:     &WorkspaceResponse{Name: "default"},
+					Configuration: map[string]interface{}{"region": "eu-west-1"},
+					Secrets:       map[string]interface{}{"aws_secret_access_key": "**********"},
+					Labels:        map[string]string{"team": "ml"},
+				},
+			})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	r := resourceServiceConnector()
+	config := map[string]interface{}{
+		"name":        "aws",
+		"type":        "aws",
+		"auth_method": "secret-key",
+		"configuration": map[string]interface{}{
+			"region":                "eu-west-1",
+			"aws_secret_access_key": "stored-key",
+		},
+	}
+	prior := schema.TestResourceDataRaw(t, r.Schema, config)
+	prior.SetId("connector")
+	state := prior.State()
+
+	config["labels"] = map[string]interface{}{"team": "ml"}
+	diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), client)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d, err := schema.InternalMap(r.Schema).Data(state, diff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if diags := resourceServiceConnectorUpdate(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if _, ok := update["configuration"]; ok {
+		t.Errorf("expected the configuration to be left unchanged, got %v", update["configuration"])
+	}
+	if _, ok := update["secrets"]; ok {
+		t.Errorf("expected the secrets to be left unchanged, got %v", update["secrets"])
+	}
+	if labels := update["labels"]; !reflect.DeepEqual(labels, map[string]interface{}{"team": "ml"}) {
+		t.Errorf("expected the labels to be updated, got %v", labels)
+	}
+}
+
+func TestResourceServiceConnectorAdoptExisting(t *testing.T) {
+	connectors := []ServiceConnectorResponse{{
+		ID:   "existing",
+		Name: "aws",
+		Body: &ServiceConnectorResponseBody{
+			ConnectorType: json.RawMessage(`{"connector_type": "aws"}`),
+			AuthMethod:    "secret-key",
+			User:          &UserResponse{Name: "platform"},
+		},
+		Metadata: &ServiceConnectorResponseMetadata{
+			Workspace:     &WorkspaceResponse{Name: "default"},
+			Configuration: map[string]interface{}{"region": "eu-west-1", "aws_secret_access_key": "**********"},
+			Secrets:       map[string]interface{}{"aws_secret_access_key": "**********"},
+		},
+	}}
+	var requests []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v1/current-user":
+			json.NewEncoder(w).Encode(UserResponse{ID: "user"})
+		case r.Method == "GET" && r.URL.Path == "/api/v1/workspaces/default":
+			json.NewEncoder(w).Encode(WorkspaceResponse{ID: "workspace", Name: "default"})
+		case r.Method == "GET" && r.URL.Path == "/api/v1/service_connectors":
+			var items []ServiceConnectorResponse
+			for _, connector := range connectors {
+				if connector.Name == r.URL.Query().Get("name") {
+					items = append(items, connector)
+				}
+			}
+			json.NewEncoder(w).Encode(Page[ServiceConnectorResponse]{Index: 1, TotalPages: 1, Total: len(items), Items: items})
+		case r.Method == "POST" && r.URL.Path == "/api/v1/service_connectors/verify":
+			json.NewEncoder(w).Encode(ServiceConnectorResources{})
+		case r.Method == "PUT" && r.URL.Path == "/api/v1/service_connectors/existing",
+			r.Method == "GET" && r.URL.Path == "/api/v1/service_connectors/existing":
+			json.NewEncoder(w).Encode(connectors[0])
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	config := map[string]interface{}{
+		"name":           "aws",
+		"type":           "aws",
+		"auth_method":    "secret-key",
+		"adopt_existing": true,
+		"configuration": map[string]interface{}{
+			"region":                "eu-west-1",
+			"aws_secret_access_key": "secret",
+		},
+	}
+	d := schema.TestResourceDataRaw(t, resourceServiceConnector().Schema, config)
+	if diags := resourceServiceConnectorCreate(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if d.Id() != "existing" {
+		t.Errorf("expected the existing connector to be adopted, got %q", d.Id())
+	}
+	for _, request := range requests {
+		if request == "POST /api/v1/workspaces/workspace/service_connectors" {
+			t.Error("expected no connector to be created")
+		}
+	}
+
+	// A connector of another type isn't adopted
+	config["type"] = "gcp"
+	config["auth_method"] = "service-account"
+	d = schema.TestResourceDataRaw(t, resourceServiceConnector().Schema, config)
+	diags := resourceServiceConnectorCreate(context.Background(), d, client)
+	if !diags.HasError() || diags[0].Summary != "the existing service connector aws has type aws and authentication method secret-key, which don't match the configuration" {
+		t.Errorf("expected a mismatch error, got %v", diags)
+	}
+}
+
+func TestResourceServiceConnectorReadExpiry(t *testing.T) {
+	expiresAt := time.Now().UTC().Add(2 * time.Hour).Truncate(time.Second)
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expires := expiresAt.Format("2006-01-02T15:04:05")
+		expirationSeconds := 43200
+		json.NewEncoder(w).Encode(ServiceConnectorResponse{
+			ID:   "connector",
+			Name: "aws-sts",
+			Body: &ServiceConnectorResponseBody{
+				ConnectorType: json.RawMessage(`"aws"`),
+				AuthMethod:    "sts-token",
+				User:          &UserResponse{Name: "admin"},
+				ExpiresAt:     &expires,
+			},
+			Metadata: &ServiceConnectorResponseMetadata{
+				Workspace:         &WorkspaceResponse{Name: "default"},
+				ExpirationSeconds: &expirationSeconds,
+			},
+		})
+	}))
+
+	cases := []struct {
+		window      string
+		wantWarning bool
+	}{
+		{"", false},
+		{"1h", false},
+		{"72h", true},
+	}
+	for _, tc := range cases {
+		d := schema.TestResourceDataRaw(t, resourceServiceConnector().Schema, map[string]interface{}{
+			"expiry_warning_window": tc.window,
+		})
+		d.SetId("connector")
+		diags := resourceServiceConnectorRead(context.Background(), d, client)
+		if diags.HasError() {
+			t.Fatalf("unexpected error: %v", diags)
+		}
+		if got := len(diags) == 1 && diags[0].Severity == diag.Warning; got != tc.wantWarning {
+			t.Errorf("window %q: expected a warning %v, got %v", tc.window, tc.wantWarning, diags)
+		}
+		if got := d.Get("expires_at"); got != expiresAt.Format(time.RFC3339) {
+			t.Errorf("expected expires_at %s, got %v", expiresAt.Format(time.RFC3339), got)
+		}
+		if got := d.Get("expiration_seconds"); got != 43200 {
+			t.Errorf("expected expiration_seconds 43200, got %v", got)
+		}
+	}
+}
+
+func TestResourceServiceConnectorUpdateLabels(t *testing.T) {
+	tests := []struct {
+		name          string
+		prior         map[string]interface{}
+		labels        map[string]interface{}
+		defaultLabels map[string]string
+		want          string
+	}{
+		{"add", nil, map[string]interface{}{"env": "dev"}, nil, `{"env":"dev"}`},
+		{"change", map[string]interface{}{"env": "dev"}, map[string]interface{}{"env": "prod"}, nil, `{"env":"prod"}`},
+		{"remove", map[string]interface{}{"env": "dev"}, nil, nil, `{}`},
+		{"unchanged", map[string]interface{}{"env": "dev"}, map[string]interface{}{"env": "dev"}, nil, `null`},
+		{"default labels", map[string]interface{}{"env": "dev"}, map[string]interface{}{"env": "prod"}, map[string]string{"owner": "platform"}, `{"env":"prod","owner":"platform"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]json.RawMessage
+			client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == "GET" && r.URL.Path == "/api/v1/current-user":
+					json.NewEncoder(w).Encode(UserResponse{ID: "user"})
+				case r.Method == "GET" && r.URL.Path == "/api/v1/workspaces/default":
+					json.NewEncoder(w).Encode(WorkspaceResponse{ID: "workspace", Name: "default"})
+				case r.Method == "POST" && r.URL.Path == "/api/v1/service_connectors/verify":
+					json.NewEncoder(w).Encode(ServiceConnectorResources{})
+				case r.Method == "PUT" && r.URL.Path == "/api/v1/service_connectors/connector":
+					json.NewDecoder(r.Body).Decode(&body)
+					json.NewEncoder(w).Encode(ServiceConnectorResponse{ID: "connector"})
+				case r.Method == "GET" && r.URL.Path == "/api/v1/service_connectors/connector":
+					json.NewEncoder(w).Encode(ServiceConnectorResponse{ID: "connector", Name: "aws"})
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			client.DefaultLabels = tt.defaultLabels
+
+			r := resourceServiceConnector()
+			config := map[string]interface{}{
+				"name":          "aws",
+				"type":          "aws",
+				"auth_method":   "secret-key",
+				"configuration": map[string]interface{}{"region": "eu-west-1"},
+			}
+			if tt.prior != nil {
+				config["labels"] = tt.prior
+			}
+			prior := schema.TestResourceDataRaw(t, r.Schema, config)
+			prior.SetId("connector")
+			state := prior.State()
+
+			delete(config, "labels")
+			if tt.labels != nil {
+				config["labels"] = tt.labels
+			}
+			// Change the name too, so that the unchanged labels are updated
+			// along with something else
+			config["name"] = "aws-renamed"
+			diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), client)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			d, err := schema.InternalMap(r.Schema).Data(state, diff)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diags := resourceServiceConnectorUpdate(context.Background(), d, client); diags.HasError() {
+				t.Fatalf("unexpected error: %v", diags)
+			}
+			if got := string(body["labels"]); got != tt.want {
+				t.Errorf("expected labels %s in the update, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestResourceServiceConnectorDeleteInUse(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "DELETE" && r.URL.Path == "/api/v1/service_connectors/connector-id":
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{"detail": "service connector is still in use"})
+		case r.Method == "GET" && r.URL.Path == "/api/v1/components":
+			json.NewEncoder(w).Encode(Page[ComponentResponse]{Index: 1, TotalPages: 1, Total: 2, Items: []ComponentResponse{
+				{ID: "store-id", Name: "s3", Body: &ComponentResponseBody{Type: "artifact_store"}},
+				{ID: "registry-id", Name: "ecr", Body: &ComponentResponseBody{Type: "container_registry"}},
+			}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	client.MaxRetries = 0
+
+	d := schema.TestResourceDataRaw(t, resourceServiceConnector().Schema, map[string]interface{}{"name": "aws"})
+	d.SetId("connector-id")
+	diags := resourceServiceConnectorDelete(context.Background(), d, client)
+	if len(diags) != 1 || diags[0].Summary != `Service connector "aws" is used by 2 components` {
+		t.Fatalf("expected a diagnostic about the linked components, got %v", diags)
+	}
+	if want := "ecr (container_registry), s3 (artifact_store)"; !strings.Contains(diags[0].Detail, want) {
+		t.Errorf("expected the detail to name %s, got %q", want, diags[0].Detail)
+	}
+	if d.Id() != "connector-id" {
+		t.Errorf("expected the connector to stay in the state")
+	}
+}
@@ -3,11 +3,17 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"sort"
 	"strings"
 
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func resourceStack() *schema.Resource {
@@ -30,15 +36,15 @@ func resourceStack() *schema.Resource {
 			},
 			"components": {
 				Type:     schema.TypeMap,
-				Required: true,
+				Optional: true,
 				Elem: &schema.Schema{
 					Type: schema.TypeString,
 				},
-				Description: "Map of component types to component IDs",
-				// We cannot delete components while they are still in use
-				// by a stack, so we need to force new stacks when components
-				// are changed.
-				ForceNew: true,
+				Description:      "Map of component types to IDs of components managed outside of this stack",
+				ValidateDiagFunc: validateStackComponents,
+				// Changes are applied in place. A component that is replaced
+				// can only be deleted once no stack uses it anymore, which
+				// requires create_before_destroy on the component.
 			},
 			"labels": {
 				Type:     schema.TypeMap,
@@ -47,6 +53,101 @@ func resourceStack() *schema.Resource {
 					Type: schema.TypeString,
 				},
 			},
+			"shared": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				Description: "Whether the stack is shared with the other users of the workspace instead of being private to its owner. " +
+					"Only supported by servers with stack sharing",
+			},
+			"component": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Components that are created together with the stack and deleted when the stack is deleted",
+				// Inline components are attached to the stack, so they are
+				// replaced together with it, like referenced components.
+				ForceNew: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+							ForceNew: true,
+						},
+						"type": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ForceNew:     true,
+							ValidateFunc: validation.StringInSlice(validComponentTypes, false),
+						},
+						"flavor": {
+							Type:     schema.TypeString,
+							Required: true,
+							ForceNew: true,
+						},
+						"configuration": {
+							Type:      schema.TypeMap,
+							Optional:  true,
+							Sensitive: true,
+							ForceNew:  true,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+						"labels": {
+							Type:     schema.TypeMap,
+							Optional: true,
+							ForceNew: true,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+						"connector_id": {
+							Type:     schema.TypeString,
+							Optional: true,
+							ForceNew: true,
+						},
+						"connector_resource_id": {
+							Type:     schema.TypeString,
+							Optional: true,
+							ForceNew: true,
+						},
+					},
+				},
+			},
+			"adopt_existing": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				Description: "If a stack with the same name already exists in the workspace, " +
+					"manage it with this resource instead of failing to create it",
+			},
+			"deletion_mode": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "delete",
+				ValidateFunc: validation.StringInSlice([]string{"delete", "trash"}, false),
+				Description: "How the stack is deleted: 'delete' deletes it permanently, 'trash' moves it to the trash. " +
+					"With 'trash', a trashed stack with the same name is restored instead of creating a new one",
+			},
+			"validate": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				Description: "Validate that the components of the stack are compatible with each other after it is created or updated. " +
+					"Skipped if the server doesn't support stack validation",
+			},
+			"validation_failure": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "error",
+				ValidateFunc: validation.StringInSlice([]string{"error", "warning"}, false),
+				Description:  "Whether an invalid stack fails the apply ('error') or only emits warnings ('warning')",
+			},
 		},
 
 		CustomizeDiff: func(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
@@ -68,13 +169,68 @@ func resourceStack() *schema.Resource {
 					}
 				}
 			}
+
+			// A component type can either be referenced or created inline
+			referenced := d.Get("components").(map[string]interface{})
+			inline := d.Get("component").([]interface{})
+			for _, c := range inline {
+				compType := c.(map[string]interface{})["type"].(string)
+				if _, ok := referenced[compType]; ok {
+					return fmt.Errorf(
+						"component type %q is set both in components and in a component block", compType)
+				}
+			}
+
+			if len(inline) > 0 && d.Get("adopt_existing").(bool) {
+				return fmt.Errorf("adopt_existing cannot be used together with component blocks")
+			}
+			// Inline components are deleted with the stack, so a restored
+			// stack would reference deleted components
+			if len(inline) > 0 && d.Get("deletion_mode").(string) == "trash" {
+				return fmt.Errorf("deletion_mode \"trash\" cannot be used together with component blocks")
+			}
 			return nil
 		},
 
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: resourceStackImport,
 		},
+
+		// The create timeout bounds the whole creation, including waiting
+		// for the stack to become readable and validating it
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(defaultResourceTimeout),
+			Read:   schema.DefaultTimeout(defaultResourceTimeout),
+			Update: schema.DefaultTimeout(defaultResourceTimeout),
+			Delete: schema.DefaultTimeout(defaultResourceTimeout),
+		},
+	}
+}
+
+// resourceStackImport imports a stack by ID. The components of the stack are
+// read into the components map by the read that follows, as references:
+// they are never imported as component blocks, which would delete them with
+// the stack although they may be shared with other stacks.
+func resourceStackImport(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	// Arguments that are never read from the server keep their defaults,
+	// so that the plan after the import is clean
+	if err := d.Set("adopt_existing", false); err != nil {
+		return nil, err
+	}
+	if err := d.Set("deletion_mode", "delete"); err != nil {
+		return nil, err
 	}
+	if err := d.Set("validate", false); err != nil {
+		return nil, err
+	}
+	if err := d.Set("validation_failure", "error"); err != nil {
+		return nil, err
+	}
+	// Overwritten by the read on servers with stack sharing
+	if err := d.Set("shared", false); err != nil {
+		return nil, err
+	}
+	return []*schema.ResourceData{d}, nil
 }
 
 func resourceStackCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
@@ -106,13 +262,172 @@ func resourceStackCreate(ctx context.Context, d *schema.ResourceData, m interfac
 		stack.Labels = labels
 	}
 
-	resp, err := client.CreateStack(ctx, workspace, stack)
+	// Only sent when set, servers without stack sharing reject the field
+	if d.Get("shared").(bool) {
+		shared := true
+		stack.IsShared = &shared
+	}
+
+	// Create inline components together with the stack
+	if v, ok := d.GetOk("component"); ok {
+		return resourceStackCreateWithComponents(ctx, d, m, stack, v.([]interface{}))
+	}
+
+	// A stack trashed by this resource is restored when it is added again
+	if d.Get("deletion_mode").(string) == "trash" {
+		trashed, err := client.GetTrashedStackByName(ctx, workspace, stack.Name)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("error looking up trashed stack: %w", err))
+		}
+		if trashed != nil {
+			return resourceStackRestore(ctx, d, m, trashed.ID, stack)
+		}
+	}
+
+	// Only adopt existing stacks when explicitly asked to, so we don't
+	// accidentally take over stacks we shouldn't manage
+	if !d.Get("adopt_existing").(bool) {
+		resp, err := client.CreateStack(ctx, workspace, stack)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("error creating stack: %w", err))
+		}
+
+		d.SetId(resp.ID)
+		return resourceStackReadCreated(ctx, d, m)
+	}
+
+	resp, adopted, err := client.CreateOrGetStack(ctx, workspace, stack)
 	if err != nil {
 		return diag.FromErr(fmt.Errorf("error creating stack: %w", err))
 	}
 
 	d.SetId(resp.ID)
-	return resourceStackRead(ctx, d, m)
+
+	if adopted {
+		// Bring the adopted stack in line with the configuration
+		update := StackUpdate{
+			Name:       &stack.Name,
+			Components: stack.Components,
+			IsShared:   stack.IsShared,
+		}
+		if stack.Labels != nil {
+			update.Labels = &stack.Labels
+		}
+		if _, err := client.UpdateStack(ctx, resp.ID, update); err != nil {
+			return diag.FromErr(fmt.Errorf("error updating adopted stack: %w", err))
+		}
+	}
+
+	return resourceStackReadCreated(ctx, d, m)
+}
+
+// resourceStackRestore restores a trashed stack and brings it in line with
+// the configuration, instead of creating a new stack.
+func resourceStackRestore(ctx context.Context, d *schema.ResourceData, m interface{}, id string, stack StackRequest) diag.Diagnostics {
+	client := m.(*Client)
+
+	resp, err := client.RestoreStack(ctx, id)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error restoring stack: %w", err))
+	}
+
+	d.SetId(resp.ID)
+
+	update := StackUpdate{
+		Name:       &stack.Name,
+		Components: stack.Components,
+		IsShared:   stack.IsShared,
+	}
+	if stack.Labels != nil {
+		update.Labels = &stack.Labels
+	}
+	if _, err := client.UpdateStack(ctx, resp.ID, update); err != nil {
+		return diag.FromErr(fmt.Errorf("error updating restored stack: %w", err))
+	}
+
+	return resourceStackReadCreated(ctx, d, m)
+}
+
+// resourceStackCreateWithComponents creates the stack together with its
+// inline components. The client rolls back the created components if the
+// stack cannot be created.
+func resourceStackCreateWithComponents(ctx context.Context, d *schema.ResourceData, m interface{}, stack StackRequest, blocks []interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	// Get the current user
+	user, err := client.GetCurrentUser(ctx)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error getting current user: %w", err))
+	}
+
+	workspaceName := d.Get("workspace").(string)
+
+	// Get the workspace ID
+	workspace, err := client.GetWorkspaceByName(ctx, workspaceName)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error getting workspace: %w", err))
+	}
+	if workspace == nil {
+		return diag.FromErr(fmt.Errorf("workspace not found: %s", workspaceName))
+	}
+
+	components := make([]ComponentRequest, 0, len(blocks))
+	for _, b := range blocks {
+		block := b.(map[string]interface{})
+		component := ComponentRequest{
+			User:          user.ID,
+			Workspace:     workspace.ID,
+			Name:          block["name"].(string),
+			Type:          block["type"].(string),
+			Flavor:        block["flavor"].(string),
+			Configuration: block["configuration"].(map[string]interface{}),
+		}
+		if v := block["connector_id"].(string); v != "" {
+			component.ConnectorID = &v
+		}
+		if v := block["connector_resource_id"].(string); v != "" {
+			component.ConnectorResourceID = &v
+		}
+		if v := block["labels"].(map[string]interface{}); len(v) > 0 {
+			labels := make(map[string]string)
+			for k, v := range v {
+				labels[k] = v.(string)
+			}
+			component.Labels = labels
+		}
+		components = append(components, component)
+	}
+
+	resp, created, err := client.CreateStackWithComponents(ctx, workspace.ID, stack, components)
+	if err != nil {
+		// Reports every component that failed to be created
+		return bulkErrorDiagnostics(err)
+	}
+
+	d.SetId(resp.ID)
+
+	// Record the IDs of the created components, so that they can be told
+	// apart from referenced components and deleted with the stack
+	for i, b := range blocks {
+		b.(map[string]interface{})["id"] = created[i].ID
+	}
+	if err := d.Set("component", blocks); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return resourceStackReadCreated(ctx, d, m)
+}
+
+// inlineComponentIDs returns the IDs of the components created by the stack
+// resource itself.
+func inlineComponentIDs(d *schema.ResourceData) []string {
+	var ids []string
+	for _, b := range d.Get("component").([]interface{}) {
+		if id, _ := b.(map[string]interface{})["id"].(string); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
 }
 
 func resourceStackRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
@@ -132,75 +447,272 @@ func resourceStackRead(ctx context.Context, d *schema.ResourceData, m interface{
 
 	// Handle components - flatten the array structure to single IDs
 	if stack.Metadata != nil && stack.Metadata.Components != nil {
+		// Components created inline are tracked in the component blocks
+		inline := make(map[string]bool)
+		for _, id := range inlineComponentIDs(d) {
+			inline[id] = true
+		}
+
 		components := make(map[string]string)
 		for compType, compArray := range stack.Metadata.Components {
-			if len(compArray) > 0 {
+			for _, comp := range compArray {
+				if inline[comp.ID] {
+					continue
+				}
 				// Take first component ID for each type
-				components[compType] = compArray[0].ID
+				components[compType] = comp.ID
+				break
 			}
 		}
 		d.Set("components", components)
 	}
 
+	if stack.Metadata != nil && stack.Metadata.Workspace != nil && stack.Metadata.Workspace.Name != "default" {
+		d.Set("workspace", stack.Metadata.Workspace.Name)
+	}
+
 	// Handle labels if present
 	if stack.Metadata != nil && stack.Metadata.Labels != nil {
+		d.Set("labels", client.withoutDefaultLabels(stack.Metadata.Labels, d.Get("labels").(map[string]interface{})))
+	}
 
-		if stack.Metadata.Workspace.Name != "default" {
-			d.Set("workspace", stack.Metadata.Workspace.Name)
-		}
-
-		d.Set("labels", stack.Metadata.Labels)
+	if stack.Body != nil && stack.Body.IsShared != nil {
+		d.Set("shared", *stack.Body.IsShared)
 	}
 
 	return nil
 }
 
-func resourceStackUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+// resourceStackReadCreated waits until a stack that was just created can be
+// read, and then reads and validates it like after an update.
+func resourceStackReadCreated(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	client := m.(*Client)
+	if _, err := client.WaitForStack(ctx, d.Id(), client.StackWaitTimeout); err != nil {
+		return diag.FromErr(fmt.Errorf("error getting created stack: %w", err))
+	}
+	return resourceStackReadAndValidate(ctx, d, m)
+}
 
-	name := d.Get("name").(string)
+// resourceStackReadAndValidate reads a stack that was just created or
+// updated and, if requested, validates it.
+func resourceStackReadAndValidate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	diags := resourceStackRead(ctx, d, m)
+	if diags.HasError() || d.Id() == "" || !d.Get("validate").(bool) {
+		return diags
+	}
 
-	update := StackUpdate{
-		Name: &name,
+	client := m.(*Client)
+	result, err := client.ValidateStack(ctx, d.Id())
+	if err != nil {
+		return append(diags, diag.FromErr(fmt.Errorf("error validating stack: %w", err))...)
+	}
+	if result == nil {
+		tflog.Warn(ctx, "[ZENML] The server doesn't support stack validation, skipping it")
+		return diags
+	}
+	return append(diags, stackValidationDiagnostics(result, d.Get("validation_failure").(string))...)
+}
+
+// stackValidationDiagnostics turns the messages of a stack validation into
+// diagnostics. Errors are downgraded to warnings unless failure is "error".
+func stackValidationDiagnostics(result *StackValidationResponse, failure string) diag.Diagnostics {
+	severity := diag.Warning
+	if failure == "error" {
+		severity = diag.Error
+	}
+
+	var diags diag.Diagnostics
+	reported := false
+	for _, message := range result.Messages {
+		d := diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Stack validation: " + message.Message,
+		}
+		if message.Severity == "error" {
+			d.Severity = severity
+			reported = true
+		}
+		if message.ComponentType != nil {
+			d.Detail = fmt.Sprintf("Reported for the %s component of the stack.", *message.ComponentType)
+		}
+		diags = append(diags, d)
+	}
+	// Servers may reject a stack without explaining why
+	if !result.Valid && !reported {
+		diags = append(diags, diag.Diagnostic{
+			Severity: severity,
+			Summary:  "Stack validation failed",
+			Detail:   "The server reported the stack as invalid without an error message.",
+		})
+	}
+	return diags
+}
+
+func resourceStackUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	// Renames are updates: the stack keeps its ID and components
+	update := StackUpdate{}
+	if d.HasChange("name") {
+		name := d.Get("name").(string)
+		update.Name = &name
 	}
 
 	// Handle components
 	if d.HasChange("components") {
-		components := make(map[string][]string)
-		for k, v := range d.Get("components").(map[string]interface{}) {
-			// Convert single ID to array of IDs
-			components[k] = []string{v.(string)}
+		o, n := d.GetChange("components")
+		components, added, removed := stackComponentChanges(
+			o.(map[string]interface{}), n.(map[string]interface{}), d.Get("component").([]interface{}))
+		tflog.Debug(ctx, fmt.Sprintf("[ZENML] Updating stack components: adding %v, removing %v", added, removed))
+
+		// The API fails with a bare 404 for components that don't exist
+		var diags diag.Diagnostics
+		for compType, id := range added {
+			component, err := client.GetComponent(ctx, id)
+			if err != nil {
+				return diag.FromErr(fmt.Errorf("error getting component %s: %w", id, err))
+			}
+			if component == nil {
+				diags = append(diags, diag.Diagnostic{
+					Severity:      diag.Error,
+					Summary:       fmt.Sprintf("Component %s does not exist", id),
+					Detail:        fmt.Sprintf("The %s component %s referenced by the stack does not exist. It may have been deleted outside of Terraform.", compType, id),
+					AttributePath: cty.GetAttrPath("components").IndexString(compType),
+				})
+			}
+		}
+		if diags.HasError() {
+			return diags
 		}
 		update.Components = components
 	}
 
-	// Handle labels
+	// Labels are replaced as a whole; an empty map removes the last label
 	if d.HasChange("labels") {
-		if v, ok := d.GetOk("labels"); ok {
-			labels := make(map[string]string)
-			for k, v := range v.(map[string]interface{}) {
-				labels[k] = v.(string)
-			}
-			update.Labels = labels
+		labels := make(map[string]string)
+		for k, v := range d.Get("labels").(map[string]interface{}) {
+			labels[k] = v.(string)
 		}
+		update.Labels = &labels
+	}
+
+	// Visibility changes are updates as well
+	if d.HasChange("shared") {
+		shared := d.Get("shared").(bool)
+		update.IsShared = &shared
 	}
 
 	_, err := client.UpdateStack(ctx, d.Id(), update)
+	var apiErr *APIError
+	if update.Name != nil && errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict {
+		return diag.Diagnostics{{
+			Severity:      diag.Error,
+			Summary:       fmt.Sprintf("Stack name %q is already taken", *update.Name),
+			Detail:        fmt.Sprintf("The stack can't be renamed because another stack in the workspace is named %q: %s", *update.Name, apiErr.Detail),
+			AttributePath: cty.GetAttrPath("name"),
+		}}
+	}
 	if err != nil {
 		return diag.FromErr(fmt.Errorf("error updating stack: %w", err))
 	}
 
-	return resourceStackRead(ctx, d, m)
+	return resourceStackReadAndValidate(ctx, d, m)
+}
+
+// validateStackComponents checks the components map of a stack before it is
+// sent to the server, which rejects malformed IDs and components that are
+// referenced more than once with a bare 422. Only runs once all IDs are
+// known.
+func validateStackComponents(v interface{}, path cty.Path) diag.Diagnostics {
+	components := v.(map[string]interface{})
+	types := make([]string, 0, len(components))
+	for compType := range components {
+		types = append(types, compType)
+	}
+	// Report duplicates on the same type whatever the map order
+	sort.Strings(types)
+
+	var diags diag.Diagnostics
+	seen := make(map[string]string)
+	for _, compType := range types {
+		id := components[compType].(string)
+		if _, errs := validation.IsUUID(id, compType); len(errs) > 0 {
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       fmt.Sprintf("Invalid component ID %q", id),
+				Detail:        fmt.Sprintf("The %s component must be referenced by its ID, which is a UUID.", compType),
+				AttributePath: path.IndexString(compType),
+			})
+			continue
+		}
+		if other, ok := seen[id]; ok {
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       fmt.Sprintf("Component %s is referenced more than once", id),
+				Detail:        fmt.Sprintf("The component is referenced both as the %s and as the %s component of the stack. A component can only be part of a stack once.", other, compType),
+				AttributePath: path.IndexString(compType),
+			})
+			continue
+		}
+		seen[id] = compType
+	}
+	return diags
+}
+
+// stackComponentChanges compares the referenced components of a stack before
+// and after an update. Updates replace all components of a stack, so it
+// returns the complete set of components to send, including the components
+// created inline, along with the referenced components that are added or
+// replaced, by type, and the IDs of the ones that are removed.
+func stackComponentChanges(old, new map[string]interface{}, inline []interface{}) (components map[string][]string, added map[string]string, removed []string) {
+	components = make(map[string][]string)
+	added = make(map[string]string)
+	for compType, v := range new {
+		id := v.(string)
+		components[compType] = []string{id}
+		if old[compType] != id {
+			added[compType] = id
+		}
+	}
+	for compType, v := range old {
+		if new[compType] != v {
+			removed = append(removed, v.(string))
+		}
+	}
+	sort.Strings(removed)
+
+	for _, b := range inline {
+		block := b.(map[string]interface{})
+		if id, _ := block["id"].(string); id != "" {
+			compType := block["type"].(string)
+			components[compType] = append(components[compType], id)
+		}
+	}
+	return components, added, removed
 }
 
 func resourceStackDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	client := m.(*Client)
 
+	if d.Get("deletion_mode").(string) == "trash" {
+		if err := client.TrashStack(ctx, d.Id()); err != nil {
+			return diag.FromErr(fmt.Errorf("error trashing stack: %w", err))
+		}
+		d.SetId("")
+		return nil
+	}
+
 	err := client.DeleteStack(ctx, d.Id())
 	if err != nil {
 		return diag.FromErr(fmt.Errorf("error deleting stack: %w", err))
 	}
 
+	// Tear down the components created with the stack, but leave referenced
+	// components alone
+	if err := client.DeleteComponentsBatch(ctx, inlineComponentIDs(d)); err != nil {
+		return bulkErrorDiagnostics(err)
+	}
+
 	d.SetId("")
 	return nil
 }
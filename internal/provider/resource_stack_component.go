@@ -4,7 +4,11 @@ package provider
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sort"
+	"strings"
 
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
@@ -18,14 +22,34 @@ func resourceStackComponent() *schema.Resource {
 		DeleteContext: resourceStackComponentDelete,
 
 		CustomizeDiff: func(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
-			// Validate that if connector_resource_id is set, connector should also be set
+			// Validate that if connector_resource_id is set, connector should
+			// also be set. The ID of a connector created in the same run is
+			// only known after apply, so it can't be checked yet. The
+			// resource ID itself is optional: connectors that are bound to a
+			// single resource imply it.
 			connector, hasConnector := d.GetOk("connector_id")
 			connectorResourceID, hasConnectorResourceID := d.GetOk("connector_resource_id")
 
-			if hasConnectorResourceID && connectorResourceID.(string) != "" && (!hasConnector || connector.(string) == "") {
+			if hasConnectorResourceID && connectorResourceID.(string) != "" && (!hasConnector || connector.(string) == "") && d.NewValueKnown("connector_id") {
 				return fmt.Errorf("connector_id must be set when connector_resource_id is specified")
 			}
 
+			if d.HasChange("connector_resource_type") {
+				connectorResourceType, hasConnectorResourceType := d.GetOk("connector_resource_type")
+				if hasConnectorResourceType && connectorResourceType.(string) != "" && (!hasConnector || connector.(string) == "") {
+					return fmt.Errorf("connector_id must be set when connector_resource_type is specified")
+				}
+			}
+
+			// A resource type that isn't configured is the one read back
+			// for the previous connector, which the new connector may not
+			// support, so resolve it again for the new connector
+			if d.HasChange("connector_id") && !isConfigured(d, "connector_resource_type") {
+				if err := d.SetNewComputed("connector_resource_type"); err != nil {
+					return err
+				}
+			}
+
 			return nil
 		},
 
@@ -58,6 +82,36 @@ func resourceStackComponent() *schema.Resource {
 				Elem: &schema.Schema{
 					Type: schema.TypeString,
 				},
+				// Values that are JSON objects or lists, e.g. set with
+				// jsonencode, are read back in canonical form
+				DiffSuppressFunc: suppressEquivalentJSON,
+			},
+			"config_merge_strategy": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "replace",
+				ValidateFunc: validation.StringInSlice([]string{"replace", "merge"}, false),
+				Description: "How configuration changes are applied: 'replace' replaces the stored configuration with the declared one, " +
+					"removing keys that aren't declared. 'merge' only changes the declared keys and the keys removed from the configuration, " +
+					"keeping keys set outside of Terraform",
+			},
+			"skip_config_validation": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Skip validating the configuration against the flavor's configuration schema before sending it, e.g. for flavors newer than the provider",
+			},
+			"skip_flavor_validation": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Skip checking that the flavor belongs to the component type before creating the component",
+			},
+			"force_delete": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Delete the component even if stacks still reference it",
 			},
 			"labels": {
 				Type:     schema.TypeMap,
@@ -69,23 +123,61 @@ func resourceStackComponent() *schema.Resource {
 			"connector_id": {
 				Type:     schema.TypeString,
 				Optional: true,
-				// We cannot delete service connectors while they are still in
-				// use by a component, so we need to force new components when
-				// the connector is changed.
-				ForceNew: true,
+				// Changing the connector updates the component in place. We
+				// cannot delete service connectors while they are still in use
+				// by a component, so a replaced connector must be created
+				// before the old one is destroyed (create_before_destroy).
 			},
 			"connector_resource_id": {
 				Type:     schema.TypeString,
 				Optional: true,
 			},
+			"connector_resource_type": {
+				Type:     schema.TypeString,
+				Optional: true,
+				// Connectors that only support a single resource type imply
+				// it, so the server-side value is read back when not set.
+				Computed: true,
+			},
 		},
 
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: resourceStackComponentImport,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(defaultResourceTimeout),
+			Read:   schema.DefaultTimeout(defaultResourceTimeout),
+			Update: schema.DefaultTimeout(defaultResourceTimeout),
+			Delete: schema.DefaultTimeout(defaultResourceTimeout),
 		},
 	}
 }
 
+// resourceStackComponentImport imports a component by its ID.
+func resourceStackComponentImport(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	// Arguments that are never read from the server keep their defaults,
+	// so that the plan after the import is clean. Replacing the
+	// configuration also reads back all of its keys.
+	if err := d.Set("config_merge_strategy", "replace"); err != nil {
+		return nil, err
+	}
+	if err := d.Set("skip_config_validation", false); err != nil {
+		return nil, err
+	}
+	if err := d.Set("skip_flavor_validation", false); err != nil {
+		return nil, err
+	}
+	if err := d.Set("force_delete", false); err != nil {
+		return nil, err
+	}
+	// Overwritten by the read for components in other workspaces
+	if err := d.Set("workspace", "default"); err != nil {
+		return nil, err
+	}
+	return []*schema.ResourceData{d}, nil
+}
+
 func resourceStackComponentCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	client, ok := m.(*Client)
 	if !ok {
@@ -112,6 +204,13 @@ func resourceStackComponentCreate(ctx context.Context, d *schema.ResourceData, m
 		return diag.FromErr(fmt.Errorf("workspace not found: %s", workspaceName))
 	}
 
+	if diags := validateComponentFlavor(ctx, client, d); diags.HasError() {
+		return diags
+	}
+	if diags := validateComponentConfiguration(ctx, client, d); diags.HasError() {
+		return diags
+	}
+
 	// Create the component request
 	component := ComponentRequest{
 		User:          user.ID, // Add the user ID
@@ -126,6 +225,13 @@ func resourceStackComponentCreate(ctx context.Context, d *schema.ResourceData, m
 	if v, ok := d.GetOk("connector_id"); ok {
 		connectorID := v.(string)
 		component.ConnectorID = &connectorID
+
+		resourceType, err := resolveConnectorResourceType(
+			ctx, client, connectorID, d.Get("connector_resource_type").(string))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		component.ConnectorResourceType = &resourceType
 	}
 
 	if v, ok := d.GetOk("connector_resource_id"); ok {
@@ -144,9 +250,6 @@ func resourceStackComponentCreate(ctx context.Context, d *schema.ResourceData, m
 	// Make the API call
 	resp, err := client.CreateComponent(ctx, workspace.ID, component)
 	if err != nil {
-		if apiErr, ok := err.(*APIError); ok {
-			return diag.FromErr(fmt.Errorf("API error: %s", apiErr.Error()))
-		}
 		return diag.FromErr(fmt.Errorf("failed to create component: %w", err))
 	}
 
@@ -160,12 +263,19 @@ func resourceStackComponentCreate(ctx context.Context, d *schema.ResourceData, m
 		d.Set("flavor", resp.Body.Flavor)
 	}
 	if resp.Metadata != nil {
-		d.Set("configuration", resp.Metadata.Configuration)
+		configuration := resp.Metadata.Configuration
+		if d.Get("config_merge_strategy").(string) == "merge" {
+			configuration = managedConfiguration(configuration, d.Get("configuration").(map[string]interface{}))
+		}
+		d.Set("configuration", flattenConfiguration(configuration))
 		if resp.Metadata.ConnectorResourceID != nil {
 			d.Set("connector_resource_id", *resp.Metadata.ConnectorResourceID)
 		}
+		if resp.Metadata.ConnectorResourceType != nil {
+			d.Set("connector_resource_type", *resp.Metadata.ConnectorResourceType)
+		}
 		if resp.Metadata.Labels != nil {
-			d.Set("labels", resp.Metadata.Labels)
+			d.Set("labels", client.withoutDefaultLabels(resp.Metadata.Labels, d.Get("labels").(map[string]interface{})))
 		}
 	}
 
@@ -196,16 +306,33 @@ func resourceStackComponentRead(ctx context.Context, d *schema.ResourceData, m i
 	}
 
 	if component.Metadata != nil {
-		d.Set("configuration", component.Metadata.Configuration)
+		configuration := component.Metadata.Configuration
+		if d.Get("config_merge_strategy").(string) == "merge" {
+			// Keys set outside of Terraform are not managed by this resource
+			configuration = managedConfiguration(configuration, d.Get("configuration").(map[string]interface{}))
+		}
+		d.Set("configuration", flattenConfiguration(configuration))
 
 		if component.Metadata.Workspace.Name != "default" {
 			d.Set("workspace", component.Metadata.Workspace.Name)
 		}
+		if component.Metadata.Connector != nil {
+			d.Set("connector_id", component.Metadata.Connector.ID)
+		} else {
+			d.Set("connector_id", "")
+		}
 		if component.Metadata.ConnectorResourceID != nil {
 			d.Set("connector_resource_id", *component.Metadata.ConnectorResourceID)
+		} else {
+			d.Set("connector_resource_id", "")
+		}
+		if component.Metadata.ConnectorResourceType != nil {
+			d.Set("connector_resource_type", *component.Metadata.ConnectorResourceType)
+		} else {
+			d.Set("connector_resource_type", "")
 		}
 		if component.Metadata.Labels != nil {
-			d.Set("labels", component.Metadata.Labels)
+			d.Set("labels", client.withoutDefaultLabels(component.Metadata.Labels, d.Get("labels").(map[string]interface{})))
 		}
 	}
 
@@ -224,11 +351,23 @@ func resourceStackComponentUpdate(ctx context.Context, d *schema.ResourceData, m
 	// type and flavor are immutable, so we don't need to check for changes
 
 	if d.HasChange("configuration") {
-		configMap := make(map[string]interface{})
-		for k, v := range d.Get("configuration").(map[string]interface{}) {
-			configMap[k] = v
+		if diags := validateComponentConfiguration(ctx, client, d); diags.HasError() {
+			return diags
+		}
+		o, n := d.GetChange("configuration")
+		configuration := n.(map[string]interface{})
+		if d.Get("config_merge_strategy").(string) == "merge" {
+			current, err := client.GetComponent(ctx, d.Id())
+			if err != nil {
+				return diag.FromErr(fmt.Errorf("error getting component: %w", err))
+			}
+			var stored map[string]interface{}
+			if current != nil && current.Metadata != nil {
+				stored = current.Metadata.Configuration
+			}
+			configuration = mergeConfiguration(stored, o.(map[string]interface{}), configuration)
 		}
-		update.Configuration = configMap
+		update.Configuration = &configuration
 	}
 
 	if d.HasChange("labels") {
@@ -253,6 +392,13 @@ func resourceStackComponentUpdate(ctx context.Context, d *schema.ResourceData, m
 		} else {
 			update.ConnectorResourceID = nil
 		}
+
+		resourceType, err := resolveConnectorResourceType(
+			ctx, client, str, d.Get("connector_resource_type").(string))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		update.ConnectorResourceType = &resourceType
 	} else {
 		update.ConnectorID = nil
 	}
@@ -265,10 +411,149 @@ func resourceStackComponentUpdate(ctx context.Context, d *schema.ResourceData, m
 	return resourceStackComponentRead(ctx, d, m)
 }
 
+// mergeConfiguration applies a configuration change to the stored
+// configuration: keys removed from the old configuration are deleted and the
+// keys of the new configuration are set. Other stored keys are kept.
+func mergeConfiguration(stored, old, new map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(stored)+len(new))
+	for key, value := range stored {
+		merged[key] = value
+	}
+	for key := range old {
+		if _, ok := new[key]; !ok {
+			delete(merged, key)
+		}
+	}
+	for key, value := range new {
+		merged[key] = value
+	}
+	return merged
+}
+
+// managedConfiguration returns the keys of the stored configuration that
+// are declared in the configuration.
+func managedConfiguration(stored, declared map[string]interface{}) map[string]interface{} {
+	managed := make(map[string]interface{}, len(declared))
+	for key := range declared {
+		if value, ok := stored[key]; ok {
+			managed[key] = value
+		}
+	}
+	return managed
+}
+
+// validateComponentFlavor checks that the component's flavor belongs to its
+// type, since the server rejects a flavor of another type with a vague
+// error. Flavors that don't exist for any type are left to the server.
+func validateComponentFlavor(ctx context.Context, client *Client, d *schema.ResourceData) diag.Diagnostics {
+	if d.Get("skip_flavor_validation").(bool) {
+		return nil
+	}
+
+	componentType := d.Get("type").(string)
+	flavorName := d.Get("flavor").(string)
+	flavor, err := client.GetFlavor(ctx, componentType, flavorName)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error getting flavor: %w", err))
+	}
+	if flavor != nil {
+		return nil
+	}
+
+	flavors, err := client.ListFlavorsByName(ctx, flavorName)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error listing flavors: %w", err))
+	}
+	var types []string
+	for _, f := range flavors {
+		if f.Body != nil && f.Body.Type != componentType && !slices.Contains(types, f.Body.Type) {
+			types = append(types, f.Body.Type)
+		}
+	}
+	if len(types) == 0 {
+		return nil
+	}
+	return diag.Diagnostics{{
+		Severity:      diag.Error,
+		Summary:       fmt.Sprintf("Flavor %q doesn't belong to component type %s", flavorName, componentType),
+		Detail:        fmt.Sprintf("The flavor %q belongs to %s components, not %s components. Set skip_flavor_validation to skip this check.", flavorName, listItems(types), componentType),
+		AttributePath: cty.GetAttrPath("flavor"),
+	}}
+}
+
+// validateComponentConfiguration checks the configuration against the
+// configuration schema of the component's flavor, so that missing or
+// misspelled keys are reported before the request fails on the server.
+// Flavors that can't be found are not validated.
+func validateComponentConfiguration(ctx context.Context, client *Client, d *schema.ResourceData) diag.Diagnostics {
+	if d.Get("skip_config_validation").(bool) {
+		return nil
+	}
+
+	componentType := d.Get("type").(string)
+	flavorName := d.Get("flavor").(string)
+	flavor, err := client.GetFlavor(ctx, componentType, flavorName)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error getting flavor: %w", err))
+	}
+	if flavor == nil {
+		return nil
+	}
+
+	configuration := d.Get("configuration").(map[string]interface{})
+	_, known := flavor.ConfigDefaults()
+
+	var diags diag.Diagnostics
+	for _, key := range flavor.RequiredConfigKeys() {
+		if _, ok := configuration[key]; !ok {
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       fmt.Sprintf("Missing required configuration key %q", key),
+				Detail:        fmt.Sprintf("The %s flavor %q requires the configuration key %q. Set skip_config_validation to skip this check.", componentType, flavorName, key),
+				AttributePath: cty.GetAttrPath("configuration"),
+			})
+		}
+	}
+	if !flavor.AllowsAdditionalConfig() {
+		keys := make([]string, 0, len(configuration))
+		for key := range configuration {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if !known[key] {
+				diags = append(diags, diag.Diagnostic{
+					Severity:      diag.Error,
+					Summary:       fmt.Sprintf("Unknown configuration key %q", key),
+					Detail:        fmt.Sprintf("The %s flavor %q has no configuration key %q. Set skip_config_validation to skip this check.", componentType, flavorName, key),
+					AttributePath: cty.GetAttrPath("configuration").IndexString(key),
+				})
+			}
+		}
+	}
+	return diags
+}
+
 func resourceStackComponentDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	client := m.(*Client)
 
-	err := client.DeleteComponent(ctx, d.Id())
+	force := d.Get("force_delete").(bool)
+	if !force {
+		stacks, err := client.ListStacksByComponent(ctx, d.Id())
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("error listing stacks using the component: %w", err))
+		}
+		if len(stacks) > 0 {
+			names := make([]string, 0, len(stacks))
+			for _, stack := range stacks {
+				names = append(names, stack.Name)
+			}
+			return inUseDiagnostics("Component", "stack", names,
+				"Remove it from these stacks first, or set force_delete to delete it anyway.")
+		}
+	}
+
+	err := client.DeleteComponentWithOptions(ctx, d.Id(), force)
 	if err != nil {
 		return diag.FromErr(fmt.Errorf("error deleting component: %w", err))
 	}
@@ -277,4 +562,58 @@ func resourceStackComponentDelete(ctx context.Context, d *schema.ResourceData, m
 	return nil
 }
 
+// isConfigured reports whether the configuration sets an attribute, as
+// opposed to an Optional+Computed attribute that only holds the value read
+// from the server. Without the raw configuration, e.g. in unit tests, only
+// changed attributes count as configured.
+func isConfigured(d *schema.ResourceDiff, key string) bool {
+	config := d.GetRawConfig()
+	if config.IsNull() || !config.IsKnown() || !config.Type().IsObjectType() || !config.Type().HasAttribute(key) {
+		return d.HasChange(key)
+	}
+	return !config.GetAttr(key).IsNull()
+}
+
+// resolveConnectorResourceType determines the connector resource type that a
+// component should use with the given service connector. Connectors that
+// support a single resource type imply it, so an empty requested type is
+// resolved to that type. Connectors that support multiple resource types
+// require the type to be set explicitly and to be one they support.
+func resolveConnectorResourceType(ctx context.Context, client *Client, connectorID, requested string) (string, error) {
+	connector, err := client.GetServiceConnector(ctx, connectorID)
+	if err != nil {
+		return "", fmt.Errorf("error getting service connector: %w", err)
+	}
+	if connector == nil {
+		return "", fmt.Errorf("service connector not found: %s", connectorID)
+	}
+
+	var supported []string
+	if connector.Body != nil {
+		supported = connector.Body.ResourceTypes
+	}
+
+	if requested == "" {
+		switch len(supported) {
+		case 0:
+			return "", fmt.Errorf("service connector %s does not support any resource types", connectorID)
+		case 1:
+			return supported[0], nil
+		default:
+			return "", fmt.Errorf(
+				"service connector %s supports multiple resource types, connector_resource_type must be set to one of: %s",
+				connectorID, strings.Join(supported, ", "))
+		}
+	}
+
+	for _, t := range supported {
+		if t == requested {
+			return requested, nil
+		}
+	}
+	return "", fmt.Errorf(
+		"invalid connector_resource_type %q for service connector %s. Supported types are: %s",
+		requested, connectorID, strings.Join(supported, ", "))
+}
+
 // resource_stack_component.go
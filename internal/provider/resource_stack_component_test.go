@@ -3,11 +3,17 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
@@ -87,6 +93,304 @@ func TestAccStackComponent_withConnector(t *testing.T) {
 	})
 }
 
+func TestResolveConnectorResourceType(t *testing.T) {
+	connectors := map[string][]string{
+		"single":   {"s3-bucket"},
+		"multiple": {"docker-registry", "kubernetes-cluster"},
+	}
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/api/v1/service_connectors/")
+		resourceTypes, ok := connectors[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(ServiceConnectorResponse{
+			ID:   id,
+			Name: id,
+			Body: &ServiceConnectorResponseBody{ResourceTypes: resourceTypes},
+		})
+	}))
+
+	cases := []struct {
+		name      string
+		connector string
+		requested string
+		want      string
+		wantErr   bool
+	}{
+		{"implicit single type", "single", "", "s3-bucket", false},
+		{"explicit single type", "single", "s3-bucket", "s3-bucket", false},
+		{"invalid single type", "single", "gcs-bucket", "", true},
+		{"explicit multiple types", "multiple", "docker-registry", "docker-registry", false},
+		{"missing with multiple types", "multiple", "", "", true},
+		{"invalid multiple types", "multiple", "s3-bucket", "", true},
+		{"unknown connector", "missing", "", "", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := resolveConnectorResourceType(context.Background(), client, tc.connector, tc.requested)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got resource type %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("expected resource type %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestValidateComponentConfiguration(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/flavors" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		properties := map[string]interface{}{
+			"path":          map[string]interface{}{"type": "string"},
+			"client_kwargs": map[string]interface{}{"type": "object"},
+		}
+		schemas := map[string]map[string]interface{}{
+			"s3":    {"properties": properties, "required": []interface{}{"path"}, "additionalProperties": false},
+			"gcp":   {"properties": properties},
+			"azure": {"properties": properties, "additionalProperties": map[string]interface{}{"type": "string"}},
+			"local": {},
+		}
+		page := Page[FlavorResponse]{}
+		name := r.URL.Query().Get("name")
+		if configSchema, ok := schemas[name]; ok {
+			page.Items = []FlavorResponse{{
+				Name:     name,
+				Metadata: &FlavorResponseMetadata{ConfigSchema: configSchema},
+			}}
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+
+	cases := []struct {
+		name          string
+		flavor        string
+		configuration map[string]interface{}
+		skip          bool
+		wantPaths     []string
+	}{
+		{"valid", "s3", map[string]interface{}{"path": "s3://bucket"}, false, nil},
+		{"missing required key", "s3", map[string]interface{}{}, false, []string{"configuration"}},
+		{"misspelled key", "s3", map[string]interface{}{"path": "s3://bucket", "client_kwarg": "{}"}, false, []string{"configuration.client_kwarg"}},
+		{"skipped", "s3", map[string]interface{}{"pth": "s3://bucket"}, true, nil},
+		{"unknown flavor", "custom", map[string]interface{}{"anything": "x"}, false, nil},
+		{"additional properties unset", "gcp", map[string]interface{}{"path": "gs://bucket", "project": "p"}, false, nil},
+		{"additional properties schema", "azure", map[string]interface{}{"path": "az://bucket", "account": "a"}, false, nil},
+		{"empty schema", "local", map[string]interface{}{"path": "/tmp", "anything": "x"}, false, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, resourceStackComponent().Schema, map[string]interface{}{
+				"name":                   "store",
+				"type":                   "artifact_store",
+				"flavor":                 tc.flavor,
+				"configuration":          tc.configuration,
+				"skip_config_validation": tc.skip,
+			})
+			diags := validateComponentConfiguration(context.Background(), client, d)
+
+			var paths []string
+			for _, diag := range diags {
+				var parts []string
+				for _, step := range diag.AttributePath {
+					switch s := step.(type) {
+					case cty.GetAttrStep:
+						parts = append(parts, s.Name)
+					case cty.IndexStep:
+						parts = append(parts, s.Key.AsString())
+					}
+				}
+				paths = append(paths, strings.Join(parts, "."))
+			}
+			if strings.Join(paths, ",") != strings.Join(tc.wantPaths, ",") {
+				t.Errorf("expected diagnostics for %v, got %v", tc.wantPaths, diags)
+			}
+		})
+	}
+}
+
+func TestValidateComponentFlavor(t *testing.T) {
+	var requests []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/flavors" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		query := r.URL.Query()
+		requests = append(requests, query.Get("type")+"/"+query.Get("name"))
+		flavors := []FlavorResponse{
+			{Name: "s3", Body: &FlavorResponseBody{Type: "artifact_store"}},
+			{Name: "kubernetes", Body: &FlavorResponseBody{Type: "orchestrator"}},
+			{Name: "kubernetes", Body: &FlavorResponseBody{Type: "step_operator"}},
+		}
+		page := Page[FlavorResponse]{Index: 1, TotalPages: 1}
+		for _, flavor := range flavors {
+			if flavor.Name == query.Get("name") && (query.Get("type") == "" || flavor.Body.Type == query.Get("type")) {
+				page.Items = append(page.Items, flavor)
+			}
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+
+	cases := []struct {
+		name          string
+		componentType string
+		flavor        string
+		skip          bool
+		wantSummary   string
+	}{
+		{"matching type", "artifact_store", "s3", false, ""},
+		{"other type", "orchestrator", "s3", false, `Flavor "s3" doesn't belong to component type orchestrator`},
+		{"several other types", "artifact_store", "kubernetes", false, `Flavor "kubernetes" doesn't belong to component type artifact_store`},
+		{"skipped", "orchestrator", "s3", true, ""},
+		{"unknown flavor", "orchestrator", "custom", false, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, resourceStackComponent().Schema, map[string]interface{}{
+				"name":                   "component",
+				"type":                   tc.componentType,
+				"flavor":                 tc.flavor,
+				"skip_flavor_validation": tc.skip,
+			})
+			diags := validateComponentFlavor(context.Background(), client, d)
+			if tc.wantSummary == "" {
+				if len(diags) > 0 {
+					t.Errorf("expected no diagnostics, got %v", diags)
+				}
+				return
+			}
+			if len(diags) != 1 || diags[0].Summary != tc.wantSummary {
+				t.Fatalf("expected a diagnostic %q, got %v", tc.wantSummary, diags)
+			}
+			if step, ok := diags[0].AttributePath[0].(cty.GetAttrStep); !ok || step.Name != "flavor" {
+				t.Errorf("expected the diagnostic on the flavor, got %v", diags[0].AttributePath)
+			}
+		})
+	}
+	// Lookups are cached, including those that found no flavor
+	requests = nil
+	for _, flavor := range []string{"s3", "custom"} {
+		if _, err := client.GetFlavor(context.Background(), "orchestrator", flavor); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(requests) != 0 {
+		t.Errorf("expected cached flavor lookups, got requests %v", requests)
+	}
+}
+
+func TestResourceStackComponentUpdateConnector(t *testing.T) {
+	var updates []map[string]interface{}
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v1/service_connectors/conn-2":
+			json.NewEncoder(w).Encode(ServiceConnectorResponse{
+				ID:   "conn-2",
+				Body: &ServiceConnectorResponseBody{ResourceTypes: []string{"s3-bucket"}},
+			})
+		case r.Method == "PUT" && r.URL.Path == "/api/v1/components/comp":
+			var update map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&update)
+			updates = append(updates, update)
+			json.NewEncoder(w).Encode(ComponentResponse{ID: "comp"})
+		case r.Method == "GET" && r.URL.Path == "/api/v1/components/comp":
+			json.NewEncoder(w).Encode(ComponentResponse{ID: "comp", Metadata: &ComponentResponseMetadata{
+				Workspace: &WorkspaceResponse{Name: "default"},
+			}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	attach := map[string]interface{}{
+		"name":                   "store",
+		"type":                   "artifact_store",
+		"flavor":                 "s3",
+		"connector_id":           "conn-2",
+		"connector_resource_id":  "s3://bucket",
+		"skip_config_validation": true,
+	}
+	detach := map[string]interface{}{
+		"name":                   "store",
+		"type":                   "artifact_store",
+		"flavor":                 "s3",
+		"skip_config_validation": true,
+	}
+	for _, raw := range []map[string]interface{}{attach, detach} {
+		d := schema.TestResourceDataRaw(t, resourceStackComponent().Schema, raw)
+		d.SetId("comp")
+		if diags := resourceStackComponentUpdate(context.Background(), d, client); diags.HasError() {
+			t.Fatalf("unexpected error: %v", diags)
+		}
+	}
+
+	if len(updates) != 2 {
+		t.Fatalf("expected 2 updates, got %d", len(updates))
+	}
+	if updates[0]["connector"] != "conn-2" || updates[0]["connector_resource_id"] != "s3://bucket" || updates[0]["connector_resource_type"] != "s3-bucket" {
+		t.Errorf("expected the connector linkage to be sent, got %v", updates[0])
+	}
+	for _, key := range []string{"connector", "connector_resource_id", "connector_resource_type"} {
+		if v, ok := updates[1][key]; !ok || v != nil {
+			t.Errorf("expected %s to be sent as null to detach the connector, got %v", key, updates[1])
+		}
+	}
+}
+
+func TestResourceStackComponentDelete(t *testing.T) {
+	var deletes []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v1/stacks":
+			if r.URL.Query().Get("component_id") != "comp" {
+				t.Errorf("expected stacks to be filtered by component, got query %q", r.URL.RawQuery)
+			}
+			json.NewEncoder(w).Encode(Page[StackResponse]{
+				Index:      1,
+				TotalPages: 1,
+				Items:      []StackResponse{{ID: "s1", Name: "production"}, {ID: "s2", Name: "staging"}},
+			})
+		case r.Method == "DELETE" && r.URL.Path == "/api/v1/components/comp":
+			deletes = append(deletes, r.URL.RawQuery)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceStackComponent().Schema, map[string]interface{}{})
+	d.SetId("comp")
+	diags := resourceStackComponentDelete(context.Background(), d, client)
+	if !diags.HasError() || !strings.Contains(diags[0].Detail, "production, staging") {
+		t.Fatalf("expected an error naming the stacks, got %v", diags)
+	}
+	if len(deletes) != 0 {
+		t.Fatalf("expected the component not to be deleted, got %v", deletes)
+	}
+
+	d = schema.TestResourceDataRaw(t, resourceStackComponent().Schema, map[string]interface{}{
+		"force_delete": true,
+	})
+	d.SetId("comp")
+	if diags := resourceStackComponentDelete(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if len(deletes) != 1 || deletes[0] != "force=true" {
+		t.Errorf("expected a forced delete, got %v", deletes)
+	}
+}
+
 func testAccCheckStackComponentExists(n string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[n]
@@ -219,3 +523,225 @@ resource "zenml_stack_component" "test" {
 }
 `, workspace, workspace)
 }
+
+func TestResourceStackComponentTypeChangeForcesReplacement(t *testing.T) {
+	state := &terraform.InstanceState{
+		ID: "component-id",
+		Attributes: map[string]string{
+			"id":                     "component-id",
+			"workspace":              "default",
+			"name":                   "store",
+			"type":                   "artifact_store",
+			"flavor":                 "s3",
+			"skip_config_validation": "false",
+			"force_delete":           "false",
+		},
+	}
+
+	tests := []struct {
+		name       string
+		config     map[string]interface{}
+		requireNew bool
+	}{
+		{"rename", map[string]interface{}{"name": "renamed", "type": "artifact_store", "flavor": "s3"}, false},
+		{"retype", map[string]interface{}{"name": "store", "type": "orchestrator", "flavor": "s3"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := resourceStackComponent().Diff(context.Background(), state, terraform.NewResourceConfigRaw(tt.config), nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff.RequiresNew() != tt.requireNew {
+				t.Errorf("expected RequiresNew %t, got %t", tt.requireNew, diff.RequiresNew())
+			}
+		})
+	}
+}
+
+func TestResourceStackComponentUpdateConfigMergeStrategy(t *testing.T) {
+	cases := []struct {
+		strategy string
+		new      map[string]interface{}
+		want     map[string]interface{}
+	}{
+		{"replace", map[string]interface{}{"path": "s3://new"}, map[string]interface{}{"path": "s3://new"}},
+		{"replace", map[string]interface{}{}, map[string]interface{}{}},
+		{"merge", map[string]interface{}{"path": "s3://new"}, map[string]interface{}{"path": "s3://new", "external": "kept"}},
+		{"merge", map[string]interface{}{}, map[string]interface{}{"external": "kept"}},
+	}
+	for _, tc := range cases {
+		t.Run(fmt.Sprintf("%s %v", tc.strategy, tc.new), func(t *testing.T) {
+			stored := map[string]interface{}{"path": "s3://old", "region": "eu-west-1", "external": "kept"}
+			var sent map[string]interface{}
+			client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == "PUT" && r.URL.Path == "/api/v1/components/comp":
+					var update map[string]interface{}
+					json.NewDecoder(r.Body).Decode(&update)
+					var ok bool
+					if sent, ok = update["configuration"].(map[string]interface{}); !ok {
+						t.Errorf("expected the configuration to be sent, got %v", update)
+					}
+					stored = sent
+					json.NewEncoder(w).Encode(ComponentResponse{ID: "comp"})
+				case r.Method == "GET" && r.URL.Path == "/api/v1/components/comp":
+					json.NewEncoder(w).Encode(ComponentResponse{ID: "comp", Metadata: &ComponentResponseMetadata{
+						Workspace:     &WorkspaceResponse{Name: "default"},
+						Configuration: stored,
+					}})
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+
+			r := resourceStackComponent()
+			config := map[string]interface{}{
+				"name":                   "store",
+				"type":                   "artifact_store",
+				"flavor":                 "s3",
+				"config_merge_strategy":  tc.strategy,
+				"skip_config_validation": true,
+				"configuration":          map[string]interface{}{"path": "s3://old", "region": "eu-west-1"},
+			}
+			prior := schema.TestResourceDataRaw(t, r.Schema, config)
+			prior.SetId("comp")
+			state := prior.State()
+
+			config["configuration"] = tc.new
+			diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), client)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			d, err := schema.InternalMap(r.Schema).Data(state, diff)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diags := resourceStackComponentUpdate(context.Background(), d, client); diags.HasError() {
+				t.Fatalf("unexpected error: %v", diags)
+			}
+
+			if !reflect.DeepEqual(sent, tc.want) {
+				t.Errorf("expected configuration %v to be sent, got %v", tc.want, sent)
+			}
+			// Only the declared keys are read back
+			if got := d.Get("configuration").(map[string]interface{}); !reflect.DeepEqual(got, tc.new) {
+				t.Errorf("expected configuration %v in the state, got %v", tc.new, got)
+			}
+		})
+	}
+}
+
+func TestResourceStackComponentImport(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/api/v1/components/imported" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(ComponentResponse{
+			ID:   "imported",
+			Name: "store",
+			Body: &ComponentResponseBody{Type: "artifact_store", Flavor: "s3"},
+			Metadata: &ComponentResponseMetadata{
+				Workspace:     &WorkspaceResponse{Name: "default"},
+				Configuration: map[string]interface{}{"path": "s3://bucket", "key": "value"},
+				Labels:        map[string]string{"env": "prod"},
+			},
+		})
+	}))
+
+	r := resourceStackComponent()
+	d := r.TestResourceData()
+	d.SetId("imported")
+	imported, err := r.Importer.StateContext(context.Background(), d, client)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d = imported[0]
+	if diags := resourceStackComponentRead(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	expected := map[string]interface{}{"path": "s3://bucket", "key": "value"}
+	if configuration := d.Get("configuration"); !reflect.DeepEqual(configuration, expected) {
+		t.Errorf("expected the whole configuration to be imported, got %v", configuration)
+	}
+
+	// The configuration matching the imported component plans no changes
+	diff, err := r.Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":          "store",
+		"type":          "artifact_store",
+		"flavor":        "s3",
+		"configuration": map[string]interface{}{"path": "s3://bucket", "key": "value"},
+		"labels":        map[string]interface{}{"env": "prod"},
+	}), client)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff != nil && !diff.Empty() {
+		t.Errorf("expected no changes after the import, got %v", diff.Attributes)
+	}
+}
+
+func TestResourceStackComponentChangeConnector(t *testing.T) {
+	var update map[string]interface{}
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v1/service_connectors/conn-2":
+			json.NewEncoder(w).Encode(ServiceConnectorResponse{
+				ID:   "conn-2",
+				Body: &ServiceConnectorResponseBody{ResourceTypes: []string{"s3-bucket"}},
+			})
+		case r.Method == "PUT" && r.URL.Path == "/api/v1/components/comp":
+			json.NewDecoder(r.Body).Decode(&update)
+			json.NewEncoder(w).Encode(ComponentResponse{ID: "comp"})
+		case r.Method == "GET" && r.URL.Path == "/api/v1/components/comp":
+			resourceType := "s3-bucket"
+			json.NewEncoder(w).Encode(ComponentResponse{ID: "comp", Metadata: &ComponentResponseMetadata{
+				Workspace:             &WorkspaceResponse{Name: "default"},
+				Connector:             &ServiceConnectorResponse{ID: "conn-2"},
+				ConnectorResourceType: &resourceType,
+			}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	r := resourceStackComponent()
+	config := map[string]interface{}{
+		"name":                   "store",
+		"type":                   "artifact_store",
+		"flavor":                 "s3",
+		"connector_id":           "conn-1",
+		"skip_config_validation": true,
+	}
+	// The resource type of the previous connector was read back
+	prior := schema.TestResourceDataRaw(t, r.Schema, config)
+	prior.SetId("comp")
+	prior.Set("connector_resource_type", "gcs-bucket")
+	state := prior.State()
+
+	config["connector_id"] = "conn-2"
+	diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), client)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if attr := diff.Attributes["connector_resource_type"]; attr == nil || !attr.NewComputed {
+		t.Errorf("expected the resource type to be resolved again, got %v", attr)
+	}
+	d, err := schema.InternalMap(r.Schema).Data(state, diff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if diags := resourceStackComponentUpdate(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if update["connector"] != "conn-2" || update["connector_resource_type"] != "s3-bucket" {
+		t.Errorf("expected the resource type of the new connector to be sent, got %v", update)
+	}
+	if got := d.Get("connector_resource_type"); got != "s3-bucket" {
+		t.Errorf("expected the new resource type to be read back, got %q", got)
+	}
+}
@@ -2,11 +2,19 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
@@ -88,3 +96,544 @@ func testAccCheckStackExists(resourceName string) resource.TestCheckFunc {
 		return nil
 	}
 }
+
+func TestStackComponentChanges(t *testing.T) {
+	old := map[string]interface{}{
+		"orchestrator":   "orchestrator-1",
+		"artifact_store": "store-1",
+	}
+	inline := []interface{}{
+		map[string]interface{}{"id": "registry-1", "type": "container_registry"},
+	}
+
+	tests := []struct {
+		name        string
+		new         map[string]interface{}
+		wantAdded   map[string]string
+		wantRemoved []string
+	}{
+		{
+			name:      "add",
+			new:       map[string]interface{}{"orchestrator": "orchestrator-1", "artifact_store": "store-1", "step_operator": "step-1"},
+			wantAdded: map[string]string{"step_operator": "step-1"},
+		},
+		{
+			name:        "remove",
+			new:         map[string]interface{}{"artifact_store": "store-1"},
+			wantAdded:   map[string]string{},
+			wantRemoved: []string{"orchestrator-1"},
+		},
+		{
+			name:        "replace",
+			new:         map[string]interface{}{"orchestrator": "orchestrator-2", "artifact_store": "store-1"},
+			wantAdded:   map[string]string{"orchestrator": "orchestrator-2"},
+			wantRemoved: []string{"orchestrator-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			components, added, removed := stackComponentChanges(old, tt.new, inline)
+
+			// The update body holds the complete set of components
+			want := map[string][]string{"container_registry": {"registry-1"}}
+			for compType, id := range tt.new {
+				want[compType] = []string{id.(string)}
+			}
+			body, _ := json.Marshal(StackUpdate{Components: components})
+			wantBody, _ := json.Marshal(StackUpdate{Components: want})
+			if string(body) != string(wantBody) {
+				t.Errorf("expected update %s, got %s", wantBody, body)
+			}
+			if !reflect.DeepEqual(added, tt.wantAdded) {
+				t.Errorf("expected added %v, got %v", tt.wantAdded, added)
+			}
+			if !reflect.DeepEqual(removed, tt.wantRemoved) {
+				t.Errorf("expected removed %v, got %v", tt.wantRemoved, removed)
+			}
+		})
+	}
+}
+
+func TestResourceStackUpdateMissingComponent(t *testing.T) {
+	updated := false
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v1/components/store-1":
+			json.NewEncoder(w).Encode(ComponentResponse{ID: "store-1"})
+		case r.Method == "PUT":
+			updated = true
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceStack().Schema, map[string]interface{}{
+		"name": "test",
+		"components": map[string]interface{}{
+			"artifact_store": "store-1",
+			"orchestrator":   "deleted",
+		},
+	})
+	d.SetId("stack")
+
+	diags := resourceStackUpdate(context.Background(), d, client)
+	if len(diags) != 1 || diags[0].Summary != "Component deleted does not exist" {
+		t.Fatalf("expected a diagnostic for the missing component, got %v", diags)
+	}
+	if updated {
+		t.Error("expected the stack not to be updated")
+	}
+}
+
+func TestResourceStackTrashAndRestore(t *testing.T) {
+	var requests []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v1/stacks":
+			if r.URL.Query().Get("trashed") != "true" {
+				t.Errorf("expected only trashed stacks to be listed, got %s", r.URL.RawQuery)
+			}
+			json.NewEncoder(w).Encode(Page[StackResponse]{Items: []StackResponse{{ID: "trashed", Name: "test"}}})
+		case r.Method == "POST" && r.URL.Path == "/api/v1/stacks/trashed/restore",
+			r.Method == "PUT" && r.URL.Path == "/api/v1/stacks/trashed",
+			r.Method == "GET" && r.URL.Path == "/api/v1/stacks/trashed":
+			json.NewEncoder(w).Encode(StackResponse{ID: "trashed", Name: "test"})
+		case r.Method == "POST" && r.URL.Path == "/api/v1/stacks/trashed/trash":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceStack().Schema, map[string]interface{}{
+		"name":          "test",
+		"deletion_mode": "trash",
+	})
+	if diags := resourceStackCreate(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if d.Id() != "trashed" {
+		t.Errorf("expected the trashed stack to be restored, got %q", d.Id())
+	}
+
+	if diags := resourceStackDelete(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if last := requests[len(requests)-1]; last != "POST /api/v1/stacks/trashed/trash" {
+		t.Errorf("expected the stack to be trashed, got %s", last)
+	}
+}
+
+func TestResourceStackImport(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/api/v1/stacks/imported" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(StackResponse{
+			ID:   "imported",
+			Name: "production",
+			Metadata: &StackResponseMetadata{
+				Workspace: &WorkspaceResponse{Name: "ml"},
+				Components: map[string][]ComponentResponse{
+					"orchestrator":   {{ID: "orchestrator-id", Name: "kubernetes"}},
+					"artifact_store": {{ID: "store-id", Name: "s3"}},
+				},
+			},
+		})
+	}))
+
+	r := resourceStack()
+	d := r.TestResourceData()
+	d.SetId("imported")
+	imported, err := r.Importer.StateContext(context.Background(), d, client)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d = imported[0]
+	if diags := resourceStackRead(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	expected := map[string]interface{}{"orchestrator": "orchestrator-id", "artifact_store": "store-id"}
+	if components := d.Get("components"); !reflect.DeepEqual(components, expected) {
+		t.Errorf("expected the components to be imported as references, got %v", components)
+	}
+	if blocks := d.Get("component").([]interface{}); len(blocks) != 0 {
+		t.Errorf("expected no component blocks, got %v", blocks)
+	}
+
+	// The configuration matching the imported stack plans no changes
+	diff, err := r.Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":      "production",
+		"workspace": "ml",
+		"components": map[string]interface{}{
+			"orchestrator":   "orchestrator-id",
+			"artifact_store": "store-id",
+		},
+	}), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff != nil && !diff.Empty() {
+		t.Errorf("expected no changes after the import, got %v", diff.Attributes)
+	}
+}
+
+func TestResourceStackValidate(t *testing.T) {
+	orchestrator := "orchestrator"
+	invalid := StackValidationResponse{
+		Valid: false,
+		Messages: []StackValidationMessage{
+			{Severity: "error", Message: "the local orchestrator can't use a remote artifact store", ComponentType: &orchestrator},
+			{Severity: "warning", Message: "no container registry configured"},
+		},
+	}
+
+	cases := []struct {
+		name       string
+		validation *StackValidationResponse
+		failure    string
+		severities []diag.Severity
+	}{
+		{"invalid", &invalid, "error", []diag.Severity{diag.Error, diag.Warning}},
+		{"invalid with warnings", &invalid, "warning", []diag.Severity{diag.Warning, diag.Warning}},
+		{"invalid without messages", &StackValidationResponse{Valid: false}, "error", []diag.Severity{diag.Error}},
+		{"valid", &StackValidationResponse{Valid: true}, "error", nil},
+		{"unsupported", nil, "error", nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == "POST" && r.URL.Path == "/api/v1/workspaces/default/stacks",
+					r.Method == "GET" && r.URL.Path == "/api/v1/stacks/stack-id":
+					json.NewEncoder(w).Encode(StackResponse{ID: "stack-id", Name: "test"})
+				case r.Method == "GET" && r.URL.Path == "/api/v1/stacks/stack-id/validate" && tc.validation != nil:
+					json.NewEncoder(w).Encode(tc.validation)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+
+			d := schema.TestResourceDataRaw(t, resourceStack().Schema, map[string]interface{}{
+				"name":               "test",
+				"validate":           true,
+				"validation_failure": tc.failure,
+			})
+			diags := resourceStackCreate(context.Background(), d, client)
+			var severities []diag.Severity
+			for _, d := range diags {
+				severities = append(severities, d.Severity)
+			}
+			if !reflect.DeepEqual(severities, tc.severities) {
+				t.Errorf("expected diagnostics with severities %v, got %v", tc.severities, diags)
+			}
+			if d.Id() != "stack-id" {
+				t.Errorf("expected the stack to be created, got %q", d.Id())
+			}
+		})
+	}
+}
+
+func TestResourceStackRename(t *testing.T) {
+	var updates []map[string]interface{}
+	name := "old"
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "PUT" && r.URL.Path == "/api/v1/stacks/stack-id":
+			var update map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&update)
+			updates = append(updates, update)
+			if update["name"] == "taken" {
+				w.WriteHeader(http.StatusConflict)
+				json.NewEncoder(w).Encode(map[string]string{"detail": "stack already exists"})
+				return
+			}
+			name = update["name"].(string)
+			json.NewEncoder(w).Encode(StackResponse{ID: "stack-id", Name: name})
+		case r.Method == "GET" && r.URL.Path == "/api/v1/stacks/stack-id":
+			json.NewEncoder(w).Encode(StackResponse{
+				ID:       "stack-id",
+				Name:     name,
+				Metadata: &StackResponseMetadata{Components: StackComponents{"orchestrator": {{ID: "orchestrator-1", Name: "k8s"}}}},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	r := resourceStack()
+	config := map[string]interface{}{
+		"name":       "old",
+		"components": map[string]interface{}{"orchestrator": "orchestrator-1"},
+	}
+	prior := schema.TestResourceDataRaw(t, r.Schema, config)
+	prior.SetId("stack-id")
+	state := prior.State()
+
+	for _, newName := range []string{"new", "taken"} {
+		config["name"] = newName
+		diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), client)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if diff.RequiresNew() {
+			t.Fatalf("expected a rename to be an in-place update, got %v", diff.Attributes)
+		}
+		d, err := schema.InternalMap(r.Schema).Data(state, diff)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		diags := resourceStackUpdate(context.Background(), d, client)
+		if newName == "taken" {
+			if len(diags) != 1 || diags[0].Summary != `Stack name "taken" is already taken` {
+				t.Fatalf("expected a diagnostic for the conflicting name, got %v", diags)
+			}
+			continue
+		}
+		if diags.HasError() {
+			t.Fatalf("unexpected error: %v", diags)
+		}
+		if d.Id() != "stack-id" || d.Get("name") != "new" || d.Get("components.orchestrator") != "orchestrator-1" {
+			t.Errorf("expected the stack to keep its ID and components, got %s %v %v", d.Id(), d.Get("name"), d.Get("components"))
+		}
+	}
+
+	want := []map[string]interface{}{{"name": "new"}, {"name": "taken"}}
+	if !reflect.DeepEqual(updates, want) {
+		t.Errorf("expected only the name to be updated, got %v", updates)
+	}
+}
+
+func TestResourceStackShared(t *testing.T) {
+	var updates []map[string]interface{}
+	shared := false
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "PUT" && r.URL.Path == "/api/v1/stacks/stack-id":
+			var update map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&update)
+			updates = append(updates, update)
+			shared = update["is_shared"].(bool)
+			json.NewEncoder(w).Encode(StackResponse{ID: "stack-id", Name: "stack"})
+		case r.Method == "GET" && r.URL.Path == "/api/v1/stacks/stack-id":
+			json.NewEncoder(w).Encode(StackResponse{
+				ID:   "stack-id",
+				Name: "stack",
+				Body: &StackResponseBody{IsShared: &shared},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	r := resourceStack()
+	config := map[string]interface{}{"name": "stack"}
+	prior := schema.TestResourceDataRaw(t, r.Schema, config)
+	prior.SetId("stack-id")
+	state := prior.State()
+
+	for _, visibility := range []bool{true, false} {
+		config["shared"] = visibility
+		diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), client)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if diff.RequiresNew() {
+			t.Fatalf("expected a visibility change to be an in-place update, got %v", diff.Attributes)
+		}
+		d, err := schema.InternalMap(r.Schema).Data(state, diff)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if diags := resourceStackUpdate(context.Background(), d, client); diags.HasError() {
+			t.Fatalf("unexpected error: %v", diags)
+		}
+		if d.Get("shared") != visibility {
+			t.Errorf("expected shared to be read back as %v, got %v", visibility, d.Get("shared"))
+		}
+		state = d.State()
+	}
+
+	want := []map[string]interface{}{{"is_shared": true}, {"is_shared": false}}
+	if !reflect.DeepEqual(updates, want) {
+		t.Errorf("expected only the visibility to be updated, got %v", updates)
+	}
+}
+
+func TestResourceStackComponentsValidation(t *testing.T) {
+	const orchestrator = "0b7ac5c2-4a1e-4d4e-9a43-0c3a6f5d2b11"
+	const artifactStore = "5f0c3d8e-1b2a-4c6d-8e9f-a1b2c3d4e5f6"
+	tests := []struct {
+		name       string
+		components map[string]interface{}
+		summaries  []string
+		paths      []cty.Path
+	}{
+		{
+			name:       "valid",
+			components: map[string]interface{}{"orchestrator": orchestrator, "artifact_store": artifactStore},
+		},
+		{
+			name:       "duplicate",
+			components: map[string]interface{}{"orchestrator": orchestrator, "artifact_store": orchestrator},
+			summaries:  []string{"Component " + orchestrator + " is referenced more than once"},
+			paths:      []cty.Path{cty.GetAttrPath("components").IndexString("orchestrator")},
+		},
+		{
+			name:       "malformed",
+			components: map[string]interface{}{"orchestrator": "my-orchestrator", "artifact_store": artifactStore},
+			summaries:  []string{`Invalid component ID "my-orchestrator"`},
+			paths:      []cty.Path{cty.GetAttrPath("components").IndexString("orchestrator")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := terraform.NewResourceConfigRaw(map[string]interface{}{
+				"name":       "stack",
+				"components": tt.components,
+			})
+			diags := resourceStack().Validate(config)
+			if len(diags) != len(tt.summaries) {
+				t.Fatalf("expected %d diagnostics, got %v", len(tt.summaries), diags)
+			}
+			for i, d := range diags {
+				if d.Summary != tt.summaries[i] || !d.AttributePath.Equals(tt.paths[i]) {
+					t.Errorf("expected %q on %#v, got %q on %#v", tt.summaries[i], tt.paths[i], d.Summary, d.AttributePath)
+				}
+			}
+		})
+	}
+
+	// IDs of components created in the same apply are validated once known.
+	// The SDK marks unknown values with this placeholder
+	const unknown = "74D93920-ED26-11E3-AC10-0800200C9A66"
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":       "stack",
+		"components": map[string]interface{}{"orchestrator": unknown, "artifact_store": unknown},
+	})
+	if diags := resourceStack().Validate(config); len(diags) != 0 {
+		t.Errorf("expected unknown components not to be validated, got %v", diags)
+	}
+}
+
+func TestResourceStackUpdateLabels(t *testing.T) {
+	tests := []struct {
+		name          string
+		prior         map[string]interface{}
+		labels        map[string]interface{}
+		defaultLabels map[string]string
+		want          string
+	}{
+		{"add", nil, map[string]interface{}{"env": "dev"}, nil, `{"env":"dev"}`},
+		{"change", map[string]interface{}{"env": "dev"}, map[string]interface{}{"env": "prod"}, nil, `{"env":"prod"}`},
+		{"remove", map[string]interface{}{"env": "dev", "team": "ml"}, map[string]interface{}{"env": "dev"}, nil, `{"env":"dev"}`},
+		{"remove all", map[string]interface{}{"env": "dev"}, nil, nil, `{}`},
+		{"unchanged", map[string]interface{}{"env": "dev"}, map[string]interface{}{"env": "dev"}, nil, ``},
+		{"default labels", map[string]interface{}{"env": "dev"}, map[string]interface{}{"env": "prod"}, map[string]string{"owner": "platform", "env": "default"}, `{"env":"prod","owner":"platform"}`},
+		{"remove all with default labels", map[string]interface{}{"env": "dev"}, nil, map[string]string{"owner": "platform"}, `{"owner":"platform"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]json.RawMessage
+			var labels map[string]string
+			client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == "PUT" && r.URL.Path == "/api/v1/stacks/stack-id":
+					json.NewDecoder(r.Body).Decode(&body)
+					json.Unmarshal(body["labels"], &labels)
+					json.NewEncoder(w).Encode(StackResponse{ID: "stack-id", Name: "renamed"})
+				case r.Method == "GET" && r.URL.Path == "/api/v1/stacks/stack-id":
+					json.NewEncoder(w).Encode(StackResponse{
+						ID:       "stack-id",
+						Name:     "renamed",
+						Metadata: &StackResponseMetadata{Labels: labels},
+					})
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			client.DefaultLabels = tt.defaultLabels
+
+			r := resourceStack()
+			config := map[string]interface{}{"name": "stack"}
+			if tt.prior != nil {
+				config["labels"] = tt.prior
+			}
+			prior := schema.TestResourceDataRaw(t, r.Schema, config)
+			prior.SetId("stack-id")
+			state := prior.State()
+
+			delete(config, "labels")
+			if tt.labels != nil {
+				config["labels"] = tt.labels
+			}
+			// Rename the stack too, so that unchanged labels are updated
+			// along with something else
+			config["name"] = "renamed"
+			diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), client)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			d, err := schema.InternalMap(r.Schema).Data(state, diff)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diags := resourceStackUpdate(context.Background(), d, client); diags.HasError() {
+				t.Fatalf("unexpected error: %v", diags)
+			}
+			if got := string(body["labels"]); got != tt.want {
+				t.Errorf("expected labels %s in the update, got %s", tt.want, got)
+			}
+			// Default labels don't show up as changes to the configuration
+			if tt.want != "" {
+				want := tt.labels
+				if want == nil {
+					want = map[string]interface{}{}
+				}
+				if got := d.Get("labels"); !reflect.DeepEqual(got, want) {
+					t.Errorf("expected the configured labels %v to be read back, got %v", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestResourceStackCreateTimeout(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/api/v1/workspaces/default/stacks":
+			json.NewEncoder(w).Encode(StackResponse{ID: "stack-id", Name: "stack"})
+		default:
+			// The created stack never becomes readable
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	client.StackWaitInterval = 10 * time.Millisecond
+
+	r := resourceStack()
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":     "stack",
+		"timeouts": map[string]interface{}{"create": "100ms"},
+	})
+	diff, err := r.Diff(context.Background(), nil, config, client)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	start := time.Now()
+	_, diags := r.Apply(context.Background(), nil, diff, client)
+	if elapsed := time.Since(start); elapsed > client.StackWaitTimeout/2 {
+		t.Errorf("expected the create timeout to cut the wait for the stack short, took %s", elapsed)
+	}
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, context.DeadlineExceeded.Error()) {
+		t.Errorf("expected a deadline error, got %v", diags)
+	}
+}
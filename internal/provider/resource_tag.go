@@ -0,0 +1,138 @@
+// resource_tag.go
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// tagColors are the colors ZenML can display tags in.
+var tagColors = []string{
+	"grey",
+	"purple",
+	"red",
+	"green",
+	"yellow",
+	"orange",
+	"lime",
+	"teal",
+	"turquoise",
+	"magenta",
+	"blue",
+}
+
+func resourceTag() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceTagCreate,
+		ReadContext:   resourceTagRead,
+		UpdateContext: resourceTagUpdate,
+		DeleteContext: resourceTagDelete,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"color": {
+				Type:     schema.TypeString,
+				Optional: true,
+				// The server picks a random color if none is given
+				Computed:     true,
+				Description:  "The color the tag is displayed in",
+				ValidateFunc: validation.StringInSlice(tagColors, false),
+			},
+			"tagged_count": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "The number of entities the tag is attached to",
+			},
+		},
+
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+	}
+}
+
+func resourceTagCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	tag := TagRequest{
+		Name: d.Get("name").(string),
+	}
+
+	if v, ok := d.GetOk("color"); ok {
+		color := v.(string)
+		tag.Color = &color
+	}
+
+	resp, err := client.CreateTag(ctx, tag)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error creating tag: %w", err))
+	}
+
+	d.SetId(resp.ID)
+	return resourceTagRead(ctx, d, m)
+}
+
+func resourceTagRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	tag, err := client.GetTag(ctx, d.Id())
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error getting tag: %w", err))
+	}
+	if tag == nil {
+		// Handle 404 by removing from state
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("name", tag.Name)
+
+	if tag.Body != nil {
+		d.Set("color", tag.Body.Color)
+		d.Set("tagged_count", tag.Body.TaggedCount)
+	}
+
+	return nil
+}
+
+func resourceTagUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	update := TagUpdate{}
+
+	if d.HasChange("name") {
+		name := d.Get("name").(string)
+		update.Name = &name
+	}
+
+	if d.HasChange("color") {
+		color := d.Get("color").(string)
+		update.Color = &color
+	}
+
+	_, err := client.UpdateTag(ctx, d.Id(), update)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error updating tag: %w", err))
+	}
+
+	return resourceTagRead(ctx, d, m)
+}
+
+func resourceTagDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	err := client.DeleteTag(ctx, d.Id())
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error deleting tag: %w", err))
+	}
+
+	d.SetId("")
+	return nil
+}
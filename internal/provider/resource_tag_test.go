@@ -0,0 +1,54 @@
+// internal/provider/resource_tag_test.go
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestResourceTagUpdate(t *testing.T) {
+	if resourceTag().Schema["color"].ForceNew {
+		t.Fatalf("expected color changes to be applied in place")
+	}
+
+	var methods []string
+	var update TagUpdate
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		if r.URL.Path != "/api/v1/tags/tag-id" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == "PUT" {
+			json.NewDecoder(r.Body).Decode(&update)
+		}
+		json.NewEncoder(w).Encode(TagResponse{
+			ID:   "tag-id",
+			Name: "production",
+			Body: &TagResponseBody{Color: "green", TaggedCount: 3},
+		})
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceTag().Schema, map[string]interface{}{
+		"name":  "production",
+		"color": "green",
+	})
+	d.SetId("tag-id")
+
+	if diags := resourceTagUpdate(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if len(methods) != 2 || methods[0] != "PUT" || methods[1] != "GET" {
+		t.Errorf("expected the tag to be updated in place, got requests %v", methods)
+	}
+	if update.Color == nil || *update.Color != "green" {
+		t.Errorf("expected the color to be sent, got %+v", update)
+	}
+	if d.Id() != "tag-id" || d.Get("tagged_count").(int) != 3 {
+		t.Errorf("expected the tag to be read back, got id %q and tagged_count %v", d.Id(), d.Get("tagged_count"))
+	}
+}
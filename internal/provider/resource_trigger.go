@@ -0,0 +1,248 @@
+// resource_trigger.go
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func resourceTrigger() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceTriggerCreate,
+		ReadContext:   resourceTriggerRead,
+		UpdateContext: resourceTriggerUpdate,
+		DeleteContext: resourceTriggerDelete,
+
+		Schema: map[string]*schema.Schema{
+			"workspace": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "default",
+				ForceNew: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"event_source_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The ID of the event source the trigger listens to",
+			},
+			"event_filter": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Description:      "The filter events must match to run the action, as a JSON object",
+				ValidateFunc:     validation.StringIsJSON,
+				DiffSuppressFunc: suppressEquivalentJSON,
+			},
+			"action_flavor": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The flavor of the action, e.g. 'builtin'",
+			},
+			"action_subtype": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The subtype of the action, e.g. 'pipeline_run'",
+			},
+			"action": {
+				Type:     schema.TypeString,
+				Required: true,
+				// The action configuration may contain secrets
+				Sensitive:        true,
+				Description:      "The action configuration as a JSON object",
+				ValidateFunc:     validation.StringIsJSON,
+				DiffSuppressFunc: suppressEquivalentJSON,
+			},
+			"is_active": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+			"created": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+	}
+}
+
+func resourceTriggerCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	// Get the current user
+	user, err := client.GetCurrentUser(ctx)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error getting current user: %w", err))
+	}
+
+	workspaceName := d.Get("workspace").(string)
+
+	// Get the workspace ID
+	workspace, err := client.GetWorkspaceByName(ctx, workspaceName)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error getting workspace: %w", err))
+	}
+	if workspace == nil {
+		return diag.FromErr(fmt.Errorf("workspace not found: %s", workspaceName))
+	}
+
+	eventFilter, err := expandJSONObject(d, "event_filter")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	action, err := expandJSONObject(d, "action")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	trigger := TriggerRequest{
+		User:          user.ID,
+		Workspace:     workspace.ID,
+		Name:          d.Get("name").(string),
+		Description:   d.Get("description").(string),
+		EventSourceID: d.Get("event_source_id").(string),
+		EventFilter:   eventFilter,
+		ActionFlavor:  d.Get("action_flavor").(string),
+		ActionSubtype: d.Get("action_subtype").(string),
+		Action:        action,
+	}
+
+	resp, err := client.CreateTrigger(ctx, trigger)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error creating trigger: %w", err))
+	}
+
+	d.SetId(resp.ID)
+
+	// Triggers are created active
+	if !d.Get("is_active").(bool) {
+		isActive := false
+		if _, err := client.UpdateTrigger(ctx, resp.ID, TriggerUpdate{IsActive: &isActive}); err != nil {
+			return diag.FromErr(fmt.Errorf("error deactivating trigger: %w", err))
+		}
+	}
+
+	return resourceTriggerRead(ctx, d, m)
+}
+
+func resourceTriggerRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	trigger, err := client.GetTrigger(ctx, d.Id())
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error getting trigger: %w", err))
+	}
+	if trigger == nil {
+		// Handle 404 by removing from state
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("name", trigger.Name)
+
+	if trigger.Body != nil {
+		d.Set("action_flavor", trigger.Body.ActionFlavor)
+		d.Set("action_subtype", trigger.Body.ActionSubtype)
+		d.Set("is_active", trigger.Body.IsActive)
+		d.Set("created", trigger.Body.Created)
+	}
+
+	if trigger.Metadata != nil {
+		if trigger.Metadata.Workspace != nil && trigger.Metadata.Workspace.Name != "default" {
+			d.Set("workspace", trigger.Metadata.Workspace.Name)
+		}
+		d.Set("description", trigger.Metadata.Description)
+		if trigger.Metadata.EventSource != nil {
+			d.Set("event_source_id", trigger.Metadata.EventSource.ID)
+		}
+		// An empty filter matches all events, like an unset one
+		if len(trigger.Metadata.EventFilter) > 0 {
+			if err := flattenJSONObject(d, "event_filter", trigger.Metadata.EventFilter); err != nil {
+				return diag.FromErr(err)
+			}
+		}
+		if err := flattenJSONObject(d, "action", trigger.Metadata.Action); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	return nil
+}
+
+func resourceTriggerUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	update := TriggerUpdate{}
+
+	if d.HasChange("name") {
+		name := d.Get("name").(string)
+		update.Name = &name
+	}
+
+	if d.HasChange("description") {
+		description := d.Get("description").(string)
+		update.Description = &description
+	}
+
+	if d.HasChange("event_filter") {
+		eventFilter, err := expandJSONObject(d, "event_filter")
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if eventFilter == nil {
+			// Removing the filter makes the trigger match all events
+			eventFilter = map[string]interface{}{}
+		}
+		update.EventFilter = eventFilter
+	}
+
+	if d.HasChange("action") {
+		action, err := expandJSONObject(d, "action")
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		update.Action = action
+	}
+
+	if d.HasChange("is_active") {
+		isActive := d.Get("is_active").(bool)
+		update.IsActive = &isActive
+	}
+
+	_, err := client.UpdateTrigger(ctx, d.Id(), update)
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error updating trigger: %w", err))
+	}
+
+	return resourceTriggerRead(ctx, d, m)
+}
+
+func resourceTriggerDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	err := client.DeleteTrigger(ctx, d.Id())
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("error deleting trigger: %w", err))
+	}
+
+	d.SetId("")
+	return nil
+}
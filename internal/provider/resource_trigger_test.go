@@ -0,0 +1,61 @@
+// internal/provider/resource_trigger_test.go
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestResourceTriggerCreate(t *testing.T) {
+	var created TriggerRequest
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v1/current-user":
+			json.NewEncoder(w).Encode(UserResponse{ID: "user-id"})
+		case r.Method == "GET" && r.URL.Path == "/api/v1/workspaces/default":
+			json.NewEncoder(w).Encode(WorkspaceResponse{ID: "workspace-id", Name: "default"})
+		case r.Method == "POST" && r.URL.Path == "/api/v1/triggers":
+			json.NewDecoder(r.Body).Decode(&created)
+			json.NewEncoder(w).Encode(TriggerResponse{ID: "trigger-id", Name: created.Name})
+		case r.Method == "GET" && r.URL.Path == "/api/v1/triggers/trigger-id":
+			json.NewEncoder(w).Encode(TriggerResponse{
+				ID:   "trigger-id",
+				Name: "on-push",
+				Body: &TriggerResponseBody{ActionFlavor: "builtin", ActionSubtype: "pipeline_run", IsActive: true},
+				Metadata: &TriggerResponseMetadata{
+					EventFilter: map[string]interface{}{"branch": "main"},
+					Action:      map[string]interface{}{"template_id": "template-id"},
+					EventSource: &EventSourceResponse{ID: "source-id"},
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceTrigger().Schema, map[string]interface{}{
+		"name":            "on-push",
+		"event_source_id": "source-id",
+		"event_filter":    `{"branch": "main"}`,
+		"action_flavor":   "builtin",
+		"action_subtype":  "pipeline_run",
+		"action":          `{"template_id": "template-id"}`,
+	})
+	if diags := resourceTriggerCreate(context.Background(), d, client); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	if created.EventSourceID != "source-id" || created.EventFilter["branch"] != "main" || created.Action["template_id"] != "template-id" {
+		t.Errorf("unexpected trigger request %+v", created)
+	}
+	if d.Id() != "trigger-id" {
+		t.Errorf("expected the trigger ID to be set, got %q", d.Id())
+	}
+	if got := d.Get("action").(string); got != `{"template_id":"template-id"}` {
+		t.Errorf("expected the action to be read back, got %s", got)
+	}
+}
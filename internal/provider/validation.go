@@ -96,6 +96,12 @@ var (
 		"step_operator",
 		"model_registry",
 	}
+
+	validModelStages = []string{
+		"none",
+		"staging",
+		"production",
+	}
 )
 
 func validateServiceConnector(d *schema.ResourceDiff) error {